@@ -0,0 +1,33 @@
+package conv
+
+import "reflect"
+
+// BindMap converts a map to a value of type T, T is usually a struct or a pointer to a struct.
+// It is equivalent to BindMapWith[T](new(Conv), m) .
+//
+// e.g.
+//
+//	user, err := conv.BindMap[DemoUser](m)
+func BindMap[T any](m map[string]interface{}) (T, error) {
+	return BindMapWith[T](_defaultConv, m)
+}
+
+// BindMapWith converts a map to a value of type T with the given Conv, using Conv.ConvertType() .
+// T can be any type which a map can be converted to, such as a struct, a pointer to a struct, or another map.
+// If T is a pointer type, a pointer to a new value is returned.
+func BindMapWith[T any](c *Conv, m map[string]interface{}) (T, error) {
+	var res T
+	typ := reflect.TypeOf(&res).Elem()
+
+	v, err := c.ConvertType(m, typ)
+	if err != nil || v == nil {
+		return res, err
+	}
+
+	// A custom converter may return a value of another type.
+	res, ok := v.(T)
+	if !ok {
+		return res, errForFunction("BindMapWith", "the converted value is %T, not %v", v, typ)
+	}
+	return res, nil
+}
@@ -0,0 +1,79 @@
+package conv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBindMap(t *testing.T) {
+	type User struct {
+		Name string
+		Age  int
+	}
+
+	m := map[string]interface{}{"Name": "Bob", "Age": "12"}
+
+	t.Run("struct", func(t *testing.T) {
+		got, err := BindMap[User](m)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := (User{"Bob", 12}); got != want {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		got, err := BindMap[*User](m)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := (User{"Bob", 12}); got == nil || *got != want {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("map", func(t *testing.T) {
+		got, err := BindMap[map[string]string](m)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := map[string]string{"Name": "Bob", "Age": "12"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("nil-map", func(t *testing.T) {
+		got, err := BindMap[*User](nil)
+		if err == nil || got != nil {
+			t.Errorf("want error, got %v", got)
+		}
+	})
+
+	t.Run("non-struct", func(t *testing.T) {
+		got, err := BindMap[int](m)
+		if err == nil || got != 0 {
+			t.Errorf("want error, got %v", got)
+		}
+	})
+
+	t.Run("with-conv", func(t *testing.T) {
+		got, err := BindMapWith[User](_caseInsensitiveConv, map[string]interface{}{"name": "Bob"})
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := (User{Name: "Bob"}); got != want {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("bad-converter", func(t *testing.T) {
+		c := &Conv{Conf: Config{CustomConverters: []ConvertFunc{
+			func(value interface{}, typ reflect.Type) (interface{}, error) { return 1, nil },
+		}}}
+		got, err := BindMapWith[User](c, m)
+		if want := "conv.BindMapWith: the converted value is int, not conv.User"; err == nil || err.Error() != want || got != (User{}) {
+			t.Errorf("want error %v, got %v, %v", want, got, err)
+		}
+	})
+}
@@ -2,9 +2,24 @@
 package conv
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net/url"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Conv provides a group of functions to convert between simple types, maps, slices and structs.
@@ -26,6 +41,31 @@ import (
 type Conv struct {
 	// Conf is used to customize the conversion behavior.
 	Conf Config
+
+	// path is the path of the value being converted, such as 'A.B[0]'. It is empty at the root level.
+	// A Conv with a non-empty path is a copy of the one used by the caller, see Conv.at() .
+	path string
+
+	// fieldPath is like path, but without the indexes of slices and the keys of maps, such as 'A.B'.
+	// It is used to look up Conv.Conf.InterfaceFieldTypes .
+	fieldPath string
+
+	// formatTime specifies whether StructToMap() converts times to strings. It is set by Conv.forField() .
+	formatTime bool
+
+	// unixTime specifies whether StructToMap() converts times to Unix timestamps. It is set by Conv.forField() for
+	// fields with the 'unix' tag option.
+	unixTime bool
+
+	// identities maps the source pointers to the converted ones, see Conv.Conf.PreserveIdentity . It is created by
+	// the root ConvertType() call, and shared by the nested conversions.
+	identities map[identityKey]reflect.Value
+
+	// mapDepth is the number of levels of structs being converted by StructToMap(), 0 at the root level.
+	mapDepth int
+
+	// ctx is checked for cancellation during the conversion, it is set by Conv.MapToStructContext() .
+	ctx context.Context
 }
 
 // Config is used to customize the conversion behavior of Conv .
@@ -49,6 +89,20 @@ type Config struct {
 	// such as CaseInsensitiveFieldMatcherCreator(), CamelSnakeCaseFieldMatcherCreator().
 	FieldMatcherCreator FieldMatcherCreator
 
+	// MatcherChain is a fallback chain of FieldMatcherCreator. If it is not empty, FieldMatcherCreator is ignored,
+	// each name is matched by the matchers in order until one matches. e.g. try exact matching first, then
+	// case-insensitive matching, then camel-snake-case matching. It supports mixed-source maps without a single
+	// permissive matcher causing false matches.
+	// The tag name for reading tag options is given by the first creator that tells a non-empty one.
+	MatcherChain []FieldMatcherCreator
+
+	// OnFieldMatch is called when a key of the map matches a field when converting a map to a struct, for
+	// debugging unexpected bindings. The key is the original key of the map, and the field name is the name of
+	// the struct field. The matcher name is 'MatcherChain[i]' if the field is matched by the i-th creator of
+	// MatcherChain; otherwise it is 'FieldMatcherCreator'.
+	// Keys matching no field, or matching setters, are not reported.
+	OnFieldMatch func(key, fieldName, matcherName string)
+
 	// CustomConverters provides a group of functions for converting the given value to some specific type.
 	// The target type will never be nil.
 	//
@@ -62,10 +116,36 @@ type Config struct {
 	//   - If no function in the slice returns OK, the conversion will continue with the predefined implementations,
 	//     such as MapToMap(), StructToMap(), etc.
 	//
+	// When the target type is a pointer, such as *T, the functions are called with *T first, then with T.
+	// The source value is always passed as it is, e.g. a nested map for a struct field, it is not pre-converted.
+	//
 	// NOTE: If your ConvertFunc use Conv internally, be carefully if there will be infinity loops. Is it suggested to
 	// use a Conv instance with no ConvertFunc for the internal conversions.
 	CustomConverters []ConvertFunc
 
+	// TypedConverters maps destination types to the functions converting values to them. It works like
+	// CustomConverters, but the function is found by the destination type directly, so it is not called for
+	// conversions to other types. The function of the destination type is called before CustomConverters; if it
+	// returns nil with no error, the functions in CustomConverters are tried.
+	//
+	// Use Conv.RegisterConverter() to add functions.
+	TypedConverters map[reflect.Type]ConvertFunc
+
+	// CustomConvertersEx is like CustomConverters, but the functions work on reflect.Value, see ConvertFuncEx .
+	// They are called after TypedConverters and before CustomConverters; the first one returning true or an error
+	// stops the conversion with its result.
+	CustomConvertersEx []ConvertFuncEx
+
+	// NamedConverters registers converters which can be referenced by fields with the tag option 'converter=name',
+	// e.g. `conv:"id,converter=hexID"` . The tag is read with the tag name of the FieldMatcherCreator.
+	//
+	// When converting a map or a struct to a struct, the converter of a field is called with the source value and
+	// the type of the field, instead of ConvertType(); the result must be assignable to the field.
+	// When converting a struct to a map, the converter is called with the value of the field and the type of the
+	// empty interface, the result is used as the value of the map.
+	// It is an error if the name is not registered.
+	NamedConverters map[string]ConvertFunc
+
 	// TimeToString formats the given time.
 	// It is called internally by Convert(), ConvertType() or other functions.
 	// Set this field if it is needed to customize the procedure.
@@ -77,11 +157,562 @@ type Config struct {
 	// Set this field if it is needed to customize the procedure.
 	// If this field is nil, the function DefaultStringToTime() will be used.
 	StringToTime func(v string) (time.Time, error)
+
+	// OnWarning is called when a conversion succeeds but is lossy, e.g. converting a float64 to float32 loses
+	// precision, or converting a time.Time to a Unix timestamp drops the fractional second.
+	// The path is the location of the value in the source, such as 'Items[2].Price', it is empty if the value
+	// is at the root level.
+	// If this field is nil, warnings are ignored.
+	OnWarning func(path, msg string)
+
+	// KeyAliases maps keys of the source map to other names when converting a map to a struct.
+	// The alias is used for matching fields instead of the original key, it is useful when the struct comes
+	// from a third-party package and can't be tagged.
+	// e.g. {"emailAddress": "MailAddr"} makes the key 'emailAddress' be matched as 'MailAddr'.
+	//
+	// The alias takes precedence over the FieldMatcher: when a key has an alias, only the alias is matched.
+	KeyAliases map[string]string
+
+	// AllowedKeys is a whitelist of keys of the source map when converting a map to a struct.
+	// If it is not empty, keys not in the list are ignored, even if they match fields of the struct.
+	// This prevents sensitive fields from being assigned by untrusted input.
+	// The keys are compared with the original keys of the map, before KeyAliases are applied.
+	//
+	// If this field is empty, all keys are allowed.
+	AllowedKeys []string
+
+	// ForbiddenKeys is a blacklist of keys of the source map when converting a map to a struct.
+	// Keys in the list are ignored, even if they match fields of the struct, e.g. 'IsAdmin' or 'Role'.
+	// Like AllowedKeys, the keys are compared with the original keys of the map.
+	//
+	// If a key is in both AllowedKeys and ForbiddenKeys, it is forbidden.
+	ForbiddenKeys []string
+
+	// TrimStringValues specifies whether to trim the leading and trailing white spaces of strings when converting
+	// a string to another string, e.g. '  hello  ' is converted to 'hello'. The strings looked up in Enums and
+	// BoolStrings are trimmed too.
+	// It is useful when the values come from a form or a CSV file.
+	TrimStringValues bool
+
+	// MaxFields limits the number of keys of the map when converting a map to a struct. If the map has more keys,
+	// the conversion fails. It is used as a guard against huge maps from untrusted input, so the source map is
+	// checked before MigrateMap and PreProcessMap are called.
+	// Zero means no limit.
+	MaxFields int
+
+	// TypeValidators are used to validate the values of fields when converting to a struct.
+	// After a field value is converted, the function associated with the type of the field is called with the
+	// value, the conversion fails if the function returns an error.
+	// e.g. a validator for some type Email can ensure the value is a valid email address.
+	TypeValidators map[reflect.Type]func(v interface{}) error
+
+	// LayoutTag specifies the name of the tag which gives the layout for parsing and formatting times of a field.
+	// The layout is used with time.Parse() and time.Format() , it overrides StringToTime and TimeToString.
+	// e.g. when LayoutTag is 'layout':
+	//
+	//	type T struct {
+	//	    CreatedAt time.Time `layout:"2006-01-02"`
+	//	}
+	//
+	// When converting a map or a struct to T, a string is parsed with the layout for the field CreatedAt;
+	// when converting T to a map, the field is formatted with the layout, the value in the map is a string.
+	//
+	// If this field is empty, layout tags are not processed.
+	LayoutTag string
+
+	// DefaultLocation is the location for times parsed with layouts given by LayoutTag, when the string has no time
+	// zone information, e.g. '2020-01-02 15:04:05' is parsed as a time in the location. A time zone given by
+	// TimeZoneTag takes precedence.
+	//
+	// If this field is nil, such times are in UTC, the same as time.Parse() .
+	DefaultLocation *time.Location
+
+	// TimeZoneTag specifies the name of the tag which gives the time zone of a time field, the zone is loaded with
+	// time.LoadLocation() . e.g. when TimeZoneTag is 'tz':
+	//
+	//	type T struct {
+	//	    CreatedAt time.Time `tz:"Asia/Shanghai"`
+	//	}
+	//
+	// When converting T to a map, the field is converted to the zone and formatted to a string.
+	// When converting to T, the parsed time is converted to the zone; with a layout tag (see LayoutTag),
+	// a string without zone information is interpreted in the zone.
+	//
+	// If this field is empty, time zone tags are not processed.
+	TimeZoneTag string
+
+	// SeparatorTag specifies the name of the tag which gives the separator for splitting strings of a field,
+	// it overrides StringSplitter. e.g. when SeparatorTag is 'sep':
+	//
+	//	type T struct {
+	//	    Tags []string `sep:";"`
+	//	}
+	//
+	// When converting a map or a struct to T, a string value such as 'a;b' is split by ';' for the field Tags.
+	//
+	// If this field is empty, separator tags are not processed.
+	SeparatorTag string
+
+	// EncodingTag specifies the name of the tag which gives the encoding of a []byte field, the value can be
+	// 'base64' (the standard encoding defined in RFC 4648), 'hex' or 'raw'. e.g. when EncodingTag is 'encoding':
+	//
+	//	type T struct {
+	//	    Data []byte `encoding:"base64"`
+	//	}
+	//
+	// When converting a map or a struct to T, a string value is decoded with the encoding for the field Data;
+	// 'raw' means the bytes of the string are used as is. Values of other types are converted as usual.
+	//
+	// If this field is empty, encoding tags are not processed.
+	EncodingTag string
+
+	// ByteSliceEncoding specifies how strings and byte slices are converted to each other, the value can be
+	// 'base64', 'hex' or 'raw', like the values of EncodingTag. e.g. with 'raw', the string '233' is converted to
+	// []byte("233") instead of []byte{233}; with 'base64', the string 'AQI=' is converted to []byte{1, 2}, and
+	// []byte{1, 2} is converted to 'AQI='. StructToMap() converts byte slices to strings with the encoding too,
+	// which is useful for embedding binary data in maps. The encoding given by EncodingTag takes precedence.
+	//
+	// If this field is empty, a string is converted to a byte slice like other slices, see StringToSlice() ,
+	// and a byte slice is converted to a string as UTF-8 text.
+	ByteSliceEncoding string
+
+	// MaxLenTag specifies the name of the tag which gives the maximum length, in runes, of a string field.
+	// e.g. when MaxLenTag is 'maxlen':
+	//
+	//	type T struct {
+	//	    Name string `maxlen:"50"`
+	//	}
+	//
+	// When converting a map or a struct to T, if the converted value of Name is longer than the limit, an error
+	// is returned, or the string is truncated if TruncateLongStrings is true. Pointers to strings are checked too.
+	//
+	// If this field is empty, maximum length tags are not processed.
+	MaxLenTag string
+
+	// TruncateLongStrings specifies whether to truncate strings longer than the limit given by MaxLenTag, instead
+	// of returning an error. A warning is sent to OnWarning when a string is truncated.
+	TruncateLongStrings bool
+
+	// MinTag and MaxTag specify the names of the tags which give the inclusive range of a numeric field.
+	// e.g. when MinTag is 'min' and MaxTag is 'max':
+	//
+	//	type T struct {
+	//	    Port int `min:"1" max:"65535"`
+	//	}
+	//
+	// When converting a map or a struct to T, it is an error if the converted value of Port is out of the range.
+	// Fields of integer, unsigned integer and float kinds, and pointers to them, are checked; the bounds are parsed
+	// as values of the same kind, it is an error if a bound is invalid for the kind.
+	//
+	// If a field is empty, the corresponding tags are not processed.
+	MinTag string
+	MaxTag string
+
+	// PipeTag specifies the name of the tag which gives a pipeline of transforms for a field, the transforms are
+	// separated by commas and are looked up in Pipes. e.g. when PipeTag is 'pipe':
+	//
+	//	type T struct {
+	//	    Name string `pipe:"trim,lower"`
+	//	}
+	//
+	// When converting a map or a struct to T, the source value of the field is passed through the transforms in
+	// order, then the result is converted to the type of the field. It is an error if a transform is not found
+	// in Pipes, or any transform returns an error.
+	//
+	// If this field is empty, pipe tags are not processed.
+	PipeTag string
+
+	// Pipes are the named transforms used by PipeTag.
+	Pipes map[string]func(v interface{}) (interface{}, error)
+
+	// UseSetters specifies whether to use setter methods when converting a map to a struct.
+	// A setter is a method of the pointer to the struct, with a name like 'SetXxx' and one parameter, no return value.
+	//
+	// When a key of the map matches no field, or the matched field can't be set, 'Xxx' is matched with the key
+	// using the FieldMatcher, the value is converted to the type of the parameter and passed to the setter.
+	// e.g. the key 'Name' matches the method SetName(v string) .
+	UseSetters bool
+
+	// NumericStringToBool specifies whether to convert numeric strings to booleans with the rule for numbers:
+	// zero as false, non-zero as true. e.g. '2' and '-1' are converted to true, '0' and '0.0' to false.
+	// Non-numeric strings are still converted with strconv.ParseBool() .
+	NumericStringToBool bool
+
+	// BoolStrings maps extra strings to booleans, the strings are matched case-insensitively, e.g.
+	//
+	//	BoolStrings: map[string]bool{"yes": true, "no": false, "on": true, "off": false}
+	//
+	// When converting a string to a boolean, the string is looked up in BoolStrings first; strings not found are
+	// converted as usual.
+	BoolStrings map[string]bool
+
+	// FlattenKeys specifies extra keys for flattening single-key maps. By default, only a map[string]interface{} with
+	// a single empty key is flattened, i.e. map[string]interface{}{"": v} is converted as v.
+	// With this field, a map[string]interface{} with a single key which is one of the FlattenKeys is flattened too,
+	// e.g. if FlattenKeys is []string{"value"}, map[string]interface{}{"value": 1} can be converted to the int 1.
+	//
+	// Since a map with such a key is likely to be converted to a struct or a map, the extra keys are only used when
+	// the destination type is neither a map nor a struct. time.Time is treated as a simple type, not a struct.
+	FlattenKeys []string
+
+	// Epoch is the time that timestamps are relative to when converting between numbers and time.Time .
+	// e.g. set it to time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC) for the timestamps used by Apple's Core Data.
+	// The unit of timestamps is given by TimestampUnit.
+	//
+	// If this field is the zero value, the Unix epoch 1970-01-01T00:00:00Z is used.
+	Epoch time.Time
+
+	// TimestampUnit is the unit of timestamps when converting between numbers and time.Time, including integer
+	// strings which can't be parsed by StringToTime, e.g. with UnitMillis, '1650425440123' is converted to
+	// 2022-04-20T03:30:40.123Z .
+	// The zero value is UnitSeconds.
+	TimestampUnit TimestampUnit
+
+	// TimeLocation is the location of times converted from timestamps, e.g. with time.UTC, the timestamp 0 is
+	// converted to 1970-01-01T00:00:00Z . A time zone given by TimeZoneTag takes precedence.
+	//
+	// If this field is nil, time.Local is used.
+	TimeLocation *time.Location
+
+	// UseStringer specifies whether to convert values implementing the error interface to strings with their
+	// Error() method, when the destination type is a string. e.g. converting a map containing an error to a struct
+	// with a string field for logging. Other values implementing fmt.Stringer are converted with their String()
+	// method, except simple types, such as time.Time , which are converted as usual.
+	// Nil pointers are not converted this way.
+	UseStringer bool
+
+	// ThousandsSeparator is removed from strings before parsing them as numbers, e.g. if it is ",", the string
+	// '1,000,000' can be converted to the int 1000000.
+	// When converting a string to a slice, the string is split by StringSplitter before the separator is removed,
+	// so '1,2' is still converted to []int{1, 2} if StringSplitter splits the string by commas.
+	//
+	// If this field is empty, strings are parsed as is.
+	ThousandsSeparator string
+
+	// DotNestedKeys specifies whether to group dotted keys when converting a map to a struct.
+	// A key like 'prefix.rest' which doesn't match any field is grouped by its prefix, e.g.
+	//
+	//	{"labels.env": "prod", "labels.app": "web"}
+	//
+	// is converted as
+	//
+	//	{"labels": {"env": "prod", "app": "web"}}
+	//
+	// then the group is converted to the field matches the prefix, which can be a map or a struct.
+	// A nested struct is converted the same way, so keys like 'a.b.c' are grouped level by level, to any depth;
+	// intermediate fields which are pointers to structs are allocated.
+	// It is an error if the prefix is also a key of the source map.
+	DotNestedKeys bool
+
+	// DisallowUnknownFields specifies whether MapToStruct() returns an error if some keys of the map match no field
+	// of the struct, nor a setter if UseSetters is true, like json.Decoder.DisallowUnknownFields() . The error lists
+	// all the unknown keys, e.g. 'unknown keys: 'a', 'b''. Keys excluded by AllowedKeys or ForbiddenKeys are not checked.
+	// Nested maps converted to nested structs are checked the same way.
+	//
+	// With CollectAllFieldErrors, each unknown key is reported as a *ConvError with the key as its path.
+	DisallowUnknownFields bool
+
+	// RequireAllFields specifies whether MapToStruct() requires every field of the struct to be matched by a key of
+	// the map, as if all fields had the 'required' tag option; otherwise missing fields keep their zero values.
+	// Fields with the tag option 'optional', 'omit', 'raw' or 'readonly' are not required.
+	// Nested maps converted to nested structs are checked the same way.
+	RequireAllFields bool
+
+	// CollectAllFieldErrors specifies whether to continue converting the other fields when a field fails to convert,
+	// when converting a map or a struct to a struct. If it is true, the errors of all fields are returned at once with
+	// a *MultiError , which is useful for giving complete validation feedback in one pass.
+	// Each error contained is a *ConvError with the path and the types of the field, see MultiError.ConvErrors() ;
+	// an error that isn't from converting a field, such as an error from a setter, has the key of the map, or the
+	// name of the source field, as its path.
+	//
+	// By default, the conversion stops on the first error.
+	CollectAllFieldErrors bool
+
+	// ReturnPartialOnError specifies whether to return the partially-populated struct alongside the error, when
+	// converting a map to a struct fails on a field. The struct contains the fields set before the failing one;
+	// since the keys of a map are iterated in random order, use it with CollectAllFieldErrors to get all valid fields.
+	// Errors not related to the fields, such as an invalid destination type, still return nil.
+	//
+	// By default, nil is returned on any error.
+	ReturnPartialOnError bool
+
+	// NullStrings specifies strings which are treated as nil, e.g. []string{"null", "NULL", "nil"}, it is useful
+	// for the data from CSV exports. When a string source value equals one of them, it is converted to the zero
+	// value of the destination type: the zero value for value types, and nil for pointers, maps and slices.
+	// Map keys are not affected.
+	//
+	// If this field is empty, no string is treated specially.
+	NullStrings []string
+
+	// URLDecodeValues specifies whether to decode the values of the source map with url.QueryUnescape() when
+	// converting a map to a struct or another map. It is useful for binding raw query maps.
+	// Only values that are strings or []string are decoded; nested maps are decoded when they are converted.
+	URLDecodeValues bool
+
+	// PreProcessMap is called at the start of converting a map to a struct, the returned map is used for binding
+	// instead of the source map. It can be used to rename keys or inject default values, etc.
+	// It is also called for the nested maps which are converted to nested structs.
+	// The function should not modify the given map, since it may be owned by the caller.
+	//
+	// If this field is nil, the source map is used directly.
+	PreProcessMap func(m map[string]interface{}) map[string]interface{}
+
+	// MigrateMap is called when converting a map to a struct, before PreProcessMap, to transform maps in old
+	// formats into the current shape. The version is read from the key specified by VersionKey and converted to int;
+	// it is 0 if the key is absent or VersionKey is empty. The returned map is used for binding.
+	// Like PreProcessMap, it is also called for the nested maps and should not modify the given map.
+	//
+	// If this field is nil, no migration is performed.
+	MigrateMap func(version int, m map[string]interface{}) map[string]interface{}
+
+	// VersionKey is the key of the source map that holds the version passed to MigrateMap.
+	VersionKey string
+
+	// IncludeGetters specifies whether to read getters of the source struct, when converting a struct to a map or
+	// another struct. A getter is an exported method with no parameter and one return value, the method name is
+	// used as the field name, e.g. 'Name()' is read as 'Name'. Methods returning an error, such as 'Close() error',
+	// are not getters, they are never called. Methods promoted from embedded structs, interfaces or pointers are
+	// included too, they are skipped if an embedded interface or pointer on the way is nil.
+	//
+	// When a getter has the same name as a field, the field is used.
+	IncludeGetters bool
+
+	// MaxSliceLen limits the length of slices produced by converting a slice or a string to a slice, it protects
+	// against adversarial inputs creating huge slices. When a source is longer than the limit, an error is returned,
+	// or the extra elements are dropped if TruncateLongSlices is true.
+	//
+	// If this field is 0, the length is unlimited.
+	MaxSliceLen int
+
+	// TruncateLongSlices specifies whether to drop the extra elements of slices longer than MaxSliceLen, instead
+	// of returning an error. A warning is sent to OnWarning when a slice is truncated.
+	TruncateLongSlices bool
+
+	// StrictReadonly specifies whether to return an error when a key of the source map matches a readonly field.
+	// A field is readonly if the 'readonly' option presents in its tag, e.g. `conv:"id,readonly"`, such fields are
+	// never set when converting a map to a struct, which prevents clients from overriding server-controlled fields.
+	// By default, the key is ignored silently.
+	//
+	// Tag options are read with the tag name of the FieldMatcherCreator, which is SimpleMatcherConfig.Tag when
+	// using SimpleMatcherCreator. A custom FieldMatcherCreator can provide it with a method 'TagName() string'.
+	StrictReadonly bool
+
+	// ValueTransformer is applied to each value of the source map before the value is converted, when converting
+	// a map to a struct or another map. The returned value is then converted to the type of the field or the map
+	// value. It enables cross-cutting transforms, such as decrypting or normalizing, without per-field converters.
+	// The key is the original key of the source map; for maps with non-string keys, it is formatted with fmt.Sprint().
+	// If URLDecodeValues is true, the value is decoded before the transformer is applied.
+	//
+	// If this field is nil, the values are converted as is.
+	ValueTransformer func(key string, value interface{}) interface{}
+
+	// PresenceAsTrue specifies whether a key with a nil or empty string value means true for a bool field, when
+	// converting a map to a struct. It supports flag-style inputs like {"verbose": ""}. Pointers to bool are
+	// supported too. Absent keys leave the fields unchanged, which are false by default.
+	PresenceAsTrue bool
+
+	// Enums registers the names of the values of enum types. The keys are named integer types, such as
+	// 'type Color int', the values map the names to the values of the enum, e.g.
+	//
+	//	Enums: map[reflect.Type]map[string]int64{
+	//	    reflect.TypeOf(Color(0)): {"Red": 1, "Green": 2},
+	//	}
+	//
+	// When converting a string to a registered type, the string is parsed as a name of the enum; if the name is not
+	// registered but the string is an integer, such as "1", it is parsed as the value of the enum, otherwise it is an
+	// error. Numbers are converted as usual.
+	// When converting a value of a registered type to a string, the name of the value is used; if the value has no
+	// name, it is formatted as a number. If several names have the same value, the first one in lexical order is used.
+	Enums map[reflect.Type]map[string]int64
+
+	// EnumCaseInsensitive specifies whether to match the names of the enums registered in Enums case-insensitively
+	// when converting strings to enums, e.g. "RED" and "red" both match "Red". An exact match is preferred;
+	// if several names differ only in case, the first one in lexical order is used.
+	EnumCaseInsensitive bool
+
+	// StrictTypes disables type coercion for fields of simple types, when converting a map or a struct to a struct.
+	// A field of a simple type, or a pointer to it, is set only if the type of the source value, after
+	// dereferencing pointers, is assignable to the type of the field, after dereferencing pointers; otherwise
+	// an error is returned. e.g. an int can't be bound to a string field, and a string can't be bound to an int field.
+	//
+	// Fields of other types, such as nested structs, are converted as usual, and their fields are checked the same way.
+	StrictTypes bool
+
+	// InterfaceFieldTypes maps paths of interface fields to concrete types, when converting a map or a struct to
+	// a struct. The source value of such a field is converted to the concrete type, then stored in the field.
+	// e.g. with {"Data": reflect.TypeOf(&Detail{})}, a nested map for the field 'Data interface{}' is converted
+	// to a *Detail. The concrete type must implement the interface type of the field.
+	// Nil values are stored as nil interfaces.
+	//
+	// The keys are the dot-split paths of the fields from the root struct, built with the names of the fields, not
+	// the names given by tags. e.g. 'Data' is the field of the root struct, 'Inner.Data' is the field of the nested
+	// struct in the field 'Inner'. Indexes of slices and keys of maps are not part of the path, e.g. 'Items.Data'
+	// is the field of each element of the slice 'Items'. A promoted field of an embedded struct is named as a field
+	// of the outer struct.
+	InterfaceFieldTypes map[string]reflect.Type
+
+	// InterfaceImplementations maps non-empty interface types to concrete types. When the destination type of
+	// ConvertType() is one of the interfaces, and the source value doesn't implement it, the source value is
+	// converted to the concrete type instead. e.g. with {reflect.TypeOf((*Shape)(nil)).Elem(): reflect.TypeOf(&Circle{})},
+	// a map can be converted to a Shape, the result is a *Circle. The concrete type must implement the interface.
+	//
+	// InterfaceFieldTypes takes precedence over this registry for struct fields.
+	InterfaceImplementations map[reflect.Type]reflect.Type
+
+	// JSONSafeMapValues specifies whether StructToMap() produces only values that encoding/json can encode as
+	// JSON-native types: time.Time is formatted with TimeToString (RFC3339 by default), time.Duration is formatted
+	// with its String() method, such as '1m30s', and complex numbers are formatted with strconv.FormatComplex() ,
+	// such as '(1+2i)'.
+	JSONSafeMapValues bool
+
+	// StructToMapMaxDepth limits the levels of structs converted to maps by StructToMap() , 0 means no limit.
+	// Nested structs beyond the depth, or pointers to them, are kept as they are. e.g. with 1, only the given struct
+	// is converted, a field of type *T, or of type []T, gets a *T, or a []T; with 2, the fields of the nested
+	// structs are converted too, and so on.
+	StructToMapMaxDepth int
+
+	// StructToMapShallow specifies whether StructToMap() stores the values of fields as they are, without any
+	// conversion, e.g. a nested struct, a pointer or a slice is kept in its original type, which is useful for
+	// template engines. Only the names of the fields and the tag options 'omit', 'omitempty', 'string' and
+	// 'converter=NAME' are applied.
+	StructToMapShallow bool
+
+	// UnwrapSingletonSlices specifies whether to unwrap slices when converting a map or a struct to a struct,
+	// if the field is of a simple type, or a pointer to it. A slice with exactly one element is replaced with the
+	// element, e.g. ["42"] is bound to an int field as 42, which is common for values from multi-value form parsers.
+	// A slice with more elements can't be bound to such a field, an error is returned.
+	//
+	// Byte slices are not unwrapped, they are converted as usual.
+	UnwrapSingletonSlices bool
+
+	// LenientComplex specifies whether to accept more formats when converting strings to complex numbers.
+	// By default, strings are parsed with strconv.ParseComplex() , which accepts formats like '3+4i', '3', '4i'
+	// and '(3+4i)'. With this field, these formats are also accepted:
+	//   - Spaces are ignored, e.g. ' 3 + 4i '.
+	//   - 'j' as the imaginary unit, e.g. '3+4j'.
+	//   - The imaginary unit without a coefficient, e.g. 'i', '3-i'.
+	//   - A pair of the real and imaginary parts, e.g. '(3,4)'.
+	LenientComplex bool
+
+	// AcceptScientificInt specifies whether to accept strings in scientific notation, or with a fractional part,
+	// when converting strings to integers, e.g. '1e3' is converted to 1000, '2.5e2' to 250. It is common in the data
+	// from JSON or CSV exports. The string is parsed as a float64 if it can't be parsed as an integer, the
+	// conversion fails if the number is not an integer, e.g. '1.5e0'.
+	AcceptScientificInt bool
+
+	// ErrorOnAmbiguousEmbedded specifies whether MapToStruct() returns an error when the destination struct has
+	// ambiguous fields, i.e. fields with the same name at the same depth of different embedded structs, such as
+	//
+	//	type A struct{ ID int }
+	//	type B struct{ ID int }
+	//	type T struct {
+	//	    A
+	//	    B // T.A.ID and T.B.ID are ambiguous.
+	//	}
+	//
+	// By default, the first one is used silently.
+	ErrorOnAmbiguousEmbedded bool
+
+	// DrainChannels specifies whether to convert channels to slices, by receiving values from the channel until
+	// it is closed, then converting the values to the slice, e.g. a chan int can be bound to a field of type []int .
+	// The conversion blocks until the channel is closed, so the channel must be closed by the sender; a nil channel
+	// is converted to a nil slice.
+	DrainChannels bool
+
+	// MapKVSlices specifies whether ConvertType() converts maps to slices of key/value structs and back, with
+	// Conv.MapToKVSlice() and Conv.KVSliceToMap() , e.g. a map[int]string can be converted to a
+	// []struct{ Key int; Value string } , which is JSON-friendly and ordered.
+	MapKVSlices bool
+
+	// ISO8601Durations specifies whether to parse strings in the ISO8601 duration format when converting to
+	// time.Duration, e.g. 'PT1H30M' is 90 minutes, 'P1DT2H' is 26 hours. The format is
+	// '[-]P[nW][nD][T[nH][nM][nS]]', the last number can have a fractional part, such as 'PT1.5S'.
+	// A day is 24 hours. Years and months are not supported since their lengths vary.
+	// Strings not starting with 'P' or '-P' are converted as usual.
+	ISO8601Durations bool
+
+	// DeepCopyInputs specifies whether to deep copy source values that are stored as they are, so that the result
+	// never shares maps, slices or pointers with the source, and mutating the source later doesn't affect the
+	// result. e.g. by default, a map value bound to a field of type interface{} is the same map of the source.
+	// Containers converted to other types, such as by MapToMap() or SliceToSlice() , are always newly allocated.
+	//
+	// The copy is made with DeepClone() , unexported fields of structs are copied shallowly.
+	DeepCopyInputs bool
+
+	// PreserveIdentity specifies whether ConvertType() reuses the converted value when the same source pointer is
+	// converted to the same pointer type more than once, e.g. when cloning a struct graph where two fields point to
+	// the same object, the two fields of the result point to the same copy too, instead of two separate copies.
+	// Cyclic references, such as a node pointing to itself, are reproduced in the result.
+	//
+	// The pointers are tracked within a single call of ConvertType() or Convert() , including the nested
+	// conversions; maps and slices are not tracked.
+	PreserveIdentity bool
+
+	// DecodeJSONStrings specifies whether to decode strings as JSON when converting them to structs, maps, slices
+	// or arrays, e.g. the string '{"Name":"a"}' can be converted to a struct with the field Name.
+	// The decoded value is then converted to the destination type as usual. Numbers are decoded as json.Number
+	// to keep the precision.
+	//
+	// Only strings starting with '{', for structs and maps, or '[', for slices and arrays, are decoded, leading
+	// white spaces are ignored; other strings are converted as usual, e.g. 'a,b' is still converted to a slice
+	// by StringToSlice() . It is an error if the string is not valid JSON.
+	DecodeJSONStrings bool
+}
+
+// tagNamer can be implemented by a FieldMatcherCreator to tell the tag name it uses, so that Conv can read
+// the options of the tag, such as `conv:"name,readonly"`. SimpleMatcherCreator implements it.
+type tagNamer interface {
+	TagName() string
+}
+
+// TimestampUnit is the unit of timestamps, see Conv.Conf.TimestampUnit .
+type TimestampUnit int
+
+const (
+	UnitSeconds TimestampUnit = iota // Timestamps are in seconds.
+	UnitMillis                       // Timestamps are in milliseconds.
+	UnitMicros                       // Timestamps are in microseconds.
+	UnitNanos                        // Timestamps are in nanoseconds.
+)
+
+// duration returns the duration of one unit.
+func (u TimestampUnit) duration() time.Duration {
+	switch u {
+	case UnitMillis:
+		return time.Millisecond
+	case UnitMicros:
+		return time.Microsecond
+	case UnitNanos:
+		return time.Nanosecond
+	default:
+		return time.Second
+	}
 }
 
 // ConvertFunc is used to customize the conversion.
 type ConvertFunc func(value interface{}, typ reflect.Type) (result interface{}, err error)
 
+// ConvertFuncEx is like ConvertFunc, but it is given the source value as a reflect.Value, so it can check the
+// kind of the value without a type switch, or handle typed nils, such as a nil *T , which is a valid reflect.Value of
+// kind Ptr. A nil interface{} is given as an invalid reflect.Value.
+//
+// The function returns false if it doesn't handle the conversion. If it returns true, the result is used, an
+// invalid reflect.Value is treated as the zero value of the destination type.
+type ConvertFuncEx func(src reflect.Value, dstTyp reflect.Type) (result reflect.Value, ok bool, err error)
+
+// RegisterConverter adds the function to Conv.Conf.TypedConverters for the destination type, replacing the one
+// registered before. The map is copied before adding, so Conv instances sharing the same map are not affected.
+//
+// It is not safe to call this method concurrently with conversions of the same Conv instance.
+func (c *Conv) RegisterConverter(typ reflect.Type, f ConvertFunc) {
+	m := make(map[reflect.Type]ConvertFunc, len(c.Conf.TypedConverters)+1)
+	for k, v := range c.Conf.TypedConverters {
+		m[k] = v
+	}
+	m[typ] = f
+	c.Conf.TypedConverters = m
+}
+
 // DefaultTimeToString formats time using the time.RFC3339 format.
 func DefaultTimeToString(t time.Time) (string, error) {
 	return t.Format(time.RFC3339), nil
@@ -92,6 +723,48 @@ func DefaultStringToTime(v string) (time.Time, error) {
 	return time.Parse(time.RFC3339Nano, v)
 }
 
+// at returns a copy of c which is used to convert the value of the given member, such as a field.
+func (c *Conv) at(name string) *Conv {
+	cc := *c
+	if cc.path == "" {
+		cc.path = name
+	} else {
+		cc.path += "." + name
+	}
+
+	if cc.fieldPath == "" {
+		cc.fieldPath = name
+	} else {
+		cc.fieldPath += "." + name
+	}
+	return &cc
+}
+
+// atIndex is like at(), but for an element of a slice, or a value of a map.
+func (c *Conv) atIndex(index interface{}) *Conv {
+	cc := *c
+	cc.path += fmt.Sprintf("[%v]", index)
+	return &cc
+}
+
+// forKey returns the Conv for converting map keys, Conv.Conf.NullStrings is not applied to them.
+func (c *Conv) forKey() *Conv {
+	if len(c.Conf.NullStrings) == 0 {
+		return c
+	}
+
+	cc := *c
+	cc.Conf.NullStrings = nil
+	return &cc
+}
+
+// warn sends a warning to Conv.Conf.OnWarning .
+func (c *Conv) warn(msgFormat string, a ...interface{}) {
+	if c.Conf.OnWarning != nil {
+		c.Conf.OnWarning(c.path, fmt.Sprintf(msgFormat, a...))
+	}
+}
+
 func (c *Conv) doSplitString(v string) []string {
 	var parts []string
 	if c.Conf.StringSplitter == nil {
@@ -133,9 +806,15 @@ func (c *Conv) StringToSlice(v string, simpleSliceType reflect.Type) (interface{
 	}
 
 	parts := c.doSplitString(v)
+	n, err := c.checkSliceLen(len(parts))
+	if err != nil {
+		return nil, errForFunction(fnName, "%s", err)
+	}
+
+	parts = parts[:n]
 	dst := reflect.MakeSlice(simpleSliceType, 0, len(parts))
 	for i, elemIn := range parts {
-		elemOut, err := c.SimpleToSimple(elemIn, elemTyp)
+		elemOut, err := c.atIndex(i).SimpleToSimple(elemIn, elemTyp)
 		if err != nil {
 			return nil, errForFunction(fnName, "cannot convert to %v, at index %v: %v", simpleSliceType, i, err)
 		}
@@ -152,7 +831,8 @@ func (c *Conv) StringToSlice(v string, simpleSliceType reflect.Type) (interface{
 // Rules:
 //   - nil: as false.
 //   - Numbers: zero as false, non-zero as true.
-//   - String: same as strconv.ParseBool().
+//   - String: same as strconv.ParseBool(). If Conv.Conf.NumericStringToBool is true, numeric strings are
+//     converted with the rule for numbers. Strings in Conv.Conf.BoolStrings are converted to the mapped values.
 //   - time.Time: zero Unix timestamps as false, other values as true.
 //   - Other values are not supported, returns false and an error.
 func (c *Conv) SimpleToBool(simple interface{}) (bool, error) {
@@ -162,13 +842,22 @@ func (c *Conv) SimpleToBool(simple interface{}) (bool, error) {
 		return false, nil
 	}
 
+	if res, ok := c.boolString(simple); ok {
+		return res, nil
+	}
+
+	if res, ok := c.numericStringToBool(simple); ok {
+		return res, nil
+	}
+
+	simple = namedTimeToTime(simple)
 	typ := reflect.TypeOf(simple)
 	if IsPrimitiveType(typ) {
 		res, err := primitive.toBool(simple)
 		if err == nil {
 			return res, nil
 		}
-		return res, errForFunction(fnName, err.Error())
+		return res, errForFunction(fnName, "%s", err)
 	}
 
 	if typ == typTime {
@@ -180,7 +869,8 @@ func (c *Conv) SimpleToBool(simple interface{}) (bool, error) {
 }
 
 // SimpleToString converts the given value to a string.
-// The value must be a simple type, for which IsSimpleType() returns true.
+// The value must be a simple type, for which IsSimpleType() returns true; or, if Conv.Conf.UseStringer is true,
+// a value implementing the error interface or fmt.Stringer .
 //
 // Conv.Config.StringToTime() is used to format times.
 // Specially, booleans are converted to 0/1, not the default format true/false.
@@ -191,6 +881,18 @@ func (c *Conv) SimpleToString(v interface{}) (string, error) {
 		return "", errSourceShouldNotBeNil(fnName)
 	}
 
+	if name, ok := c.enumName(v); ok {
+		return name, nil
+	}
+
+	if s, ok, err := c.tryMarshalText(v); ok {
+		if err != nil {
+			return "", errForFunction(fnName, "%s", err)
+		}
+		return s, nil
+	}
+
+	v = namedTimeToTime(v)
 	t := reflect.TypeOf(v)
 	if t == typTime {
 		res, err := c.doTimeToString(v.(time.Time))
@@ -202,12 +904,43 @@ func (c *Conv) SimpleToString(v interface{}) (string, error) {
 
 	k := t.Kind()
 	if !IsPrimitiveKind(k) {
+		if s, ok := c.tryStringer(v, typString); ok {
+			return s.(string), nil
+		}
 		return "", errForFunction(fnName, "cannot convert %v to a primitive value", k)
 	}
 
 	return primitive.toString(v), nil
 }
 
+// IntToStringBase converts the given value to a string representing the integer in the given base,
+// the base must be between 2 and 36, e.g. 255 is converted to 'ff' in base 16, -5 to '-101' in base 2.
+// Unsigned integers are formatted with strconv.FormatUint(), signed integers with strconv.FormatInt() .
+// The kind of the value must be an integer, other values such as booleans, floats and strings can't be converted.
+func (c *Conv) IntToStringBase(v interface{}, base int) (string, error) {
+	const fnName = "IntToStringBase"
+
+	if v == nil {
+		return "", errSourceShouldNotBeNil(fnName)
+	}
+
+	if base < 2 || base > 36 {
+		return "", errForFunction(fnName, "the base must be between 2 and 36, got %v", base)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch k := rv.Kind(); {
+	case isKindInt(k):
+		return strconv.FormatInt(rv.Int(), base), nil
+
+	case isKindUint(k):
+		return strconv.FormatUint(rv.Uint(), base), nil
+
+	default:
+		return "", errForFunction(fnName, "cannot convert %v to an integer", rv.Type())
+	}
+}
+
 /*
 SimpleToSimple converts a simple type, for which IsSimpleType() returns true, to another simple type.
 The conversion use the following rules:
@@ -216,17 +949,35 @@ Booleans:
   - true/false is converted to number 0/1, or string '0'/'1'.
   - From a boolean to a string: use strconv.ParseBool().
   - From a number to a boolean: zero value as false; non-zero value as true.
+  - From a numeric string to a boolean: same as numbers if Conv.Conf.NumericStringToBool is true.
+  - From a string in Conv.Conf.BoolStrings to a boolean: the mapped value.
 
 Numbers:
   - From a complex number to a real number: the imaginary part must be zero, the real part will be converted.
+  - From a string to an integer: the string is parsed exactly, e.g. '18446744073709551615' can be converted to
+    math.MaxUint64 . A float64 can't hold such large integers precisely, so when the values come from JSON, decode them
+    with json.Decoder.UseNumber() to keep the string form, instead of letting them be decoded as float64.
+
+Enums:
+  - From a string to a type registered in Conv.Conf.Enums: the string is parsed as a name of the enum, or
+    as the value if it is an integer not registered as a name.
+  - From a value of a registered type to a string: the name of the value.
+
+Strings:
+  - From a string to another string: the leading and trailing white spaces are trimmed if Conv.Conf.TrimStringValues is true.
 
 To time.Time:
-  - From a number: the number is treated as a Unix-timestamp as converted using time.Unix(),  the time zone is time.Local.
-  - From a string: use Conv.Conf.StringToTime function.
+  - From a number: the number is treated as a Unix-timestamp as converted using time.Unix(), the time zone is
+    Conv.Conf.TimeLocation, or time.Local if it is nil.
+    If Conv.Conf.Epoch is set, the number is the time elapsed since the epoch instead. The unit of the number
+    is given by Conv.Conf.TimestampUnit, it is seconds by default.
+  - From a string: use Conv.Conf.StringToTime function. If it fails and the string is an integer, such as '1700000000',
+    the string is treated as a Unix-timestamp, the same as numbers.
   - From another time.Time: the raw value is cloned, includes the timestamp and the location.
 
 From time.Time:
-  - To a number: output a Unix-timestamp.
+  - To a number: output a Unix-timestamp, or the seconds elapsed since Conv.Conf.Epoch if it is set.
+    The unit is given by Conv.Conf.TimestampUnit .
   - To a string: use Conv.Conf.TimeToString function.
 */
 func (c *Conv) SimpleToSimple(src interface{}, dstTyp reflect.Type) (interface{}, error) {
@@ -236,10 +987,39 @@ func (c *Conv) SimpleToSimple(src interface{}, dstTyp reflect.Type) (interface{}
 		return nil, errSourceShouldNotBeNil(fnName)
 	}
 
+	if res, ok, err := c.tryParseEnum(src, dstTyp); ok {
+		if err != nil {
+			return nil, errForFunction(fnName, "%s", err)
+		}
+		return res, nil
+	}
+
+	if dstTyp.Kind() == reflect.String {
+		if name, ok := c.enumName(src); ok {
+			return reflect.ValueOf(name).Convert(dstTyp).Interface(), nil
+		}
+	}
+
+	if c.Conf.ISO8601Durations && dstTyp == typDuration {
+		if v := reflect.ValueOf(src); v.Kind() == reflect.String && isISO8601Duration(v.String()) {
+			d, err := parseISO8601Duration(v.String())
+			if err != nil {
+				return nil, errForFunction(fnName, "%s", err)
+			}
+			return d, nil
+		}
+	}
+
 	var res interface{}
 	var err error
 	dstKind := dstTyp.Kind()
 	if IsPrimitiveKind(dstKind) {
+		if c.Conf.TrimStringValues && dstKind == reflect.String {
+			if v := reflect.ValueOf(src); v.Kind() == reflect.String {
+				src = strings.TrimSpace(v.String())
+			}
+		}
+
 		res, err = c.simpleToPrimitive(src, dstKind)
 	} else if dstTyp.ConvertibleTo(typTime) {
 		res, err = c.simpleToTime(src)
@@ -260,10 +1040,12 @@ func (c *Conv) SimpleToSimple(src interface{}, dstTyp reflect.Type) (interface{}
 
 /*
 time.Time -> raw value
-string -> Conv.Conf.StringToTime()
-number as unix-timestamp -> Local time
+string -> Conv.Conf.StringToTime(), or as unix-timestamp if the string is an integer
+number as timestamp -> see timestampToTime(), the unit is Conv.Conf.TimestampUnit , relative to Conv.Conf.Epoch ,
+in Conv.Conf.TimeLocation or the Local time zone
 */
 func (c *Conv) simpleToTime(src interface{}) (time.Time, error) {
+	src = namedTimeToTime(src)
 	srcTyp := reflect.TypeOf(src)
 
 	if srcTyp == typTime {
@@ -272,8 +1054,15 @@ func (c *Conv) simpleToTime(src interface{}) (time.Time, error) {
 
 	switch {
 	case srcTyp.Kind() == reflect.String:
-		t, err := c.doStringToTime(src.(string))
+		s := src.(string)
+		t, err := c.doStringToTime(s)
 		if err != nil {
+			// Fallback to a Unix-timestamp.
+			if isIntegerString(s) {
+				if timestamp, e := strconv.ParseInt(s, 10, 64); e == nil {
+					return c.timestampToTime(timestamp), nil
+				}
+			}
 			return zeroTime, err
 		}
 		return t, nil
@@ -283,17 +1072,91 @@ func (c *Conv) simpleToTime(src interface{}) (time.Time, error) {
 		if err != nil {
 			return zeroTime, err
 		}
-		return time.Unix(timestamp.(int64), 0), nil // Get a local time.
+		return c.timestampToTime(timestamp.(int64)), nil
 	}
 
 	// All simple types are processed in the switch block above, this line should never run.
 	return zeroTime, errCantConvertTo(src, "time.Time")
 }
 
+// timestampToTime converts a timestamp to a time in Conv.Conf.TimeLocation , the timestamp is relative to
+// Conv.Conf.Epoch , in Conv.Conf.TimestampUnit .
+func (c *Conv) timestampToTime(timestamp int64) time.Time {
+	perSecond := int64(time.Second / c.Conf.TimestampUnit.duration())
+	sec := timestamp / perSecond
+	nsec := timestamp % perSecond * int64(c.Conf.TimestampUnit.duration())
+
+	if !c.Conf.Epoch.IsZero() {
+		// time.Unix() normalizes the nanoseconds out of [0, 1e9) .
+		sec += c.Conf.Epoch.Unix()
+		nsec += int64(c.Conf.Epoch.Nanosecond())
+	}
+
+	t := time.Unix(sec, nsec) // Get a local time.
+	if c.Conf.TimeLocation != nil {
+		t = t.In(c.Conf.TimeLocation)
+	}
+	return t
+}
+
+// timeToTimestamp converts the time to a timestamp relative to Conv.Conf.Epoch , in Conv.Conf.TimestampUnit .
+// The precision below the unit is dropped.
+func (c *Conv) timeToTimestamp(t time.Time) int64 {
+	sec, nsec := t.Unix(), int64(t.Nanosecond())
+	if !c.Conf.Epoch.IsZero() {
+		sec -= c.Conf.Epoch.Unix()
+		nsec -= int64(c.Conf.Epoch.Nanosecond())
+		if nsec < 0 {
+			sec--
+			nsec += int64(time.Second)
+		}
+	}
+
+	unit := c.Conf.TimestampUnit.duration()
+	return sec*int64(time.Second/unit) + nsec/int64(unit)
+}
+
 func (c *Conv) simpleToPrimitive(src interface{}, dstKind reflect.Kind) (interface{}, error) {
+	if dstKind == reflect.Bool {
+		if res, ok := c.boolString(src); ok {
+			return res, nil
+		}
+
+		if res, ok := c.numericStringToBool(src); ok {
+			return res, nil
+		}
+	}
+
+	if c.Conf.ThousandsSeparator != "" && isKindNumber(dstKind) {
+		if v := reflect.ValueOf(src); v.Kind() == reflect.String {
+			src = strings.ReplaceAll(v.String(), c.Conf.ThousandsSeparator, "")
+		}
+	}
+
+	if c.Conf.AcceptScientificInt && (isKindInt(dstKind) || isKindUint(dstKind)) {
+		if v := reflect.ValueOf(src); v.Kind() == reflect.String {
+			src = parseScientificInt(v.String(), isKindUint(dstKind))
+		}
+	}
+
+	if c.Conf.LenientComplex && isKindComplex(dstKind) {
+		if v := reflect.ValueOf(src); v.Kind() == reflect.String {
+			cpl, ok := parseLenientComplex(v.String())
+			if !ok {
+				return nil, errCantConvertTo(src, dstKind.String())
+			}
+			src = cpl
+		}
+	}
+
+	src = namedTimeToTime(src)
 	srcTyp := reflect.TypeOf(src)
 	if IsPrimitiveType(srcTyp) {
-		return primitive.toPrimitive(src, dstKind)
+		res, err := primitive.toPrimitive(src, dstKind)
+		if err == nil && c.Conf.OnWarning != nil {
+			c.checkNumberLoss(src, res)
+		}
+		return res, err
 	}
 
 	if srcTyp == typTime {
@@ -303,7 +1166,15 @@ func (c *Conv) simpleToPrimitive(src interface{}, dstKind reflect.Kind) (interfa
 			return c.doTimeToString(tm)
 
 		case IsPrimitiveKind(dstKind):
-			timestamp := tm.Unix()
+			if unit := c.Conf.TimestampUnit.duration(); tm.Nanosecond()%int(unit) != 0 {
+				if unit == time.Second {
+					c.warn("the fractional second of %v is dropped when converting to a Unix timestamp", tm)
+				} else {
+					c.warn("the precision below %v of %v is dropped when converting to a timestamp", unit, tm)
+				}
+			}
+
+			timestamp := c.timeToTimestamp(tm)
 			return primitive.toPrimitive(timestamp, dstKind)
 		}
 	}
@@ -311,103 +1182,1596 @@ func (c *Conv) simpleToPrimitive(src interface{}, dstKind reflect.Kind) (interfa
 	return nil, fmt.Errorf("cannot convert from %v to %v", srcTyp, dstKind)
 }
 
-// SliceToSlice converts a slice to another slice.
-//
-// Each element will be converted using Conv.ConvertType() .
-// A nil slice will be converted to a nil slice of the destination type.
-// If the source value is nil interface{}, returns nil and an error.
-func (c *Conv) SliceToSlice(src interface{}, dstSliceTyp reflect.Type) (interface{}, error) {
-	const fnName = "SliceToSlice"
+// tryParseEnum parses the string as a name of the enum if the destination type is registered in Conv.Conf.Enums .
+// The second return value is false if the value is not a string or the type is not registered.
+func (c *Conv) tryParseEnum(src interface{}, dstTyp reflect.Type) (interface{}, bool, error) {
+	names, ok := c.Conf.Enums[dstTyp]
+	if !ok {
+		return nil, false, nil
+	}
 
-	if src == nil {
-		return nil, errSourceShouldNotBeNil(fnName)
+	rv := reflect.ValueOf(src)
+	if rv.Kind() != reflect.String {
+		return nil, false, nil
 	}
 
-	vSrcSlice := reflect.ValueOf(src)
-	if vSrcSlice.Kind() != reflect.Slice {
-		return nil, errForFunction(fnName, "src must be a slice, got %v", vSrcSlice.Kind())
+	name := rv.String()
+	if c.Conf.TrimStringValues {
+		name = strings.TrimSpace(name)
 	}
 
-	if dstSliceTyp.Kind() != reflect.Slice {
-		return nil, errForFunction(fnName, "the destination type must be slice, got %v", dstSliceTyp.Kind())
+	v, ok := names[name]
+	if !ok && c.Conf.EnumCaseInsensitive {
+		v, ok = findEnumNameFold(names, name)
+	}
+	if ok {
+		return reflect.ValueOf(v).Convert(dstTyp).Interface(), true, nil
 	}
 
-	// A nil slice will be converted to a nil slice.
-	if vSrcSlice.IsNil() {
-		return reflect.Zero(dstSliceTyp).Interface(), nil
+	// Fallback to the numeric value of the enum.
+	res := reflect.New(dstTyp).Elem()
+	switch {
+	case isKindInt(dstTyp.Kind()):
+		if n, err := strconv.ParseInt(name, 10, 64); err == nil && !res.OverflowInt(n) {
+			res.SetInt(n)
+			return res.Interface(), true, nil
+		}
+	case isKindUint(dstTyp.Kind()):
+		if n, err := strconv.ParseUint(name, 10, 64); err == nil && !res.OverflowUint(n) {
+			res.SetUint(n)
+			return res.Interface(), true, nil
+		}
 	}
 
-	srcLen := vSrcSlice.Len()
-	dstElemTyp := dstSliceTyp.Elem()
-	vDstSlice := reflect.MakeSlice(dstSliceTyp, 0, srcLen)
+	return nil, true, fmt.Errorf("unknown name '%v' of the enum %v", name, dstTyp)
+}
 
-	for i := 0; i < srcLen; i++ {
-		vSrcElem := vSrcSlice.Index(i)
-		srcElem := vSrcElem.Interface()
-		vDstElem, err := c.ConvertType(srcElem, dstElemTyp)
-		if err != nil {
-			return nil, errForFunction(fnName, "cannot convert to %v, at index %v : %v", dstSliceTyp, i, err.Error())
+// findEnumNameFold finds the value of the enum whose name equals to the given name case-insensitively.
+// If several names match, the first one in lexical order is used.
+func findEnumNameFold(names map[string]int64, name string) (int64, bool) {
+	var found string
+	var v int64
+	ok := false
+	for k, kv := range names {
+		if strings.EqualFold(k, name) && (!ok || k < found) {
+			found, v, ok = k, kv, true
 		}
-
-		vDstSlice = reflect.Append(vDstSlice, reflect.ValueOf(vDstElem))
 	}
-
-	return vDstSlice.Interface(), nil
+	return v, ok
 }
 
-// MapToStruct converts a map[string]interface{} to a struct.
-//
-// Each exported field of the struct is indexed using Conv.Config.FieldMatcherCreator().
-func (c *Conv) MapToStruct(m map[string]interface{}, dstTyp reflect.Type) (interface{}, error) {
-	const fnName = "MapToStruct"
+// enumName returns the name of the value if its type is registered in Conv.Conf.Enums .
+func (c *Conv) enumName(v interface{}) (string, bool) {
+	if len(c.Conf.Enums) == 0 {
+		return "", false
+	}
 
-	if m == nil {
-		return nil, errSourceShouldNotBeNil(fnName)
+	names, ok := c.Conf.Enums[reflect.TypeOf(v)]
+	if !ok {
+		return "", false
 	}
 
-	k := dstTyp.Kind()
-	if k != reflect.Struct {
-		return nil, errForFunction(fnName, "the destination type must be struct, got %v", dstTyp)
+	rv := reflect.ValueOf(v)
+	var value int64
+	switch {
+	case isKindInt(rv.Kind()):
+		value = rv.Int()
+	case isKindUint(rv.Kind()):
+		value = int64(rv.Uint())
+	default:
+		return "", false
+	}
+
+	res, found := "", false
+	for name, n := range names {
+		if n == value && (!found || name < res) {
+			res, found = name, true
+		}
+	}
+	return res, found
+}
+
+// boolString looks up the string in Conv.Conf.BoolStrings case-insensitively.
+// The second return value is false if the value is not a string or is not found.
+func (c *Conv) boolString(v interface{}) (bool, bool) {
+	if len(c.Conf.BoolStrings) == 0 {
+		return false, false
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.String {
+		return false, false
+	}
+
+	s := rv.String()
+	if c.Conf.TrimStringValues {
+		s = strings.TrimSpace(s)
+	}
+
+	if res, ok := c.Conf.BoolStrings[s]; ok {
+		return res, true
+	}
+
+	// Use the first matched key in lexical order to get a stable result.
+	var found string
+	var res, ok bool
+	for k, kv := range c.Conf.BoolStrings {
+		if strings.EqualFold(k, s) && (!ok || k < found) {
+			found, res, ok = k, kv, true
+		}
+	}
+	return res, ok
+}
+
+// numericStringToBool converts a numeric string to bool if Conv.Conf.NumericStringToBool is true: zero as false,
+// non-zero as true. The second return value is false if the value is not a numeric string or the option is off.
+func (c *Conv) numericStringToBool(v interface{}) (bool, bool) {
+	if !c.Conf.NumericStringToBool {
+		return false, false
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.String {
+		return false, false
+	}
+
+	f, err := strconv.ParseFloat(rv.String(), 64)
+	if err != nil {
+		return false, false
+	}
+	return f != 0, true
+}
+
+// checkNumberLoss sends a warning if the number src can't be restored from the converted number res,
+// e.g. converting float64(0.1) to float32, or converting int64(1<<53+1) to float64.
+func (c *Conv) checkNumberLoss(src, res interface{}) {
+	srcKind := reflect.TypeOf(src).Kind()
+	dstKind := reflect.TypeOf(res).Kind()
+	if !isKindNumber(srcKind) || !isKindNumber(dstKind) || srcKind == dstKind {
+		return
+	}
+
+	// NaN can't be compared.
+	if isKindFloat(srcKind) && math.IsNaN(reflect.ValueOf(src).Float()) {
+		return
+	}
+
+	back, err := primitive.toPrimitive(res, srcKind)
+	if err != nil || back != reflect.ValueOf(src).Convert(reflect.TypeOf(back)).Interface() {
+		c.warn("lost precision when converting %v (%T) to %v", src, src, dstKind)
+	}
+}
+
+// SliceToSlice converts a slice or an array to a slice.
+//
+// Each element will be converted using Conv.ConvertType() .
+// A nil slice will be converted to a nil slice of the destination type.
+// If the source value is nil interface{}, returns nil and an error.
+func (c *Conv) SliceToSlice(src interface{}, dstSliceTyp reflect.Type) (interface{}, error) {
+	const fnName = "SliceToSlice"
+
+	if src == nil {
+		return nil, errSourceShouldNotBeNil(fnName)
+	}
+
+	vSrcSlice := reflect.ValueOf(src)
+	if k := vSrcSlice.Kind(); k != reflect.Slice && k != reflect.Array {
+		return nil, errForFunction(fnName, "src must be a slice or an array, got %v", k)
+	}
+
+	if dstSliceTyp.Kind() != reflect.Slice {
+		return nil, errForFunction(fnName, "the destination type must be slice, got %v", dstSliceTyp.Kind())
+	}
+
+	// A nil slice will be converted to a nil slice.
+	if vSrcSlice.Kind() == reflect.Slice && vSrcSlice.IsNil() {
+		return reflect.Zero(dstSliceTyp).Interface(), nil
+	}
+
+	srcLen, err := c.checkSliceLen(vSrcSlice.Len())
+	if err != nil {
+		return nil, errForFunction(fnName, "%s", err)
+	}
+
+	dstElemTyp := dstSliceTyp.Elem()
+	vDstSlice := reflect.MakeSlice(dstSliceTyp, 0, srcLen)
+
+	for i := 0; i < srcLen; i++ {
+		ec := c.atIndex(i)
+		if err := ec.checkContext(fnName); err != nil {
+			return nil, err
+		}
+
+		vDstElem, err := ec.convertValue("ConvertType", vSrcSlice.Index(i), dstElemTyp)
+		if err != nil {
+			if e := c.passConvError(fnName, err); e != nil {
+				return nil, e
+			}
+			return nil, errForFunction(fnName, "cannot convert to %v, at index %v : %v", dstSliceTyp, i, err.Error())
+		}
+
+		vDstSlice = reflect.Append(vDstSlice, vDstElem)
+	}
+
+	return vDstSlice.Interface(), nil
+}
+
+// ConvertEach is like SliceToSlice(), but it doesn't create the destination slice: each element of src is converted
+// to dstElemType using Conv.ConvertType() , then passed to fn with its index, one by one. It is useful for huge
+// slices, e.g. in ETL pipelines, where allocating a second slice is expensive.
+//
+// src can be a slice, an array, or a channel; the values of a channel are received until it is closed. A nil slice or
+// a nil channel has no element. Since no slice is produced, Conv.Conf.MaxSliceLen is not applied.
+//
+// The iteration stops on the first error, the error returned by fn is returned as it is.
+func (c *Conv) ConvertEach(src interface{}, dstElemType reflect.Type, fn func(i int, v interface{}) error) error {
+	const fnName = "ConvertEach"
+
+	if src == nil {
+		return errSourceShouldNotBeNil(fnName)
+	}
+
+	if dstElemType == nil {
+		return errForFunction(fnName, "the destination type should not be nil")
+	}
+
+	vSrc := reflect.ValueOf(src)
+	var next func(i int) (reflect.Value, bool)
+	switch vSrc.Kind() {
+	case reflect.Slice, reflect.Array:
+		next = func(i int) (reflect.Value, bool) {
+			if i >= vSrc.Len() {
+				return reflect.Value{}, false
+			}
+			return vSrc.Index(i), true
+		}
+
+	case reflect.Chan:
+		if vSrc.Type().ChanDir()&reflect.RecvDir == 0 {
+			return errForFunction(fnName, "cannot receive from %v", vSrc.Type())
+		}
+
+		// Receiving from a nil channel blocks forever.
+		if vSrc.IsNil() {
+			return nil
+		}
+		next = func(int) (reflect.Value, bool) { return vSrc.Recv() }
+
+	default:
+		return errForFunction(fnName, "src must be a slice, an array or a channel, got %v", vSrc.Kind())
+	}
+
+	for i := 0; ; i++ {
+		ec := c.atIndex(i)
+		if err := ec.checkContext(fnName); err != nil {
+			return err
+		}
+
+		vSrcElem, ok := next(i)
+		if !ok {
+			return nil
+		}
+
+		vDstElem, err := ec.convertValue("ConvertType", vSrcElem, dstElemType)
+		if err != nil {
+			if e := c.passConvError(fnName, err); e != nil {
+				return e
+			}
+			return errForFunction(fnName, "cannot convert to %v, at index %v : %v", dstElemType, i, err.Error())
+		}
+
+		if err := fn(i, vDstElem.Interface()); err != nil {
+			return err
+		}
+	}
+}
+
+// SliceToArray converts a slice or an array to an array, the length of the source must be equal to the length of
+// the destination array.
+//
+// Each element will be converted using Conv.ConvertType() .
+// If the source value is nil interface{}, returns nil and an error.
+func (c *Conv) SliceToArray(src interface{}, dstArrayTyp reflect.Type) (interface{}, error) {
+	const fnName = "SliceToArray"
+
+	if src == nil {
+		return nil, errSourceShouldNotBeNil(fnName)
+	}
+
+	vSrc := reflect.ValueOf(src)
+	if k := vSrc.Kind(); k != reflect.Slice && k != reflect.Array {
+		return nil, errForFunction(fnName, "src must be a slice or an array, got %v", k)
+	}
+
+	if dstArrayTyp.Kind() != reflect.Array {
+		return nil, errForFunction(fnName, "the destination type must be array, got %v", dstArrayTyp.Kind())
+	}
+
+	if vSrc.Len() != dstArrayTyp.Len() {
+		return nil, errForFunction(fnName, "the length %v does not match the length of %v", vSrc.Len(), dstArrayTyp)
+	}
+
+	dstElemTyp := dstArrayTyp.Elem()
+	vDst := reflect.New(dstArrayTyp).Elem()
+
+	for i := 0; i < vSrc.Len(); i++ {
+		ec := c.atIndex(i)
+		if err := ec.checkContext(fnName); err != nil {
+			return nil, err
+		}
+
+		vDstElem, err := ec.convertValue("ConvertType", vSrc.Index(i), dstElemTyp)
+		if err != nil {
+			if e := c.passConvError(fnName, err); e != nil {
+				return nil, e
+			}
+			return nil, errForFunction(fnName, "cannot convert to %v, at index %v : %v", dstArrayTyp, i, err.Error())
+		}
+
+		vDst.Index(i).Set(vDstElem)
+	}
+
+	return vDst.Interface(), nil
+}
+
+// checkSliceLen checks the length of a source slice with Conv.Conf.MaxSliceLen , returns the length of the
+// destination slice.
+func (c *Conv) checkSliceLen(n int) (int, error) {
+	max := c.Conf.MaxSliceLen
+	if max <= 0 || n <= max {
+		return n, nil
+	}
+
+	if !c.Conf.TruncateLongSlices {
+		return 0, fmt.Errorf("the length %v exceeds the limit %v", n, max)
+	}
+
+	c.warn("the slice is truncated from %v to %v elements", n, max)
+	return max, nil
+}
+
+// MapToStructContext is like MapToStruct, but checks the cancellation of the context while iterating keys, and
+// within nested conversions of maps and slices. When the context is done, the conversion stops and returns an error
+// wrapping the error of the context, which can be checked with errors.Is() .
+func (c *Conv) MapToStructContext(ctx context.Context, m map[string]interface{}, dstTyp reflect.Type) (interface{}, error) {
+	cc := *c
+	cc.ctx = ctx
+	return cc.MapToStruct(m, dstTyp)
+}
+
+// checkContext returns an error if the context given by Conv.MapToStructContext() is done.
+// At the root level, the error is wrapped with the function name; otherwise, it is wrapped with a *ConvError with
+// the path, which is passed through the outer layers.
+func (c *Conv) checkContext(fnName string) error {
+	if c.ctx == nil {
+		return nil
+	}
+
+	err := c.ctx.Err()
+	if err == nil {
+		return nil
+	}
+
+	if c.path == "" {
+		return &funcError{fnName, err}
+	}
+	return &ConvError{Path: c.path, Err: err}
+}
+
+// MapToStruct converts a map[string]interface{} to a struct.
+//
+// Each exported field of the struct is indexed using Conv.Config.FieldMatcherCreator().
+// Keys listed in Conv.Config.KeyAliases are replaced with their aliases before matching.
+// Keys not listed in Conv.Config.AllowedKeys, if it is not empty, and keys listed in Conv.Config.ForbiddenKeys
+// are ignored.
+// Fields with the 'readonly' tag option are never set, see Conv.Config.StrictReadonly .
+//
+// Some tag options customize the conversion of a field:
+//   - 'omit': the field is ignored, it is never set, and never read by StructToMap() or StructToStruct() .
+//   - 'layout=LAYOUT': the layout used to parse and format times, like Conv.Config.LayoutTag; the layout can't
+//     contain commas.
+//   - 'unix': times are Unix timestamps in seconds, StructToMap() outputs the timestamps as int64.
+//   - 'converter=NAME': the field is converted with the converter in Conv.Config.NamedConverters .
+//
+// A field of type map[string]interface{} with the 'raw' tag option, such as `conv:",raw"`, receives a deep copy of
+// the entire source map, which keeps the original input alongside the parsed fields. At most one raw field is
+// allowed. Like 'readonly', tag options are read with the tag name of the FieldMatcherCreator.
+//
+// A field with the 'required' tag option must be matched by a key of the map. A field with the 'nonempty' tag option,
+// if matched, must not be empty after binding, i.e. the zero value, an empty slice or map, or a pointer to such
+// value. e.g. `conv:"name,required,nonempty"` rejects a missing or blank name. With Conv.Conf.RequireAllFields ,
+// all fields are required unless they have the 'optional' tag option. If more than one field fails the checks, the
+// errors are returned at once with a *MultiError .
+//
+// A field with the 'default=VALUE' tag option, such as `conv:"port,default=8080"`, is set to the value if the field
+// is not matched by any key of the map; the value is converted from a string the same way as a value of the map, so
+// the tags of the field, like 'layout=LAYOUT', are applied. Like 'layout', the value can't contain commas. A field
+// with a default value is never missing, and it is not counted by MapToStructCount() .
+func (c *Conv) MapToStruct(m map[string]interface{}, dstTyp reflect.Type) (interface{}, error) {
+	res, _, err := c.mapToStruct("MapToStruct", m, dstTyp)
+	return res, err
+}
+
+// MapToStructCount is like MapToStruct, but also returns the number of fields actually set, including those set
+// via setters. The raw field is not counted.
+// A small count usually means the keys do not match the fields, e.g. a naming or tag mismatch.
+func (c *Conv) MapToStructCount(m map[string]interface{}, dstTyp reflect.Type) (interface{}, int, error) {
+	return c.mapToStruct("MapToStructCount", m, dstTyp)
+}
+
+// AnyMapToStruct is like MapToStruct, but accepts any map whose keys are convertible to strings, such as the
+// map[interface{}]interface{} produced by YAML decoders. The keys are converted with Conv.SimpleToString() before
+// matching the fields; it is an error if a key is not a simple type, or two keys are converted to the same string.
+// A map[string]interface{} is used directly.
+//
+// Nested maps are converted the same way by Conv.ConvertType() .
+func (c *Conv) AnyMapToStruct(m interface{}, dstTyp reflect.Type) (interface{}, error) {
+	const fnName = "AnyMapToStruct"
+
+	if m == nil {
+		return nil, errSourceShouldNotBeNil(fnName)
+	}
+
+	if mm, ok := m.(map[string]interface{}); ok {
+		res, _, err := c.mapToStruct(fnName, mm, dstTyp)
+		return res, err
+	}
+
+	mm, err := c.stringKeyMap(m)
+	if err != nil {
+		return nil, errForFunction(fnName, "%s", err)
+	}
+	res, _, err := c.mapToStruct(fnName, mm, dstTyp)
+	return res, err
+}
+
+// stringKeyMap converts a map to map[string]interface{}, the keys are converted with Conv.SimpleToString() .
+// The values are kept as is.
+func (c *Conv) stringKeyMap(m interface{}) (map[string]interface{}, error) {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return nil, fmt.Errorf("the given value must be a map, got %v", v.Type())
+	}
+
+	if v.IsNil() {
+		return nil, fmt.Errorf("the map should not be nil")
+	}
+
+	res := make(map[string]interface{}, v.Len())
+	origin := make(map[string]interface{}, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key := iter.Key().Interface()
+		if !IsSimpleType(reflect.TypeOf(key)) {
+			return nil, fmt.Errorf("the keys must be convertible to strings: got %#v of type %T", key, key)
+		}
+
+		k, err := c.SimpleToString(key)
+		if err != nil {
+			return nil, fmt.Errorf("the keys must be convertible to strings: %v", err)
+		}
+
+		if prev, ok := origin[k]; ok {
+			return nil, fmt.Errorf("the keys %#v and %#v are both converted to '%v'", prev, key, k)
+		}
+		origin[k] = key
+		res[k] = iter.Value().Interface()
+	}
+	return res, nil
+}
+
+func (c *Conv) mapToStruct(fnName string, m map[string]interface{}, dstTyp reflect.Type) (interface{}, int, error) {
+	if m == nil {
+		return nil, 0, errSourceShouldNotBeNil(fnName)
+	}
+
+	k := dstTyp.Kind()
+	if k != reflect.Struct {
+		return nil, 0, errForFunction(fnName, "the destination type must be struct, got %v", dstTyp)
+	}
+
+	// Checked before any hook, the hooks may copy the map or hide its size.
+	if c.Conf.MaxFields > 0 && len(m) > c.Conf.MaxFields {
+		return nil, 0, errForFunction(fnName, "too many keys, the limit is %v, got %v", c.Conf.MaxFields, len(m))
+	}
+
+	if c.Conf.ErrorOnAmbiguousEmbedded {
+		if amb := NewFieldWalker(dstTyp, c.tagName()).ambiguousFields(); len(amb) > 0 {
+			return nil, 0, errForFunction(fnName, "ambiguous fields %v and %v of %v", amb[0][0], amb[0][1], dstTyp)
+		}
+	}
+
+	meta := getPlan(typStringMap, dstTyp).structMeta(c, dstTyp)
+	if meta.rawErr != nil {
+		return nil, 0, errForFunction(fnName, "%s", meta.rawErr)
+	}
+	rawField := meta.rawField
+
+	// The fields with the 'required' or 'nonempty' option are checked after binding.
+	checkedFields := meta.checkedFields
+	defaultFields := meta.defaultFields
+	var matched map[string]bool
+	if len(checkedFields) > 0 || len(defaultFields) > 0 {
+		matched = make(map[string]bool)
+	}
+
+	// The raw field keeps the original input.
+	raw := m
+
+	var err error
+
+	if c.Conf.MigrateMap != nil {
+		m, err = c.migrateMap(m)
+		if err != nil {
+			return nil, 0, errForFunction(fnName, "%s", err)
+		}
+	}
+
+	if c.Conf.PreProcessMap != nil {
+		m = c.Conf.PreProcessMap(m)
+	}
+
+	dst := reflect.New(dstTyp).Elem()
+
+	// The raw field is set first, so a partial result on error has it too. It is never bound by a key.
+	if rawField != nil {
+		fieldValue, err := getFieldValue(dst, rawField.Index)
+		if err != nil {
+			return nil, 0, errForFunction(fnName, "%s", err)
+		}
+		fieldValue.Set(reflect.ValueOf(deepCopyMap(raw)))
 	}
 
-	dst := reflect.New(dstTyp).Elem()
 	ctor := c.fieldMatcherCreator()
 	mather := ctor.GetMatcher(dstTyp)
 
-	for k, vm := range m {
-		field, ok := mather.MatchField(k)
-		if !ok {
+	if c.Conf.DotNestedKeys {
+		m, err = c.groupDottedKeys(m, mather)
+		if err != nil {
+			return nil, 0, errForFunction(fnName, "%s", err)
+		}
+	}
+
+	var count int
+
+	// partial returns the struct populated so far and the count if Conv.Conf.ReturnPartialOnError is true.
+	partial := func(err error) (interface{}, int, error) {
+		if c.Conf.ReturnPartialOnError {
+			return dst.Interface(), count, err
+		}
+		return nil, 0, err
+	}
+
+	var errs []error
+	var unknownKeys []string
+	for k, vm := range m {
+		if err := c.checkContext(fnName); err != nil {
+			return nil, 0, err
+		}
+
+		// The keys are already filtered when grouping dotted keys.
+		if !c.Conf.DotNestedKeys && !c.isKeyAllowed(k) {
+			continue
+		}
+
+		set, err := c.bindMapValue(dst, mather, k, vm, matched)
+		if err == errUnknownKey {
+			unknownKeys = append(unknownKeys, k)
+			continue
+		}
+
+		if err != nil {
+			if !c.Conf.CollectAllFieldErrors {
+				if e := c.passConvError(fnName, err); e != nil {
+					return partial(e)
+				}
+				return partial(errForFunction(fnName, "%s", err))
+			}
+
+			// The errors of a nested struct are flattened.
+			var me *MultiError
+			if errors.As(err, &me) {
+				errs = append(errs, me.Errors()...)
+				continue
+			}
+
+			// All collected errors are *ConvError, errors not from the conversion of a field are located by the key.
+			var ce *ConvError
+			if !errors.As(err, &ce) {
+				err = &ConvError{Path: c.at(k).path, SrcType: reflect.TypeOf(vm), Err: err}
+			}
+			errs = append(errs, err)
+			continue
+		}
+
+		if set {
+			count++
+		}
+	}
+
+	if len(unknownKeys) > 0 {
+		sort.Strings(unknownKeys)
+		if !c.Conf.CollectAllFieldErrors {
+			quoted := make([]string, len(unknownKeys))
+			for i, k := range unknownKeys {
+				quoted[i] = "'" + k + "'"
+			}
+			return partial(errForFunction(fnName, "unknown keys: %v", strings.Join(quoted, ", ")))
+		}
+
+		for _, k := range unknownKeys {
+			errs = append(errs, &ConvError{Path: c.at(k).path, SrcType: reflect.TypeOf(m[k]), Err: errUnknownKey})
+		}
+	}
+
+	// Missing fields receive their default values, then all missing fields are reported at once.
+	var presenceErrs []error
+	for _, fi := range defaultFields {
+		if err := c.applyDefaultValue(dst, fi, matched); err != nil {
+			presenceErrs = append(presenceErrs, err)
+		}
+	}
+
+	for _, fi := range checkedFields {
+		if err := c.checkFieldPresence(dst, fi, matched); err != nil {
+			presenceErrs = append(presenceErrs, err)
+		}
+	}
+
+	if len(presenceErrs) == 1 && !c.Conf.CollectAllFieldErrors {
+		return partial(c.passConvError(fnName, presenceErrs[0]))
+	}
+	errs = append(errs, presenceErrs...)
+
+	if len(errs) > 0 {
+		return partial(newMultiError(fnName, errs))
+	}
+
+	return dst.Interface(), count, nil
+}
+
+// findRawField returns the field with the 'raw' tag option, or nil if there is no such field.
+func (c *Conv) findRawField(structTyp reflect.Type) (*FieldInfo, error) {
+	tagName := c.tagName()
+	if tagName == "" {
+		return nil, nil
+	}
+
+	var res *FieldInfo
+	var err error
+	NewFieldWalker(structTyp, tagName).WalkFields(func(fi FieldInfo) bool {
+		if !fi.TagOptions.Has("raw") {
+			return true
+		}
+
+		if res != nil {
+			err = fmt.Errorf("only one raw field is allowed, got %v and %v", res.Path, fi.Path)
+			return false
+		}
+
+		if fi.Type != typStringMap {
+			err = fmt.Errorf("the raw field %v must be map[string]interface{}, got %v", fi.Path, fi.Type)
+			return false
+		}
+
+		res = &fi
+		return true
+	})
+
+	return res, err
+}
+
+// migrateMap reads the version from the map and calls Conv.Conf.MigrateMap .
+func (c *Conv) migrateMap(m map[string]interface{}) (map[string]interface{}, error) {
+	version := 0
+	if c.Conf.VersionKey != "" {
+		if v, ok := m[c.Conf.VersionKey]; ok && v != nil {
+			res, err := c.ConvertType(v, reflect.TypeOf(version))
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse the version '%v': %v", v, err)
+			}
+			version = res.(int)
+		}
+	}
+	return c.Conf.MigrateMap(version, m), nil
+}
+
+// findRequiredFields returns the fields with the 'required' or 'nonempty' tag option, or all fields that can be
+// required if Conv.Conf.RequireAllFields is true.
+func (c *Conv) findRequiredFields(structTyp reflect.Type) []FieldInfo {
+	tagName := c.tagName()
+	if tagName == "" && !c.Conf.RequireAllFields {
+		return nil
+	}
+
+	var res []FieldInfo
+	NewFieldWalker(structTyp, tagName).WalkFields(func(fi FieldInfo) bool {
+		if c.isFieldRequired(fi) || fi.TagOptions.Has("nonempty") {
+			res = append(res, fi)
+		}
+		return true
+	})
+	return res
+}
+
+// isFieldRequired returns true if the field has the 'required' tag option, or Conv.Conf.RequireAllFields is true
+// and the field isn't excluded by its tag options.
+func (c *Conv) isFieldRequired(fi FieldInfo) bool {
+	if fi.TagOptions.Has("required") {
+		return true
+	}
+
+	if !c.Conf.RequireAllFields {
+		return false
+	}
+
+	for _, opt := range []string{"optional", "omit", "raw", "readonly"} {
+		if fi.TagOptions.Has(opt) {
+			return false
+		}
+	}
+	return true
+}
+
+// findDefaultFields returns the fields with the 'default=VALUE' tag option. Fields which are never set, i.e. with
+// the 'omit', 'raw' or 'readonly' tag option, are excluded.
+func (c *Conv) findDefaultFields(structTyp reflect.Type) []FieldInfo {
+	tagName := c.tagName()
+	if tagName == "" {
+		return nil
+	}
+
+	var res []FieldInfo
+	NewFieldWalker(structTyp, tagName).WalkFields(func(fi FieldInfo) bool {
+		if _, ok := fi.TagOptions.Value("default"); !ok {
+			return true
+		}
+
+		for _, opt := range []string{"omit", "raw", "readonly"} {
+			if fi.TagOptions.Has(opt) {
+				return true
+			}
+		}
+
+		res = append(res, fi)
+		return true
+	})
+	return res
+}
+
+// applyDefaultValue sets the value given by the 'default=VALUE' tag option to the field, if the field is not
+// matched, then the field is recorded as matched. matched contains the indexes of the matched fields, given by
+// fieldIndexKey().
+func (c *Conv) applyDefaultValue(dst reflect.Value, fi FieldInfo, matched map[string]bool) error {
+	key := fieldIndexKey(fi.Index)
+	if matched[key] {
+		return nil
+	}
+
+	def, _ := fi.TagOptions.Value("default")
+	fail := func(err error) error {
+		var ce *ConvError
+		if errors.As(err, &ce) {
+			err = ce.Err
+		}
+		err = fmt.Errorf("invalid default value '%v': %v", def, err)
+		return &ConvError{Path: c.at(fi.Path).path, SrcType: typString, DstType: fi.Type, Err: err}
+	}
+
+	fieldValue, err := getFieldValue(dst, fi.Index)
+	if err != nil {
+		return fail(err)
+	}
+
+	v, err := c.convertField(fi.StructField, def)
+	if err != nil {
+		return fail(err)
+	}
+
+	fieldValue.Set(valueOrZero(v, fi.Type))
+	matched[key] = true
+	return nil
+}
+
+// checkFieldPresence checks a field with the 'required' or 'nonempty' tag option after binding.
+// A required field must be matched by a key of the source map; a nonempty field, if matched, must not be empty.
+// matched contains the indexes of the matched fields, given by fieldIndexKey().
+func (c *Conv) checkFieldPresence(dst reflect.Value, fi FieldInfo, matched map[string]bool) error {
+	if !matched[fieldIndexKey(fi.Index)] {
+		if c.isFieldRequired(fi) {
+			return &ConvError{Path: c.at(fi.Path).path, DstType: fi.Type, Err: errors.New("the field is required")}
+		}
+		return nil
+	}
+
+	if !fi.TagOptions.Has("nonempty") {
+		return nil
+	}
+
+	fieldValue, err := getFieldValue(dst, fi.Index)
+	if err != nil {
+		return &ConvError{Path: c.at(fi.Path).path, DstType: fi.Type, Err: err}
+	}
+
+	if isEmptyValue(fieldValue) {
+		return &ConvError{Path: c.at(fi.Path).path, DstType: fi.Type, Err: errors.New("the value must not be empty")}
+	}
+	return nil
+}
+
+// fieldIndexKey returns a string which identifies the field with the given index in a struct.
+func fieldIndexKey(index []int) string {
+	return fmt.Sprint(index)
+}
+
+// bindMapValue sets the value with the given key of the source map to the matched field of the struct.
+// Returns true if a field is set, or a setter is called.
+// If matched is not nil, the matched field is recorded, see checkFieldPresence().
+func (c *Conv) bindMapValue(dst reflect.Value, matcher FieldMatcher, key string, value interface{}, matched map[string]bool) (bool, error) {
+	value, err := c.urlDecode(value)
+	if err != nil {
+		return false, fmt.Errorf("error on decoding the value of '%v': %v", key, err.Error())
+	}
+
+	if c.Conf.ValueTransformer != nil {
+		value = c.Conf.ValueTransformer(key, value)
+	}
+
+	name := key
+	if alias, ok := c.Conf.KeyAliases[key]; ok {
+		name = alias
+	}
+
+	field, matcherName, ok := matchFieldWithName(matcher, name)
+	if !ok {
+		set, err := c.trySetter(dst, name, value)
+		if err == nil && !set && c.Conf.DisallowUnknownFields {
+			return false, errUnknownKey
+		}
+		return set, err
+	}
+
+	if c.Conf.OnFieldMatch != nil {
+		c.Conf.OnFieldMatch(key, field.Name, matcherName)
+	}
+
+	if matched != nil {
+		matched[fieldIndexKey(field.Index)] = true
+	}
+
+	opts := c.tagOptions(field)
+	if opts.Has("raw") || opts.Has("omit") {
+		return false, nil
+	}
+
+	if opts.Has("readonly") {
+		if c.Conf.StrictReadonly {
+			return false, fmt.Errorf("the field '%v' is readonly", field.Name)
+		}
+		return false, nil
+	}
+
+	fieldValue, err := getFieldValue(dst, field.Index)
+	if err != nil {
+		return false, err
+	}
+
+	if !fieldValue.CanSet() {
+		return c.trySetter(dst, name, value)
+	}
+
+	if c.Conf.PresenceAsTrue && isPresenceOnly(value) && isBoolOrBoolPtr(field.Type) {
+		value = true
+	}
+
+	vf, err := c.convertField(field, value)
+	if err != nil {
+		return false, err
+	}
+
+	fieldValue.Set(valueOrZero(vf, field.Type))
+	return true, nil
+}
+
+// trySetter calls the setter matches the name if Conv.Conf.UseSetters is true.
+// Returns true if the setter is called.
+func (c *Conv) trySetter(dst reflect.Value, name string, value interface{}) (bool, error) {
+	if !c.Conf.UseSetters {
+		return false, nil
+	}
+
+	ok, err := c.setBySetter(dst, name, value)
+	if err != nil {
+		return false, fmt.Errorf("error on calling the setter of '%v': %v", name, err.Error())
+	}
+	return ok, nil
+}
+
+// checkStringLen checks the converted value of a string field, or a pointer to string field, with the maximum length
+// given by the tag of the field, see Conv.Conf.MaxLenTag . Returns the value, which may be truncated.
+func (c *Conv) checkStringLen(field reflect.StructField, v interface{}) (interface{}, error) {
+	if c.Conf.MaxLenTag == "" {
+		return v, nil
+	}
+
+	tag := field.Tag.Get(c.Conf.MaxLenTag)
+	if tag == "" {
+		return v, nil
+	}
+
+	max, err := strconv.Atoi(tag)
+	if err != nil || max < 0 {
+		return nil, fmt.Errorf("invalid maximum length '%v'", tag)
+	}
+
+	// Find the string, the value may be a pointer.
+	rv := reflect.ValueOf(v)
+	var ptrTypes []reflect.Type
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		ptrTypes = append(ptrTypes, rv.Type())
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.String {
+		return v, nil
+	}
+
+	s := rv.String()
+	n := utf8.RuneCountInString(s)
+	if n <= max {
+		return v, nil
+	}
+
+	if !c.Conf.TruncateLongStrings {
+		return nil, fmt.Errorf("the length %v exceeds the limit %v", n, max)
+	}
+
+	c.warn("the string is truncated from %v to %v runes", n, max)
+	res := reflect.ValueOf(string([]rune(s)[:max])).Convert(rv.Type())
+
+	// Don't modify the string pointed by the original pointers, create new ones.
+	for i := len(ptrTypes) - 1; i >= 0; i-- {
+		p := reflect.New(ptrTypes[i].Elem())
+		p.Elem().Set(res)
+		res = p
+	}
+	return res.Interface(), nil
+}
+
+// checkRange checks whether the numeric value is in the range given by the tags of the field,
+// see Conv.Conf.MinTag and Conv.Conf.MaxTag .
+func (c *Conv) checkRange(field reflect.StructField, v interface{}) error {
+	var min, max string
+	if c.Conf.MinTag != "" {
+		min = field.Tag.Get(c.Conf.MinTag)
+	}
+	if c.Conf.MaxTag != "" {
+		max = field.Tag.Get(c.Conf.MaxTag)
+	}
+	if min == "" && max == "" {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	// cmp compares the value with the bound, returns -1, 0 or 1.
+	var cmp func(bound string) (int, error)
+	switch k := rv.Kind(); {
+	case isKindInt(k):
+		cmp = func(bound string) (int, error) {
+			b, err := strconv.ParseInt(bound, 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return compareNumbers(rv.Int() < b, rv.Int() > b), nil
+		}
+	case isKindUint(k):
+		cmp = func(bound string) (int, error) {
+			b, err := strconv.ParseUint(bound, 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return compareNumbers(rv.Uint() < b, rv.Uint() > b), nil
+		}
+	case isKindFloat(k):
+		cmp = func(bound string) (int, error) {
+			b, err := strconv.ParseFloat(bound, 64)
+			if err != nil {
+				return 0, err
+			}
+			return compareNumbers(rv.Float() < b, rv.Float() > b), nil
+		}
+	default:
+		return nil
+	}
+
+	if min != "" {
+		r, err := cmp(min)
+		if err != nil {
+			return fmt.Errorf("invalid minimum value '%v'", min)
+		}
+		if r < 0 {
+			return fmt.Errorf("the value %v is less than the minimum %v", rv.Interface(), min)
+		}
+	}
+
+	if max != "" {
+		r, err := cmp(max)
+		if err != nil {
+			return fmt.Errorf("invalid maximum value '%v'", max)
+		}
+		if r > 0 {
+			return fmt.Errorf("the value %v is greater than the maximum %v", rv.Interface(), max)
+		}
+	}
+
+	return nil
+}
+
+// applyPipes passes the value through the transforms given by the pipe tag of the field, see Conv.Conf.PipeTag .
+func (c *Conv) applyPipes(field reflect.StructField, v interface{}) (interface{}, error) {
+	if c.Conf.PipeTag == "" {
+		return v, nil
+	}
+
+	tag := field.Tag.Get(c.Conf.PipeTag)
+	if tag == "" {
+		return v, nil
+	}
+
+	for _, name := range strings.Split(tag, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		pipe, ok := c.Conf.Pipes[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown pipe '%v'", name)
+		}
+
+		var err error
+		v, err = pipe(v)
+		if err != nil {
+			return nil, fmt.Errorf("error on pipe '%v': %v", name, err.Error())
+		}
+	}
+	return v, nil
+}
+
+// decodeBytes decodes a string for a []byte field with the encoding given by the tag of the field,
+// see Conv.Conf.EncodingTag .
+func (c *Conv) decodeBytes(field reflect.StructField, v interface{}) (interface{}, error) {
+	if c.Conf.EncodingTag == "" {
+		return v, nil
+	}
+
+	encoding := field.Tag.Get(c.Conf.EncodingTag)
+	if encoding == "" {
+		return v, nil
+	}
+
+	typ := field.Type
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	s, ok := v.(string)
+	if !ok || !isByteSlice(typ) {
+		return v, nil
+	}
+
+	return decodeString(encoding, s)
+}
+
+// decodeString decodes the string with the encoding given by Conv.Conf.EncodingTag or Conv.Conf.ByteSliceEncoding .
+func decodeString(encoding, s string) ([]byte, error) {
+	var res []byte
+	var err error
+	switch encoding {
+	case "base64":
+		res, err = base64.StdEncoding.DecodeString(s)
+	case "hex":
+		res, err = hex.DecodeString(s)
+	case "raw":
+		res = []byte(s)
+	default:
+		return nil, fmt.Errorf("unknown encoding '%v'", encoding)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error on decoding %v: %v", encoding, err.Error())
+	}
+	return res, nil
+}
+
+// encodeBytes is the reverse of decodeString() .
+func encodeBytes(encoding string, b []byte) (string, error) {
+	switch encoding {
+	case "base64":
+		return base64.StdEncoding.EncodeToString(b), nil
+	case "hex":
+		return hex.EncodeToString(b), nil
+	case "raw":
+		return string(b), nil
+	default:
+		return "", fmt.Errorf("unknown encoding '%v'", encoding)
+	}
+}
+
+// unwrapSingletonSlice returns the only element of the slice if the field is of a simple type, or a pointer to it.
+// See Conv.Conf.UnwrapSingletonSlices .
+func unwrapSingletonSlice(v interface{}, fieldTyp reflect.Type) (interface{}, error) {
+	for fieldTyp.Kind() == reflect.Ptr {
+		fieldTyp = fieldTyp.Elem()
+	}
+
+	if !IsSimpleType(fieldTyp) {
+		return v, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || isByteSlice(rv.Type()) {
+		return v, nil
+	}
+
+	if rv.Len() != 1 {
+		return nil, fmt.Errorf("cannot unwrap a slice of %v elements to %v", rv.Len(), fieldTyp)
+	}
+	return rv.Index(0).Interface(), nil
+}
+
+// checkStrictType checks the value for a field of the given type when Conv.Conf.StrictTypes is true.
+func checkStrictType(v interface{}, fieldTyp reflect.Type) error {
+	for fieldTyp.Kind() == reflect.Ptr {
+		fieldTyp = fieldTyp.Elem()
+	}
+
+	if !IsSimpleType(fieldTyp) {
+		return nil
+	}
+
+	srcTyp := reflect.TypeOf(v)
+	if srcTyp == nil {
+		return nil
+	}
+
+	for srcTyp.Kind() == reflect.Ptr {
+		srcTyp = srcTyp.Elem()
+	}
+
+	if !srcTyp.AssignableTo(fieldTyp) {
+		return fmt.Errorf("strict types: cannot assign %v to %v", srcTyp, fieldTyp)
+	}
+	return nil
+}
+
+// tagName returns the tag name used by the FieldMatcherCreator, or an empty string if it is unknown.
+func (c *Conv) tagName() string {
+	if tn, ok := c.fieldMatcherCreator().(tagNamer); ok {
+		return tn.TagName()
+	}
+	return ""
+}
+
+// tagOptions returns the options of the tag of the field, see Conv.tagName() .
+func (c *Conv) tagOptions(field reflect.StructField) TagOptions {
+	tag := c.tagName()
+	if tag == "" {
+		return nil
+	}
+
+	_, opts := parseTag(field.Tag.Get(tag))
+	return opts
+}
+
+// forField returns a copy of c which is used to convert the value of the given field.
+// The copy is customized by the tags of the field, such as Conv.Conf.LayoutTag, Conv.Conf.TimeZoneTag and
+// Conv.Conf.SeparatorTag .
+func (c *Conv) forField(field reflect.StructField) (*Conv, error) {
+	fc := c.at(field.Name)
+	opts := c.tagOptions(field)
+
+	var layout string
+	if c.Conf.LayoutTag != "" {
+		layout = field.Tag.Get(c.Conf.LayoutTag)
+	}
+
+	// The 'layout' option overrides the layout tag.
+	if v, ok := opts.Value("layout"); ok && v != "" {
+		layout = v
+	}
+
+	var loc *time.Location
+	if c.Conf.TimeZoneTag != "" {
+		if tz := field.Tag.Get(c.Conf.TimeZoneTag); tz != "" {
+			var err error
+			loc, err = loadLocation(tz)
+			if err != nil {
+				return nil, fmt.Errorf("invalid time zone '%v' of field '%v': %v", tz, field.Name, err.Error())
+			}
+		}
+	}
+
+	if layout != "" {
+		defaultLoc := c.Conf.DefaultLocation
+		if defaultLoc == nil {
+			defaultLoc = time.UTC
+		}
+
+		fc.Conf.StringToTime = func(v string) (time.Time, error) { return time.ParseInLocation(layout, v, defaultLoc) }
+		fc.Conf.TimeToString = func(t time.Time) (string, error) { return t.Format(layout), nil }
+		fc.formatTime = true
+	}
+
+	if opts.Has("unix") {
+		layout = ""
+		fc.Conf.TimestampUnit = UnitSeconds
+		fc.Conf.Epoch = time.Time{}
+		fc.Conf.StringToTime = func(v string) (time.Time, error) {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid Unix timestamp '%v'", v)
+			}
+			return time.Unix(n, 0), nil
+		}
+		fc.Conf.TimeToString = func(t time.Time) (string, error) { return strconv.FormatInt(t.Unix(), 10), nil }
+		fc.unixTime = true
+	}
+
+	if c.Conf.SeparatorTag != "" {
+		if sep := field.Tag.Get(c.Conf.SeparatorTag); sep != "" {
+			fc.Conf.StringSplitter = func(v string) []string { return strings.Split(v, sep) }
+		}
+	}
+
+	if loc != nil {
+		parse, format := fc.Conf.StringToTime, fc.Conf.TimeToString
+		if parse == nil {
+			parse = DefaultStringToTime
+		}
+		if format == nil {
+			format = DefaultTimeToString
+		}
+		if layout != "" {
+			parse = func(v string) (time.Time, error) { return time.ParseInLocation(layout, v, loc) }
+		}
+
+		fc.Conf.StringToTime = func(v string) (time.Time, error) {
+			t, err := parse(v)
+			if err != nil {
+				return t, err
+			}
+			return t.In(loc), nil
+		}
+		fc.Conf.TimeToString = func(t time.Time) (string, error) { return format(t.In(loc)) }
+		fc.Conf.TimeLocation = loc
+		fc.formatTime = true
+	}
+
+	return fc, nil
+}
+
+// groupDottedKeys groups keys like 'prefix.rest' by the prefix, see Conv.Conf.DotNestedKeys for details.
+// The original keys are lost after grouping, so keys that are not allowed are removed here.
+func (c *Conv) groupDottedKeys(m map[string]interface{}, matcher FieldMatcher) (map[string]interface{}, error) {
+	res := make(map[string]interface{}, len(m))
+	groups := make(map[string]map[string]interface{})
+	for k, v := range m {
+		if !c.isKeyAllowed(k) {
 			continue
 		}
 
-		fieldValue, err := getFieldValue(dst, field.Index)
-		if err != nil {
-			return nil, errForFunction(fnName, err.Error())
+		dot := strings.IndexByte(k, '.')
+		if dot <= 0 || dot == len(k)-1 {
+			res[k] = v
+			continue
 		}
 
-		if !fieldValue.CanSet() {
+		if _, ok := matcher.MatchField(k); ok {
+			res[k] = v
 			continue
 		}
 
-		vf, err := c.ConvertType(vm, field.Type)
+		prefix := k[:dot]
+		g, ok := groups[prefix]
+		if !ok {
+			g = make(map[string]interface{})
+			groups[prefix] = g
+		}
+		g[k[dot+1:]] = v
+	}
+
+	for prefix, g := range groups {
+		if _, ok := res[prefix]; ok {
+			return nil, fmt.Errorf("the key '%v' conflicts with the dotted keys with the same prefix", prefix)
+		}
+		res[prefix] = g
+	}
+
+	return res, nil
+}
+
+// urlDecode decodes the value with url.QueryUnescape() if Conv.Conf.URLDecodeValues is true and
+// the value is a string or []string . Other values are returned as is.
+func (c *Conv) urlDecode(v interface{}) (interface{}, error) {
+	if !c.Conf.URLDecodeValues {
+		return v, nil
+	}
+
+	switch vv := v.(type) {
+	case string:
+		return url.QueryUnescape(vv)
+
+	case []string:
+		res := make([]string, len(vv))
+		for i, s := range vv {
+			d, err := url.QueryUnescape(s)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = d
+		}
+		return res, nil
+	}
+
+	return v, nil
+}
+
+// isKeyAllowed checks whether the key of the source map can be used when converting a map to a struct.
+func (c *Conv) isKeyAllowed(key string) bool {
+	if containsString(c.Conf.ForbiddenKeys, key) {
+		return false
+	}
+
+	if len(c.Conf.AllowedKeys) == 0 {
+		return true
+	}
+	return containsString(c.Conf.AllowedKeys, key)
+}
+
+// convertField converts the value for the given field of a struct.
+// Errors are returned as *ConvError with the path and the types of the field, or the *ConvError of a nested field.
+func (c *Conv) convertField(field reflect.StructField, v interface{}) (interface{}, error) {
+	srcTyp := reflect.TypeOf(v)
+	fail := func(path string, err error) error {
+		return &ConvError{Path: path, SrcType: srcTyp, DstType: field.Type, Err: err}
+	}
+
+	fc, err := c.forField(field)
+	if err != nil {
+		return nil, fail(c.at(field.Name).path, err)
+	}
+
+	v, err = c.applyPipes(field, v)
+	if err != nil {
+		return nil, fail(fc.path, err)
+	}
+
+	v, err = c.decodeBytes(field, v)
+	if err != nil {
+		return nil, fail(fc.path, err)
+	}
+
+	if c.Conf.UnwrapSingletonSlices {
+		v, err = unwrapSingletonSlice(v, field.Type)
 		if err != nil {
-			return nil, errForFunction(fnName, "error on converting field '%v': %v", field.Name, err.Error())
+			return nil, fail(fc.path, err)
 		}
+	}
 
-		fieldValue.Set(reflect.ValueOf(vf))
+	if c.Conf.StrictTypes {
+		if err := checkStrictType(v, field.Type); err != nil {
+			return nil, fail(fc.path, err)
+		}
 	}
 
-	return dst.Interface(), nil
+	dstTyp := field.Type
+	if typ, ok := c.Conf.InterfaceFieldTypes[fc.fieldPath]; ok && v != nil && dstTyp.Kind() == reflect.Interface {
+		if !typ.Implements(dstTyp) {
+			return nil, fail(fc.path, fmt.Errorf("the type %v does not implement %v", typ, dstTyp))
+		}
+		dstTyp = typ
+	}
+
+	var res interface{}
+	if name, ok := c.tagOptions(field).Value("converter"); ok {
+		res, err = c.runNamedConverter(name, v, dstTyp)
+		if err == nil && res != nil && !reflect.TypeOf(res).AssignableTo(field.Type) {
+			err = fmt.Errorf("the converter '%v' returned %T, which is not assignable to %v", name, res, field.Type)
+		}
+	} else {
+		res, err = fc.ConvertType(v, dstTyp)
+	}
+	if err != nil {
+		var me *MultiError
+		if errors.As(err, &me) {
+			return nil, me
+		}
+
+		var ce *ConvError
+		if errors.As(err, &ce) {
+			return nil, ce
+		}
+		return nil, fail(fc.path, err)
+	}
+
+	res, err = fc.checkStringLen(field, res)
+	if err != nil {
+		return nil, fail(fc.path, err)
+	}
+
+	if err := fc.checkRange(field, res); err != nil {
+		return nil, fail(fc.path, err)
+	}
+
+	if validate, ok := c.Conf.TypeValidators[field.Type]; ok {
+		if err := validate(res); err != nil {
+			return nil, fail(fc.path, err)
+		}
+	}
+
+	return res, nil
 }
 
 func (c *Conv) fieldMatcherCreator() FieldMatcherCreator {
+	if len(c.Conf.MatcherChain) > 0 {
+		return matcherChainCreator(c.Conf.MatcherChain)
+	}
+
 	g := c.Conf.FieldMatcherCreator
 	if g == nil {
-		g = new(SimpleMatcherCreator)
+		g = _defaultMatcherCreator
 	}
 	return g
 }
 
+// MapToKVSlice converts a map to a slice of key/value structs, e.g. a map[int]string to a
+// []struct{ Key int; Value string } . The element type must be a struct, or a pointer to a struct, which has fields
+// matching the names 'Key' and 'Value', see Conv.Conf.FieldMatcherCreator . The elements are sorted by the keys,
+// numbers are compared by their values, other keys by their string forms. Each element is converted from
+// map[string]interface{}{"Key": key, "Value": value} using Conv.ConvertType() .
+//
+// If the source value is nil, the function returns a nil slice of the destination type without any error.
+func (c *Conv) MapToKVSlice(m interface{}, dstSliceTyp reflect.Type) (interface{}, error) {
+	const fnName = "MapToKVSlice"
+
+	src := reflect.ValueOf(m)
+	if src.Kind() != reflect.Map {
+		return nil, errForFunction(fnName, "the given value type must be a map, got %v", src.Kind())
+	}
+
+	if dstSliceTyp.Kind() != reflect.Slice {
+		return nil, errForFunction(fnName, "the destination type must be slice, got %v", dstSliceTyp)
+	}
+
+	dstElemTyp := dstSliceTyp.Elem()
+	if _, _, err := c.kvFields(dstElemTyp); err != nil {
+		return nil, errForFunction(fnName, "%s", err)
+	}
+
+	if src.IsNil() {
+		return reflect.Zero(dstSliceTyp).Interface(), nil
+	}
+
+	keys := src.MapKeys()
+	sortMapKeys(keys)
+
+	dst := reflect.MakeSlice(dstSliceTyp, 0, len(keys))
+	for i, key := range keys {
+		ec := c.atIndex(i)
+		if err := ec.checkContext(fnName); err != nil {
+			return nil, err
+		}
+
+		entry := map[string]interface{}{"Key": key.Interface(), "Value": src.MapIndex(key).Interface()}
+		elem, err := ec.ConvertType(entry, dstElemTyp)
+		if err != nil {
+			if e := c.passConvError(fnName, err); e != nil {
+				return nil, e
+			}
+			return nil, errForFunction(fnName, "cannot convert the entry of key '%v' to %v: %v", key, dstElemTyp, err.Error())
+		}
+
+		dst = reflect.Append(dst, valueOrZero(elem, dstElemTyp))
+	}
+
+	return dst.Interface(), nil
+}
+
+// KVSliceToMap is the reverse of MapToKVSlice() , it converts a slice, or an array, of key/value structs to a map.
+// The element type must be a struct, or a pointer to a struct, which has fields matching the names 'Key' and 'Value'.
+// The keys and values are converted using Conv.ConvertType() . Nil elements are skipped; if a key appears more
+// than once, the last value is used.
+//
+// If the source value is a nil slice, the function returns a nil map of the destination type without any error.
+func (c *Conv) KVSliceToMap(src interface{}, dstMapTyp reflect.Type) (interface{}, error) {
+	const fnName = "KVSliceToMap"
+
+	if src == nil {
+		return nil, errSourceShouldNotBeNil(fnName)
+	}
+
+	vSrc := reflect.ValueOf(src)
+	if k := vSrc.Kind(); k != reflect.Slice && k != reflect.Array {
+		return nil, errForFunction(fnName, "src must be a slice or an array, got %v", k)
+	}
+
+	if dstMapTyp.Kind() != reflect.Map {
+		return nil, errForFunction(fnName, "the destination type must be map, got %v", dstMapTyp)
+	}
+
+	keyField, valueField, err := c.kvFields(vSrc.Type().Elem())
+	if err != nil {
+		return nil, errForFunction(fnName, "%s", err)
+	}
+
+	if vSrc.Kind() == reflect.Slice && vSrc.IsNil() {
+		return reflect.Zero(dstMapTyp).Interface(), nil
+	}
+
+	dst := reflect.MakeMapWithSize(dstMapTyp, vSrc.Len())
+	dstKeyType := dstMapTyp.Key()
+	dstValueType := dstMapTyp.Elem()
+	for i := 0; i < vSrc.Len(); i++ {
+		ec := c.atIndex(i)
+		if err := ec.checkContext(fnName); err != nil {
+			return nil, err
+		}
+
+		elem := vSrc.Index(i)
+		for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+		if !elem.IsValid() {
+			continue
+		}
+
+		srcKey, _ := fieldByIndex(elem, keyField.Index)
+		dstKey, err := ec.forKey().convertValue("ConvertType", srcKey, dstKeyType)
+		if err != nil {
+			return nil, errForFunction(fnName, "cannot convert the key at index %v to %v: %v", i, dstKeyType, err.Error())
+		}
+
+		srcVal, _ := fieldByIndex(elem, valueField.Index)
+		dstVal, err := ec.convertValue("ConvertType", srcVal, dstValueType)
+		if err != nil {
+			if e := c.passConvError(fnName, err); e != nil {
+				return nil, e
+			}
+			return nil, errForFunction(fnName, "cannot convert the value at index %v to %v: %v", i, dstValueType, err.Error())
+		}
+
+		dst.SetMapIndex(dstKey, dstVal)
+	}
+
+	return dst.Interface(), nil
+}
+
+// kvFields returns the fields matching the names 'Key' and 'Value' of the given type of struct, or pointer to struct.
+func (c *Conv) kvFields(typ reflect.Type) (keyField, valueField reflect.StructField, err error) {
+	structTyp := typ
+	for structTyp.Kind() == reflect.Ptr {
+		structTyp = structTyp.Elem()
+	}
+
+	if structTyp.Kind() == reflect.Struct {
+		matcher := c.fieldMatcherCreator().GetMatcher(structTyp)
+		var keyOK, valueOK bool
+		keyField, keyOK = matcher.MatchField("Key")
+		valueField, valueOK = matcher.MatchField("Value")
+		if keyOK && valueOK {
+			return keyField, valueField, nil
+		}
+	}
+
+	err = fmt.Errorf("the element type must be a struct with the fields Key and Value, got %v", typ)
+	return
+}
+
 // MapToMap converts a map to another map.
 // If the source value is nil, the function returns a nil map of the destination type without any error.
 //
@@ -435,18 +2799,35 @@ func (c *Conv) MapToMap(m interface{}, typ reflect.Type) (interface{}, error) {
 
 	for iter.Next() {
 		srcKey := iter.Key().Interface()
-		dstKey, err := c.ConvertType(srcKey, dstKeyType)
+		ec := c.atIndex(srcKey)
+		if err := ec.checkContext(fnName); err != nil {
+			return nil, err
+		}
+
+		dstKey, err := c.forKey().ConvertType(srcKey, dstKeyType)
 		if err != nil {
 			return nil, errForFunction(fnName, "cannot covert key '%v' to %v: %v", srcKey, dstKeyType, err.Error())
 		}
 
-		srcVal := iter.Value().Interface()
-		dstVal, err := c.ConvertType(srcVal, dstValueType)
+		srcVal, err := c.urlDecode(iter.Value().Interface())
+		if err != nil {
+			return nil, errForFunction(fnName, "cannot decode value of key '%v': %v", srcKey, err.Error())
+		}
+
+		if c.Conf.ValueTransformer != nil {
+			srcVal = c.Conf.ValueTransformer(fmt.Sprint(srcKey), srcVal)
+		}
+
+		dstVal, err := ec.ConvertType(srcVal, dstValueType)
 		if err != nil {
+			if e := c.passConvError(fnName, err); e != nil {
+				return nil, e
+			}
 			return nil, errForFunction(fnName, "cannot covert value of key '%v' to %v: %v", srcKey, dstValueType, err.Error())
 		}
 
-		dst.SetMapIndex(reflect.ValueOf(dstKey), reflect.ValueOf(dstVal))
+		// A nil value must be set as the zero value, an invalid reflect.Value deletes the key.
+		dst.SetMapIndex(valueOrZero(dstKey, dstKeyType), valueOrZero(dstVal, dstValueType))
 	}
 
 	return dst.Interface(), nil
@@ -454,13 +2835,23 @@ func (c *Conv) MapToMap(m interface{}, typ reflect.Type) (interface{}, error) {
 
 // StructToMap is partially like json.Unmarshal(json.Marshal(v), &someMap) . It converts a struct to map[string]interface{} .
 //
+// The keys of the map are the names of the fields. If the field matcher uses a tag (see SimpleMatcherConfig.Tag),
+// the tags are processed the same way as FieldWalker does: the key of a tagged field is the name in the tag, and a
+// tagged embedded struct is converted as a single field instead of being flattened. The tag options 'omit',
+// 'layout=LAYOUT', 'unix' and 'converter=NAME' are applied, see MapToStruct() . Like encoding/json, fields tagged
+// '-' are excluded, a field with the 'omitempty' option is omitted if it is empty, i.e. false, 0, a nil pointer,
+// a nil interface, or an empty string, slice, map or array; the 'string' option formats numbers and booleans
+// as strings.
+//
 // Each value of exported field will be processed recursively with an internal function f() , which:
 //
 // Simple types, for which IsSimpleType() returns true:
 //   - A type whose kind is primitive, will be converted to a primitive value.
+//   - A time with a layout tag (see Conv.Conf.LayoutTag) is formatted to a string.
 //   - For other types, the value will be cloned into the map directly.
 //
 // Slices:
+//   - A byte slice is converted to a string if Conv.Conf.ByteSliceEncoding is set.
 //   - A nil slice is converted to a nil slice; an empty slice is converted to an empty slice with cap=0.
 //   - A non-empty slice is converted to another slice, each element is process with f() , all elements must be the same type.
 //
@@ -468,7 +2859,8 @@ func (c *Conv) MapToMap(m interface{}, typ reflect.Type) (interface{}, error) {
 //   - A nil map are converted to nil of map[string]interface{} .
 //   - A non-nil map is converted to map[string]interface{} , keys are processed with Conv.ConvertType() , values with f() .
 //
-// Structs are converted to map[string]interface{} using Conv.StructToMap() recursively.
+// Structs are converted to map[string]interface{} using Conv.StructToMap() recursively, until the depth given by
+// Conv.Conf.StructToMapMaxDepth is reached. With Conv.Conf.StructToMapShallow , f() is not applied.
 //
 // Pointers:
 //   - Nils are ignored.
@@ -489,23 +2881,76 @@ func (c *Conv) StructToMap(v interface{}) (map[string]interface{}, error) {
 
 	src := reflect.ValueOf(v)
 	dst := reflect.MakeMap(reflect.TypeOf(map[string]interface{}(nil)))
-	walker := NewFieldWalker(src.Type(), "") // TODO Tags on fields are not processed here.
+	walker := NewFieldWalker(src.Type(), c.tagName())
+
+	// Track the depth for Conv.Conf.StructToMapMaxDepth .
+	cc := *c
+	cc.mapDepth++
+	c = &cc
 
 	var err error
 	walker.WalkValues(src, func(fi FieldInfo, fieldValue reflect.Value) bool {
+		if fi.TagOptions.Has("omit") {
+			return true
+		}
+
+		if fi.TagOptions.Has("omitempty") && isJSONEmptyValue(fieldValue) {
+			return true
+		}
+
+		var fc *Conv
 		var ff reflect.Value
-		ff, err = c.convertToMapValue(fieldValue)
+		fc, err = c.forField(fi.StructField)
+		if err == nil {
+			if name, ok := fi.TagOptions.Value("converter"); ok {
+				var res interface{}
+				res, err = c.runNamedConverter(name, fieldValue.Interface(), typEmptyInterface)
+				ff = reflect.ValueOf(res)
+			} else if c.Conf.StructToMapShallow {
+				ff = fieldValue
+			} else {
+				ff, err = fc.convertToMapValue(fieldValue)
+			}
+		}
+
+		if err == nil && fi.TagOptions.Has("string") && ff.IsValid() && IsPrimitiveKind(ff.Kind()) {
+			// Like encoding/json, booleans are formatted as 'true' or 'false'.
+			if ff.Kind() == reflect.Bool {
+				ff = reflect.ValueOf(strconv.FormatBool(ff.Bool()))
+			} else {
+				var s string
+				s, err = fc.SimpleToString(ff.Interface())
+				ff = reflect.ValueOf(s)
+			}
+		}
 
 		if err != nil {
-			err = errForFunction(fnName, "error on converting field %v: %v", fi.Name, err.Error())
+			err = errForFunction(fnName, "error on converting field '%v': %v", fi.Name, err)
 			return false
 		}
 
 		// If ff is nil value, the map index will not be set.
-		dst.SetMapIndex(reflect.ValueOf(fi.Name), ff)
+		dst.SetMapIndex(reflect.ValueOf(fieldKey(fi)), ff)
 		return true
 	})
 
+	if err == nil && c.Conf.IncludeGetters {
+		walkGetters(src, func(name string, value reflect.Value) bool {
+			ff := value
+			if !c.Conf.StructToMapShallow {
+				ff, err = c.at(name).convertToMapValue(value)
+			}
+
+			if err != nil {
+				err = errForFunction(fnName, "error on converting getter '%v': %v", name, err)
+				return false
+			}
+
+			dst.SetMapIndex(reflect.ValueOf(name), ff)
+			return true
+		})
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -513,6 +2958,7 @@ func (c *Conv) StructToMap(v interface{}) (map[string]interface{}, error) {
 }
 
 func (c *Conv) convertToMapValue(fv reflect.Value) (reflect.Value, error) {
+	orig := fv
 	for fv.Kind() == reflect.Ptr {
 		fv = fv.Elem()
 	}
@@ -523,6 +2969,23 @@ func (c *Conv) convertToMapValue(fv reflect.Value) (reflect.Value, error) {
 		return reflect.ValueOf(nil), nil
 
 	case reflect.Struct:
+		// time.Time is a struct, but it is a simple type.
+		if IsSimpleType(fv.Type()) {
+			return c.simpleToMapValue(fv)
+		}
+
+		// e.g. sql.NullString is converted to its value, or nil if it is not valid.
+		if v, ok, err := driverValue(fv.Interface()); ok {
+			if err != nil || v == nil {
+				return reflect.ValueOf(nil), err
+			}
+			return c.convertToMapValue(reflect.ValueOf(v))
+		}
+
+		if c.reachedMaxMapDepth() {
+			return orig, nil
+		}
+
 		v, err := c.StructToMap(fv.Interface())
 		if err != nil {
 			return reflect.Value{}, err
@@ -530,9 +2993,15 @@ func (c *Conv) convertToMapValue(fv reflect.Value) (reflect.Value, error) {
 
 		return reflect.ValueOf(v), nil
 
-	case reflect.Slice:
+	case reflect.Slice, reflect.Array:
+		// Arrays are converted to slices.
+		if enc := c.Conf.ByteSliceEncoding; enc != "" && isByteSlice(fv.Type()) {
+			s, err := encodeBytes(enc, fv.Bytes())
+			return reflect.ValueOf(s), err
+		}
+
 		switch {
-		case fv.IsNil():
+		case fv.Kind() == reflect.Slice && fv.IsNil():
 			ft := fv.Type()
 			sliceType, ok := c.determineSliceTypeForMapValue(ft)
 			if !ok {
@@ -581,7 +3050,7 @@ func (c *Conv) convertToMapValue(fv reflect.Value) (reflect.Value, error) {
 			oldVal := iter.Value()
 
 			var newKey string
-			err := c.Convert(oldKey.Interface(), &newKey)
+			err := c.forKey().Convert(oldKey.Interface(), &newKey)
 			if err != nil {
 				return reflect.Value{}, fmt.Errorf("key %v: %v", oldKey, err.Error())
 			}
@@ -601,27 +3070,67 @@ func (c *Conv) convertToMapValue(fv reflect.Value) (reflect.Value, error) {
 		return c.convertToMapValue(fv)
 
 	default:
-		if IsPrimitiveKind(fv.Kind()) {
-			res, err := c.simpleToPrimitive(fv.Interface(), fv.Kind())
-			if err != nil {
-				return reflect.Value{}, err
-			}
-			return reflect.ValueOf(res), nil
+		return c.simpleToMapValue(fv)
+	}
+}
+
+// reachedMaxMapDepth returns true if nested structs are no longer converted to maps, see
+// Conv.Conf.StructToMapMaxDepth .
+func (c *Conv) reachedMaxMapDepth() bool {
+	max := c.Conf.StructToMapMaxDepth
+	return max > 0 && c.mapDepth >= max
+}
+
+func (c *Conv) simpleToMapValue(fv reflect.Value) (reflect.Value, error) {
+	if c.Conf.JSONSafeMapValues {
+		switch {
+		case fv.Type() == typDuration:
+			return reflect.ValueOf(fv.Interface().(time.Duration).String()), nil
+
+		case fv.Kind() == reflect.Complex64:
+			return reflect.ValueOf(strconv.FormatComplex(fv.Complex(), 'g', -1, 64)), nil
+
+		case fv.Kind() == reflect.Complex128:
+			return reflect.ValueOf(strconv.FormatComplex(fv.Complex(), 'g', -1, 128)), nil
 		}
+	}
 
-		if !IsSimpleType(fv.Type()) {
-			return reflect.Value{}, fmt.Errorf("must be a simple type, got %v", fv.Kind())
+	if IsPrimitiveKind(fv.Kind()) {
+		res, err := c.simpleToPrimitive(fv.Interface(), fv.Kind())
+		if err != nil {
+			return reflect.Value{}, err
 		}
+		return reflect.ValueOf(res), nil
+	}
+
+	if !IsSimpleType(fv.Type()) {
+		return reflect.Value{}, fmt.Errorf("must be a simple type, got %v", fv.Kind())
+	}
+
+	if c.unixTime {
+		return reflect.ValueOf(fv.Convert(typTime).Interface().(time.Time).Unix()), nil
+	}
 
-		// Consider convert types which are simple but non-primitive - such as time.Time - to primitive types?
-		return fv, nil
+	if c.formatTime || c.Conf.JSONSafeMapValues {
+		s, err := c.doTimeToString(fv.Convert(typTime).Interface().(time.Time))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(s), nil
 	}
+
+	// Consider convert types which are simple but non-primitive - such as time.Time - to primitive types?
+	return fv, nil
 }
 
 func (c *Conv) determineSliceTypeForMapValue(srcSliceType reflect.Type) (dstSliceType reflect.Type, ok bool) {
 	elemType := srcSliceType.Elem()
-	if IsSimpleType(elemType) {
+	if IsSimpleType(elemType) || (c.reachedMaxMapDepth() && isStructOrStructPtr(elemType)) {
+		// The structs are kept, see convertToMapValue() . Arrays are converted to slices.
 		dstSliceType = srcSliceType
+		if srcSliceType.Kind() == reflect.Array {
+			dstSliceType = reflect.SliceOf(elemType)
+		}
 		ok = true
 		return
 	}
@@ -633,7 +3142,7 @@ func (c *Conv) determineSliceTypeForMapValue(srcSliceType reflect.Type) (dstSlic
 		ok = true
 		return
 
-	case reflect.Slice:
+	case reflect.Slice, reflect.Array:
 		innerSliceType, innerOK := c.determineSliceTypeForMapValue(elemType)
 		if !innerOK {
 			return
@@ -653,6 +3162,9 @@ func (c *Conv) determineSliceTypeForMapValue(srcSliceType reflect.Type) (dstSlic
 // If the given value is nil, returns nil and an error.
 //
 // When converting, each field of the destination struct is indexed using Conv.Config.FieldMatcherCreator.
+// The fields of the source struct are matched by their names, or by the names in the tags if the field matcher
+// uses a tag, see StructToMap() for how the tags are processed. A tagged embedded struct is matched as a single
+// named field on both sides, the same way as MapToStruct() does, so the tagged embedding round-trips.
 // The field values are converted using Conv.ConvertType() .
 //
 // This function can be used to deep-clone a struct.
@@ -677,39 +3189,156 @@ func (c *Conv) StructToStruct(src interface{}, dstTyp reflect.Type) (interface{}
 	mather := ctor.GetMatcher(dstTyp)
 	vSrc := reflect.ValueOf(src)
 	vDst := reflect.New(dstTyp).Elem()
-	walker := NewFieldWalker(vSrc.Type(), "") // TODO Tags on fields are not processed here.
+	walker := NewFieldWalker(vSrc.Type(), c.tagName())
 
 	var err error
+	var errs []error
+
+	// bind returns false if the conversion should stop.
+	bind := func(name string, value reflect.Value) bool {
+		e := c.bindStructValue(vDst, mather, name, value)
+		if e == nil {
+			return true
+		}
+
+		if !c.Conf.CollectAllFieldErrors {
+			err = c.structToStructError(fnName, e)
+			return false
+		}
+
+		var me *MultiError
+		if errors.As(e, &me) {
+			errs = append(errs, me.Errors()...)
+			return true
+		}
+
+		// Like MapToStruct, errors not from the conversion of a field are located by the name.
+		var ce *ConvError
+		if !errors.As(e, &ce) {
+			e = &ConvError{Path: c.at(name).path, SrcType: value.Type(), Err: e}
+		}
+		errs = append(errs, e)
+		return true
+	}
+
 	walker.WalkValues(vSrc, func(fi FieldInfo, fieldValue reflect.Value) bool {
-		field, ok := mather.MatchField(fi.Name)
-		if !ok {
+		if fi.TagOptions.Has("omit") {
 			return true
 		}
+		return bind(fieldKey(fi), fieldValue)
+	})
+
+	if err == nil && c.Conf.IncludeGetters {
+		walkGetters(vSrc, bind)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, newMultiError(fnName, errs)
+	}
+	return vDst.Interface(), nil
+}
+
+// fieldKey returns the name used to match a field of the source struct: the name in the tag if the field is tagged,
+// otherwise the name of the field.
+func fieldKey(fi FieldInfo) string {
+	if fi.TagValue != "" {
+		return fi.TagValue
+	}
+	return fi.Name
+}
+
+func (c *Conv) structToStructError(fnName string, err error) error {
+	if e := c.passConvError(fnName, err); e != nil {
+		return e
+	}
+	return errForFunction(fnName, "%s", err)
+}
+
+// bindStructValue sets the value of a field or getter of the source struct to the matched field of the
+// destination struct.
+func (c *Conv) bindStructValue(dst reflect.Value, matcher FieldMatcher, name string, value reflect.Value) error {
+	field, ok := matcher.MatchField(name)
+	if !ok {
+		return nil
+	}
+
+	vField, err := getFieldValue(dst, field.Index)
+	if err != nil {
+		return err
+	}
+
+	if !vField.CanSet() || c.tagOptions(field).Has("omit") {
+		return nil
+	}
+
+	dstValue, err := c.convertField(field, value.Interface())
+	if err != nil {
+		return err
+	}
+
+	vField.Set(valueOrZero(dstValue, field.Type))
+	return nil
+}
+
+// copyInput returns a deep copy of the value if Conv.Conf.DeepCopyInputs is true; otherwise returns the value as is.
+func (c *Conv) copyInput(v interface{}) interface{} {
+	if !c.Conf.DeepCopyInputs {
+		return v
+	}
+	return deepCopy(v)
+}
 
-		vField, e := getFieldValue(vDst, field.Index)
-		if e != nil {
-			err = errForFunction(fnName, e.Error())
-			return false
-		}
+// identityKey identifies a source pointer converted to a pointer type, see Conv.Conf.PreserveIdentity .
+// The source type is included, since a pointer to a struct and a pointer to its first field have the same address.
+type identityKey struct {
+	ptr    uintptr
+	srcTyp reflect.Type
+	dstTyp reflect.Type
+}
 
-		if !vField.CanSet() {
-			return true
-		}
+// convertPointerIdentity converts a non-nil pointer to the pointer type, the result is reused for the same pointer,
+// see Conv.Conf.PreserveIdentity . The second return value is false if the values are not pointers.
+func (c *Conv) convertPointerIdentity(fnName string, v reflect.Value, dstTyp reflect.Type) (reflect.Value, bool, error) {
+	if dstTyp.Kind() != reflect.Ptr || v.Kind() != reflect.Ptr || v.IsNil() {
+		return reflect.Value{}, false, nil
+	}
 
-		dstValue, e := c.ConvertType(fieldValue.Interface(), vField.Type())
-		if e != nil {
-			err = errForFunction(fnName, "error on converting field %v: %v", field.Name, e.Error())
-			return false
-		}
+	key := identityKey{v.Pointer(), v.Type(), dstTyp}
+	if p, ok := c.identities[key]; ok {
+		return p, true, nil
+	}
 
-		vField.Set(reflect.ValueOf(dstValue))
-		return true
-	})
+	// The pointer is registered before converting the value it points to, so cycles end here.
+	p := reflect.New(dstTyp.Elem())
+	c.identities[key] = p
 
+	elem, err := c.convertValue(fnName, v, dstTyp.Elem())
 	if err != nil {
-		return nil, err
+		delete(c.identities, key)
+		return reflect.Value{}, true, err
 	}
-	return vDst.Interface(), nil
+
+	p.Elem().Set(elem)
+	return p, true, nil
+}
+
+// DeepClone returns a deep copy of the given value. Unlike cloning with ConvertType(src, reflect.TypeOf(src)),
+// the value is copied as is without any conversion, and cyclic references are supported: the same map, slice or
+// pointer in the source is copied once, so cycles and shared references are reproduced in the copy.
+//
+// Maps, slices, arrays, pointers, interfaces and exported fields of structs are copied recursively; other values,
+// including unexported fields of structs, channels and functions, are copied as is.
+// If the given value is nil, returns nil and an error.
+func (c *Conv) DeepClone(src interface{}) (interface{}, error) {
+	const fnName = "DeepClone"
+
+	if src == nil {
+		return nil, errSourceShouldNotBeNil(fnName)
+	}
+	return deepCopy(src), nil
 }
 
 // ConvertType is the core function of Conv . It converts the given value to the destination type.
@@ -718,15 +3347,37 @@ func (c *Conv) StructToStruct(src interface{}, dstTyp reflect.Type) (interface{}
 //
 //	simple                 -> simple                  use Conv.SimpleToSimple()
 //	string                 -> []simple                use Conv.StringToSlice()
+//	[]byte                 -> string                  as string([]byte)
+//	string                 -> TextUnmarshaler         use UnmarshalText() on a pointer to the destination
+//	TextMarshaler          -> string                  use MarshalText()
+//	driver.Valuer          -> simple or []byte        use Value(), nil becomes the zero value or a nil pointer
+//	simple, []byte or nil  -> sql.Scanner             use Scan() on a pointer to the destination
 //	map[string]interface{} -> struct                  use Conv.MapToStruct()
+//	map[ANY]ANY            -> struct                  keys are converted like Conv.AnyMapToStruct(), then use Conv.MapToStruct()
 //	map[ANY]ANY            -> map[ANY]ANY             use Conv.MapToMap()
+//	map[ANY]ANY            -> []struct                if Conv.Conf.MapKVSlices is true, use Conv.MapToKVSlice()
+//	[]struct or [N]struct  -> map[ANY]ANY             if Conv.Conf.MapKVSlices is true, use Conv.KVSliceToMap()
 //	[]ANY                  -> []ANY                   use Conv.SliceToSlice()
+//	[N]ANY                 -> []ANY                   use Conv.SliceToSlice()
+//	[]ANY or [N]ANY        -> [N]ANY                  use Conv.SliceToArray()
+//	chan ANY               -> []ANY                   if Conv.Conf.DrainChannels is true, use Conv.SliceToSlice()
 //	struct                 -> map[string]interface{}  use Conv.StructToMap()
+//	struct                 -> map[ANY]ANY             use Conv.StructToMap(), then Conv.MapToMap()
 //	struct                 -> struct                  use Conv.StructToStruct()
 //
 // 'ANY' generally can be any other type listed above. 'simple' is some type which IsSimpleType() returns true.
+// 'TextMarshaler' and 'TextUnmarshaler' are the interfaces in the encoding package. With driver.Valuer and
+// sql.Scanner , types like sql.NullString can be converted from and to their primitive counterparts.
+// The text methods are not used for time.Time and the types registered in Conv.Conf.Enums, they are handled by
+// SimpleToSimple() .
+//
+// If src is one of Conv.Conf.NullStrings, the zero value of the destination type is returned.
 //
 // If the destination type is the type of the empty interface, the function returns src directly without any error.
+// For other interfaces, if src implements the interface, src is returned directly too, e.g. a *bytes.Buffer can be
+// converted to io.Reader; a nil is converted to a nil interface. If Conv.Conf.DeepCopyInputs is true, a deep copy of
+// src is returned instead. Otherwise, if the interface is registered in Conv.Conf.InterfaceImplementations, src is
+// converted to the registered concrete type.
 //
 // For pointers:
 // If the source value is a pointer, the value pointed to will be extracted and converted.
@@ -740,68 +3391,201 @@ func (c *Conv) StructToStruct(src interface{}, dstTyp reflect.Type) (interface{}
 // the map has only one key and the key is an empty string, the conversion is performed over the value other than
 // the map itself. This is a special contract for some particular situation, when some code is working on maps only.
 func (c *Conv) ConvertType(src interface{}, dstTyp reflect.Type) (interface{}, error) {
-	const fnName = "ConvertType"
+	res, err := c.convertValue("ConvertType", reflect.ValueOf(src), dstTyp)
+	if err != nil {
+		return nil, err
+	}
+	return valueInterface(res), nil
+}
+
+// ConvertValue is like ConvertType() , but receives and returns reflect.Value , it's convenient for code which
+// already works with reflection, the result can be set to a field or an element directly.
+// It is the common core of ConvertType() and Convert() . Note that it is not free of boxing: most conversions
+// still work on interface{} internally, only the elements of slices and arrays are passed on as
+// reflect.Value .
+//
+// An invalid src is treated as nil. If src is an interface, the value it contains is converted.
+// On success, the result is always valid and assignable to dstTyp: a nil result is returned as the zero value of
+// dstTyp.
+func (c *Conv) ConvertValue(src reflect.Value, dstTyp reflect.Type) (reflect.Value, error) {
+	return c.convertValue("ConvertValue", src, dstTyp)
+}
+
+// convertValue is the implementation of ConvertType() , ConvertValue() and Convert() , errors are reported with
+// the given function name.
+func (c *Conv) convertValue(fnName string, vSrc reflect.Value, dstTyp reflect.Type) (reflect.Value, error) {
+	for vSrc.Kind() == reflect.Interface {
+		vSrc = vSrc.Elem()
+	}
+	src := valueInterface(vSrc)
+
+	if c.isNullString(src) {
+		return reflect.Zero(dstTyp), nil
+	}
 
 	if dstTyp == typEmptyInterface {
-		return src, nil
+		return valueOrZero(c.copyInput(src), dstTyp), nil
 	}
 
 	// Convert nils to nil pointers.
 	if src == nil && dstTyp.Kind() == reflect.Ptr {
-		return reflect.Zero(dstTyp).Interface(), nil
+		return reflect.Zero(dstTyp), nil
+	}
+
+	// Values like an invalid sql.NullString are nils too.
+	if dstTyp.Kind() == reflect.Ptr && c.isNullValuer(src, dstTyp) {
+		return reflect.Zero(dstTyp), nil
+	}
+
+	if dstTyp.Kind() == reflect.Interface {
+		if src == nil {
+			return reflect.Zero(dstTyp), nil
+		}
+
+		if vSrc.Type().Implements(dstTyp) {
+			return reflect.ValueOf(c.copyInput(src)), nil
+		}
 	}
 
 	// CustomConverters
-	for i, f := range c.Conf.CustomConverters {
-		res, err := f(src, dstTyp)
-		if err != nil {
-			return nil, errForFunction(fnName, "converter[%d]: %s", i, err.Error())
+	res, err := c.runCustomConverters(src, dstTyp)
+	if err != nil {
+		return reflect.Value{}, errForFunction(fnName, "%s", err)
+	}
+
+	if res != nil {
+		return reflect.ValueOf(res), nil
+	}
+
+	if impl, ok := c.Conf.InterfaceImplementations[dstTyp]; ok && dstTyp.Kind() == reflect.Interface {
+		if !impl.Implements(dstTyp) {
+			return reflect.Value{}, errForFunction(fnName, "the type %v does not implement %v", impl, dstTyp)
 		}
+		return c.convertValue(fnName, vSrc, impl)
+	}
 
-		if res != nil {
-			return res, nil
+	// Convert nil pointers to nil pointers, e.g. a nil *T field of a struct.
+	if dstTyp.Kind() == reflect.Ptr && vSrc.Kind() == reflect.Ptr && vSrc.IsNil() {
+		return reflect.Zero(dstTyp), nil
+	}
+
+	if c.Conf.PreserveIdentity {
+		if c.identities == nil {
+			cc := *c
+			cc.identities = make(map[identityKey]reflect.Value)
+			c = &cc
+		}
+
+		if res, ok, err := c.convertPointerIdentity(fnName, vSrc, dstTyp); ok {
+			return res, err
 		}
 	}
 
 	// Try to get the underlying type from a pointer type.
 	// It may be a pointer to another pointer, we should count the depth.
 	ptrDepth := 0
-	for dstTyp.Kind() == reflect.Ptr {
-		dstTyp = dstTyp.Elem()
+	elemTyp := dstTyp
+	for elemTyp.Kind() == reflect.Ptr {
+		elemTyp = elemTyp.Elem()
 		ptrDepth++
 	}
 
-	dst, err := c.convertToNonPtr(src, dstTyp)
-	if err != nil {
-		return nil, errForFunction(fnName, err.Error())
+	var dst interface{}
+	if ptrDepth > 0 {
+		// The converters are given a chance to convert to the underlying type, e.g. a converter for T also works
+		// for a field of type *T. The raw source value is passed to them, not a pre-converted one.
+		dst, err = c.runCustomConverters(src, elemTyp)
+		if err != nil {
+			return reflect.Value{}, errForFunction(fnName, "%s", err)
+		}
 	}
 
-	// Convert to pointer if needed.
-	if ptrDepth > 0 {
-		var prev, current reflect.Value
-		for i := 0; i < ptrDepth; i++ {
-			if i == 0 {
-				prev = reflect.ValueOf(dst)
-			} else {
-				prev = current
+	if dst == nil {
+		dst, err = c.convertToNonPtr(src, elemTyp)
+		if err != nil {
+			if e := c.passConvError(fnName, err); e != nil {
+				return reflect.Value{}, e
 			}
+			return reflect.Value{}, errForFunction(fnName, "%s", err)
+		}
+	}
+
+	// Convert to pointer if needed.
+	current := valueOrZero(dst, elemTyp)
+	for i := 0; i < ptrDepth; i++ {
+		prev := current
+		current = reflect.New(prev.Type())
+		current.Elem().Set(prev)
+	}
+	return current, nil
+}
+
+// runNamedConverter calls the converter registered in Conv.Conf.NamedConverters with the given name.
+func (c *Conv) runNamedConverter(name string, src interface{}, dstTyp reflect.Type) (interface{}, error) {
+	f, ok := c.Conf.NamedConverters[name]
+	if !ok || f == nil {
+		return nil, fmt.Errorf("unknown converter '%v'", name)
+	}
+
+	res, err := f(src, dstTyp)
+	if err != nil {
+		return nil, fmt.Errorf("converter '%v': %v", name, err)
+	}
+	return res, nil
+}
+
+// runCustomConverters goes through Conv.Conf.CustomConverters, returns the first non-nil result or error.
+// If no converter returns a result, returns nil with no error.
+func (c *Conv) runCustomConverters(src interface{}, dstTyp reflect.Type) (interface{}, error) {
+	if f, ok := c.Conf.TypedConverters[dstTyp]; ok {
+		res, err := f(src, dstTyp)
+		if err != nil {
+			return nil, fmt.Errorf("converter for %v: %s", dstTyp, err.Error())
+		}
 
-			current = reflect.New(prev.Type())
-			current.Elem().Set(prev)
+		if res != nil {
+			return res, nil
 		}
+	}
+
+	if len(c.Conf.CustomConvertersEx) > 0 {
+		v := reflect.ValueOf(src)
+		for i, f := range c.Conf.CustomConvertersEx {
+			res, ok, err := f(v, dstTyp)
+			if err != nil {
+				return nil, fmt.Errorf("converterEx[%d]: %s", i, err.Error())
+			}
 
-		dst = current.Interface()
+			if ok {
+				return valueOrZeroValue(res, dstTyp).Interface(), nil
+			}
+		}
 	}
 
-	return dst, nil
+	for i, f := range c.Conf.CustomConverters {
+		res, err := f(src, dstTyp)
+		if err != nil {
+			return nil, fmt.Errorf("converter[%d]: %s", i, err.Error())
+		}
+
+		if res != nil {
+			return res, nil
+		}
+	}
+	return nil, nil
 }
 
 // Convert is like Conv.ConvertType() , but receives a pointer instead of a type.
-// It stores the result in the value pointed to by dst.
+// It stores the result in the value pointed to by dst. The conversion is done by Conv.ConvertValue() , so the
+// converters and Conv.Conf.NullStrings work the same way; the converters are also given a chance to convert to each
+// level of the pointers.
 //
 // If the source value is nil, the function returns without an error, the underlying value
 // of the pointer will not be set.
 // If dst is not a pointer, the function panics an error.
+//
+// Like json.Unmarshal, nil pointers pointed to by dst are allocated, e.g. given `var p *T`, Convert(src, &p)
+// sets p to a new T holding the result. The allocated values are not stored if the conversion fails.
 func (c *Conv) Convert(src interface{}, dstPtr interface{}) error {
 	const fnName = "Convert"
 
@@ -818,37 +3602,56 @@ func (c *Conv) Convert(src interface{}, dstPtr interface{}) error {
 		return nil
 	}
 
-	// CustomConverters
-	for i, f := range c.Conf.CustomConverters {
-		if dstValue.Kind() == reflect.Ptr {
-			dstValue = dstValue.Elem()
+	// The first nil pointer and its allocated value, which is stored only when the conversion succeeds.
+	var nilPtr, allocated reflect.Value
+	set := func(v reflect.Value) {
+		dstValue.Set(v)
+		if nilPtr.IsValid() {
+			nilPtr.Set(allocated)
 		}
+	}
 
-		res, err := f(src, dstValue.Type())
+	dstValue = dstValue.Elem()
+
+	// Like ConvertType() , a null string is converted to the zero value, which is a nil pointer for pointers.
+	if c.isNullString(src) {
+		dstValue.Set(reflect.Zero(dstValue.Type()))
+		return nil
+	}
+
+	for dstValue.Kind() == reflect.Ptr {
+		// The converters are given a chance to convert to each level of the pointers, the underlying type is
+		// handled by convertValue().
+		res, err := c.runCustomConverters(src, dstValue.Type())
 		if err != nil {
-			return errForFunction(fnName, "converter[%d]: %s", i, err.Error())
+			return errForFunction(fnName, "%s", err)
 		}
 
 		if res != nil {
-			dstValue.Set(reflect.ValueOf(res))
+			set(reflect.ValueOf(res))
 			return nil
 		}
-	}
 
-	for dstValue.Kind() == reflect.Ptr {
-		dstValue = dstValue.Elem()
-		if dstValue.Kind() == reflect.Invalid {
-			panic(errForFunction(fnName, "the underlying pointer must be initialized"))
+		if !dstValue.IsNil() {
+			dstValue = dstValue.Elem()
+			continue
+		}
+
+		v := reflect.New(dstValue.Type().Elem())
+		if nilPtr.IsValid() {
+			dstValue.Set(v) // Within the allocated value, not visible until it's stored.
+		} else {
+			nilPtr, allocated = dstValue, v
 		}
+		dstValue = v.Elem()
 	}
 
-	dstTyp := dstValue.Type()
-	value, err := c.convertToNonPtr(src, dstTyp)
+	res, err := c.convertValue(fnName, reflect.ValueOf(src), dstValue.Type())
 	if err != nil {
-		return errForFunction(fnName, err.Error())
+		return err
 	}
 
-	dstValue.Set(reflect.ValueOf(value))
+	set(res)
 	return nil
 }
 
@@ -889,9 +3692,281 @@ func (c *Conv) getUnderlyingValue(v interface{}) interface{} {
 	return vo.Interface()
 }
 
+// isNullString checks whether the value is a string which is one of Conv.Conf.NullStrings .
+func (c *Conv) isNullString(v interface{}) bool {
+	if len(c.Conf.NullStrings) == 0 {
+		return false
+	}
+
+	s, ok := v.(string)
+	return ok && containsString(c.Conf.NullStrings, s)
+}
+
+// tryStringer converts the value to a string with its Error() method, or its String() method, if
+// Conv.Conf.UseStringer is true and the value implements the error interface or fmt.Stringer . Simple types,
+// or pointers to them, are not converted this way. The second return value is false if the conversion is not performed.
+func (c *Conv) tryStringer(src interface{}, dstTyp reflect.Type) (interface{}, bool) {
+	if !c.Conf.UseStringer || dstTyp.Kind() != reflect.String {
+		return nil, false
+	}
+
+	v := reflect.ValueOf(src)
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return nil, false
+	}
+
+	var s string
+	switch x := src.(type) {
+	case error:
+		s = x.Error()
+
+	case fmt.Stringer:
+		// e.g. time.Time and time.Duration, they are formatted by Conv.Conf.TimeToString or as numbers.
+		typ := v.Type()
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if getTypeInfo(typ).simple {
+			return nil, false
+		}
+		s = x.String()
+
+	default:
+		return nil, false
+	}
+
+	return reflect.ValueOf(s).Convert(dstTyp).Interface(), true
+}
+
+// textMethodsApplicable returns false for the types whose conversions are handled by Conv itself, even though
+// they implement encoding.TextMarshaler or encoding.TextUnmarshaler : time.Time and the types registered in
+// Conv.Conf.Enums .
+func (c *Conv) textMethodsApplicable(typ reflect.Type) bool {
+	if typ == typTime {
+		return false
+	}
+	_, ok := c.Conf.Enums[typ]
+	return !ok
+}
+
+// tryMarshalText converts the value to a string with its MarshalText() method if it implements
+// encoding.TextMarshaler . The second return value is false if the conversion is not performed.
+func (c *Conv) tryMarshalText(src interface{}) (string, bool, error) {
+	v := reflect.ValueOf(src)
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return "", false, nil
+	}
+
+	typ := v.Type()
+	if !c.textMethodsApplicable(typ) || (typ.Kind() == reflect.Ptr && !c.textMethodsApplicable(typ.Elem())) {
+		return "", false, nil
+	}
+
+	if info := getTypeInfo(typ); !info.textMarshaler {
+		// The method may be declared on the pointer, copy the value to an addressable one.
+		if typ.Kind() == reflect.Ptr || !info.textMarshalerOnPtr {
+			return "", false, nil
+		}
+		p := reflect.New(typ)
+		p.Elem().Set(v)
+		v = p
+	}
+
+	b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return "", true, fmt.Errorf("error on MarshalText of %v: %v", typ, err)
+	}
+	return string(b), true, nil
+}
+
+// tryUnmarshalText converts the string to the destination type with its UnmarshalText() method if the pointer to
+// the type implements encoding.TextUnmarshaler . The second return value is false if the conversion is not performed.
+func (c *Conv) tryUnmarshalText(src interface{}, dstTyp reflect.Type) (interface{}, bool, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.String || !getTypeInfo(dstTyp).textUnmarshalerOnPtr || !c.textMethodsApplicable(dstTyp) {
+		return nil, false, nil
+	}
+
+	p := reflect.New(dstTyp)
+	u, ok := p.Interface().(encoding.TextUnmarshaler)
+	if !ok {
+		return nil, false, nil
+	}
+
+	if err := u.UnmarshalText([]byte(v.String())); err != nil {
+		return nil, true, fmt.Errorf("error on UnmarshalText of %v: %v", dstTyp, err)
+	}
+	return p.Elem().Interface(), true, nil
+}
+
+// driverValue returns the result of the Value() method if the value implements driver.Valuer , such as
+// sql.NullString . The second return value is false if the value doesn't implement driver.Valuer .
+func driverValue(src interface{}) (driver.Value, bool, error) {
+	v := reflect.ValueOf(src)
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return nil, false, nil
+	}
+
+	typ := v.Type()
+	if info := getTypeInfo(typ); !info.valuer {
+		// The method may be declared on the pointer, copy the value to an addressable one.
+		if typ.Kind() == reflect.Ptr || !info.valuerOnPtr {
+			return nil, false, nil
+		}
+		p := reflect.New(typ)
+		p.Elem().Set(v)
+		v = p
+	}
+
+	res, err := v.Interface().(driver.Valuer).Value()
+	if err != nil {
+		return nil, true, fmt.Errorf("error on calling Value() of %v: %v", typ, err)
+	}
+	return res, true, nil
+}
+
+// isNullValuer returns true if the value implements driver.Valuer and its Value() returns nil, and the destination
+// type, which is a pointer, can receive the value of Value() . See Conv.convertToNonPtr() .
+func (c *Conv) isNullValuer(src interface{}, dstPtrTyp reflect.Type) bool {
+	elem := dstPtrTyp
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	if info := getTypeInfo(elem); info.scannerOnPtr || !(info.simple || isByteSlice(elem)) {
+		return false
+	}
+
+	v, ok, err := driverValue(src)
+	return ok && err == nil && v == nil
+}
+
+// tryScan converts the value to the destination type with the Scan() method if the pointer to the type implements
+// sql.Scanner , such as *sql.NullString . The second return value is false if the conversion is not performed.
+//
+// The value passed to Scan() is one of the types supported by driver.Value : nil, int64, float64, bool, []byte,
+// string and time.Time ; a driver.Valuer is converted with its Value() method first.
+func (c *Conv) tryScan(src interface{}, dstTyp reflect.Type) (interface{}, bool, error) {
+	if !getTypeInfo(dstTyp).scannerOnPtr {
+		return nil, false, nil
+	}
+
+	v, ok, err := driverValue(src)
+	if err != nil {
+		return nil, true, err
+	}
+	if !ok {
+		v, err = c.toDriverValue(src)
+		if err != nil {
+			return nil, true, fmt.Errorf("cannot convert %T to %v: %v", src, dstTyp, err)
+		}
+	}
+
+	p := reflect.New(dstTyp)
+	if err := p.Interface().(sql.Scanner).Scan(v); err != nil {
+		return nil, true, fmt.Errorf("error on calling Scan() of %v: %v", dstTyp, err)
+	}
+	return p.Elem().Interface(), true, nil
+}
+
+// toDriverValue converts a simple value, or a []byte , to the corresponding type supported by driver.Value .
+func (c *Conv) toDriverValue(src interface{}) (driver.Value, error) {
+	if src == nil {
+		return nil, nil
+	}
+
+	typ := reflect.TypeOf(src)
+	if isByteSlice(typ) {
+		return reflect.ValueOf(src).Bytes(), nil
+	}
+
+	if !IsSimpleType(typ) {
+		return nil, fmt.Errorf("must be a simple type")
+	}
+
+	k := typ.Kind()
+	switch {
+	case k == reflect.String:
+		return reflect.ValueOf(src).String(), nil
+	case k == reflect.Bool:
+		return reflect.ValueOf(src).Bool(), nil
+	case isKindInt(k):
+		return reflect.ValueOf(src).Int(), nil
+	case isKindUint(k):
+		return c.simpleToPrimitive(src, reflect.Int64)
+	case isKindFloat(k):
+		return reflect.ValueOf(src).Float(), nil
+	case isKindComplex(k):
+		return c.SimpleToString(src)
+	default:
+		return c.simpleToTime(src)
+	}
+}
+
+// tryDecodeJSONString decodes the string as JSON if Conv.Conf.DecodeJSONStrings is true and the string starts with
+// '{' or '[' matching the kind of the destination type. The second return value is false if the string is not
+// decoded.
+func (c *Conv) tryDecodeJSONString(src interface{}, dstTyp reflect.Type) (interface{}, bool, error) {
+	if !c.Conf.DecodeJSONStrings {
+		return nil, false, nil
+	}
+
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.String {
+		return nil, false, nil
+	}
+
+	s := strings.TrimLeftFunc(v.String(), unicode.IsSpace)
+	if s == "" {
+		return nil, false, nil
+	}
+
+	switch dstTyp.Kind() {
+	case reflect.Struct, reflect.Map:
+		if s[0] != '{' {
+			return nil, false, nil
+		}
+	case reflect.Slice, reflect.Array:
+		if s[0] != '[' {
+			return nil, false, nil
+		}
+	default:
+		return nil, false, nil
+	}
+
+	var res interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(&res); err != nil {
+		return nil, true, fmt.Errorf("cannot decode the JSON string: %v", err)
+	}
+	if strings.TrimSpace(s[dec.InputOffset():]) != "" {
+		return nil, true, fmt.Errorf("cannot decode the JSON string: unexpected data after the value")
+	}
+	return res, true, nil
+}
+
 func (c *Conv) convertToNonPtr(src interface{}, dstTyp reflect.Type) (interface{}, error) {
+	// Check before dereferencing, the methods may be declared on the pointer.
+	if s, ok := c.tryStringer(src, dstTyp); ok {
+		return s, nil
+	}
+
+	if dstTyp.Kind() == reflect.String {
+		if s, ok, err := c.tryMarshalText(src); ok {
+			if err != nil {
+				return nil, err
+			}
+			return reflect.ValueOf(s).Convert(dstTyp).Interface(), nil
+		}
+	}
+
 	src = c.getUnderlyingValue(src)
 
+	if res, ok, err := c.tryScan(src, dstTyp); ok {
+		return res, err
+	}
+
 	dstKind := dstTyp.Kind()
 	if src == nil {
 		if dstKind == reflect.Slice || dstKind == reflect.Map {
@@ -900,54 +3975,91 @@ func (c *Conv) convertToNonPtr(src interface{}, dstTyp reflect.Type) (interface{
 		return nil, fmt.Errorf("cannot convert nil to %v", dstTyp)
 	}
 
-	srcTyp := reflect.TypeOf(src)
-	srcKind := srcTyp.Kind()
-	if IsSimpleType(srcTyp) && IsSimpleType(dstTyp) {
-		return c.SimpleToSimple(src, dstTyp)
+	// e.g. sql.NullString -> string, the values of other kinds of types are converted as usual.
+	dstInfo := getTypeInfo(dstTyp)
+	if dstInfo.simple || isByteSlice(dstTyp) {
+		if v, ok, err := driverValue(src); ok {
+			if err != nil {
+				return nil, err
+			}
+			if v == nil || c.isNullString(v) {
+				return reflect.Zero(dstTyp).Interface(), nil
+			}
+			// Not ConvertType() , the errors are prefixed by the caller.
+			return c.convertToNonPtr(v, dstTyp)
+		}
+	}
+
+	if res, ok, err := c.tryUnmarshalText(src, dstTyp); ok {
+		return res, err
 	}
 
-	if srcKind == reflect.Map {
-		// map[string]ANY { "": value } -> ConvertType(value)
-		if underlyingValue := c.tryFlattenEmptyKeyMap(src); underlyingValue != nil {
-			return c.ConvertType(underlyingValue, dstTyp)
+	if v, ok, err := c.tryDecodeJSONString(src, dstTyp); ok {
+		if err != nil {
+			return nil, err
 		}
+		return c.convertToNonPtr(v, dstTyp)
+	}
+
+	// The rest steps depend on the kinds of the types, they are decided once for each pair of types.
+	return getPlan(reflect.TypeOf(src), dstTyp).convert(c, src)
+}
 
-		switch dstKind {
-		// map -> map
-		case reflect.Map:
-			return c.MapToMap(src, dstTyp)
+// drainChannel receives values from the channel until it is closed, returns a slice of the values.
+// Returns nil if the channel is nil. See Conv.Conf.DrainChannels .
+func drainChannel(ch reflect.Value) (interface{}, error) {
+	if ch.Type().ChanDir()&reflect.RecvDir == 0 {
+		return nil, fmt.Errorf("cannot receive from %v", ch.Type())
+	}
 
-		// map[string]ANY -> struct
-		case reflect.Struct:
-			mm, ok := src.(map[string]interface{})
-			if !ok {
-				return nil, fmt.Errorf("when converting a map to a struct, the map must be map[string]interface{}, got %v", srcTyp)
-			}
-			return c.MapToStruct(mm, dstTyp)
+	// Receiving from a nil channel blocks forever.
+	if ch.IsNil() {
+		return nil, nil
+	}
+
+	res := reflect.MakeSlice(reflect.SliceOf(ch.Type().Elem()), 0, ch.Len())
+	for {
+		v, ok := ch.Recv()
+		if !ok {
+			break
 		}
-	} else if srcKind == reflect.Struct {
-		switch dstKind {
-		case reflect.Map:
-			if dstTyp != typStringMap {
-				return nil, fmt.Errorf("when converting a struct to a map, the destination type must be map[string]interface{}, got %v", dstTyp)
-			}
-			return c.StructToMap(src)
+		res = reflect.Append(res, v)
+	}
+	return res.Interface(), nil
+}
+
+// tryFlattenKeyMap is like tryFlattenEmptyKeyMap, but also flattens maps with a single key that is one of
+// Conv.Conf.FlattenKeys, if the destination type is neither a map nor a struct.
+func (c *Conv) tryFlattenKeyMap(v interface{}, dstTyp reflect.Type) interface{} {
+	if res := c.tryFlattenEmptyKeyMap(v); res != nil {
+		return res
+	}
 
-		case reflect.Struct:
-			return c.StructToStruct(src, dstTyp)
+	if len(c.Conf.FlattenKeys) == 0 {
+		return nil
+	}
+
+	switch dstTyp.Kind() {
+	case reflect.Map:
+		return nil
+	case reflect.Struct:
+		if !IsSimpleType(dstTyp) {
+			return nil
 		}
-	} else if dstKind == reflect.Slice {
-		switch srcKind {
-		// string -> []simple
-		case reflect.String:
-			return c.StringToSlice(src.(string), dstTyp)
+	}
 
-		case reflect.Slice:
-			return c.SliceToSlice(src, dstTyp)
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return nil
+	}
+
+	for k, v := range m {
+		if containsString(c.Conf.FlattenKeys, k) {
+			return v
 		}
 	}
 
-	return nil, fmt.Errorf("cannot convert %v to %v", srcTyp, dstTyp)
+	return nil
 }
 
 // tryFlattenEmptyKeyMap check the value. When all those conditions are satisfied:
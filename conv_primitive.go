@@ -154,7 +154,8 @@ func (c primitiveConv) doPrimitiveToInt64(v interface{}, dstType string) (int64,
 }
 
 func (c primitiveConv) doFloat64ToInt64(f float64, dstType string) (int64, error) {
-	if f < math.MinInt64 || f > math.MaxInt64 {
+	// float64(math.MaxInt64) is rounded up to 2^63, which is out of the range of int64.
+	if f < math.MinInt64 || f >= math.MaxInt64 {
 		return 0, errValueOverflow(f, dstType)
 	}
 
@@ -264,7 +265,8 @@ func (c primitiveConv) doPrimitiveToUint64(v interface{}, dstType string) (uint6
 }
 
 func (c primitiveConv) doFloatToUint(f float64, dstType string) (uint64, error) {
-	if f < 0 || f > math.MaxUint64 {
+	// float64(math.MaxUint64) is rounded up to 2^64, which is out of the range of uint64.
+	if f < 0 || f >= math.MaxUint64 {
 		return 0, errValueOverflow(f, dstType)
 	}
 
@@ -99,6 +99,7 @@ func Test_primitiveConv_toInt64(t *testing.T) {
 
 		{"err-overflow-uint", args{uint64(math.MaxUint64)}, 0, true},
 		{"err-overflow-float", args{float64(math.MaxUint64)}, 0, true},
+		{"err-overflow-float-2^63", args{float64(math.MaxInt64)}, 0, true},
 		{"err-precision-loss1", args{1.5}, 0, true},
 		{"err-precision-loss2", args{-0.1}, 0, true},
 		{"err-imaginary-loss", args{-0.1 + 55i}, 0, true},
@@ -261,6 +262,8 @@ func Test_primitiveConv_toUint64(t *testing.T) {
 		{"max", args{uint64(math.MaxUint64)}, uint64(math.MaxUint64), false},
 
 		{"err-overflow-float", args{float64(math.MaxUint64) * 2}, uint64(0), true},
+		{"err-overflow-float-2^64", args{float64(math.MaxUint64)}, uint64(0), true},
+		{"max-string", args{"18446744073709551615"}, uint64(math.MaxUint64), false},
 		{"err-precision-loss", args{1.5}, uint64(0), true},
 		{"err-imaginary-loss", args{1 + 1i}, uint64(0), true},
 		{"err-negative", args{-1}, uint64(0), true},
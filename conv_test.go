@@ -1,9 +1,18 @@
 package conv
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -12,6 +21,12 @@ import (
 
 type FromString string
 type FromInt int
+type Timestamp time.Time
+type Color int
+
+var _colorEnums = map[reflect.Type]map[string]int64{
+	reflect.TypeOf(Color(0)): {"Red": 1, "Green": 2, "Blue": 3},
+}
 
 var _caseInsensitiveConv = &Conv{
 	Conf: Config{
@@ -199,6 +214,52 @@ func TestConv_SimpleToString(t *testing.T) {
 	}
 }
 
+func TestConv_IntToStringBase(t *testing.T) {
+	tests := []struct {
+		v        interface{}
+		base     int
+		want     string
+		errRegex string
+	}{
+		{5, 2, "101", ""},
+		{-5, 2, "-101", ""},
+		{int8(64), 8, "100", ""},
+		{-64, 8, "-100", ""},
+		{255, 16, "ff", ""},
+		{-255, 16, "-ff", ""},
+		{uint64(math.MaxUint64), 16, "ffffffffffffffff", ""},
+		{FromInt(35), 36, "z", ""},
+		{nil, 2, "", `^conv.IntToStringBase: the source value should not be nil$`},
+		{1, 1, "", `^conv.IntToStringBase: the base must be between 2 and 36, got 1$`},
+		{1, 37, "", `^conv.IntToStringBase: the base must be between 2 and 36, got 37$`},
+		{true, 16, "", `^conv.IntToStringBase: cannot convert bool to an integer$`},
+		{2.0, 2, "", `^conv.IntToStringBase: cannot convert float64 to an integer$`},
+		{"255", 16, "", `^conv.IntToStringBase: cannot convert string to an integer$`},
+		{[]int{1}, 2, "", `^conv.IntToStringBase: cannot convert \[\]int to an integer$`},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v-%v", tt.v, tt.base), func(t *testing.T) {
+			got, err := _defaultConv.IntToStringBase(tt.v, tt.base)
+			if err != nil {
+				if tt.errRegex == "" {
+					t.Fatalf("unexpected error = %v", err)
+				}
+				if match, _ := regexp.MatchString(tt.errRegex, err.Error()); !match {
+					t.Errorf("error = %v , must match %v", err, tt.errRegex)
+				}
+				return
+			}
+
+			if tt.errRegex != "" {
+				t.Fatalf("want error, got %v", got)
+			}
+			if got != tt.want {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestConv_SimpleToSimple(t *testing.T) {
 	spUtcTime := time.Date(2021, 6, 3, 13, 21, 22, 54321, time.UTC)
 	spUtcTimeWithoutNano := time.Unix(spUtcTime.Unix(), 0).UTC()
@@ -262,10 +323,14 @@ func TestConv_SimpleToSimple(t *testing.T) {
 		{"time-int", false, args{spLocalTime, reflect.TypeOf(0)}, int(spLocalTime.Unix()), ""},
 		{"time-float", false, args{spLocalTime, reflect.TypeOf(0.0)}, float64(spLocalTime.Unix()), ""},
 		{"int-time", false, args{1622726482, reflect.TypeOf(time.Time{})}, spUtcTimeWithoutNano.Local(), ""},
+		{"int-string-time", false, args{"1622726482", reflect.TypeOf(time.Time{})}, spUtcTimeWithoutNano.Local(), ""},
+		{"negative-int-string-time", false, args{"-1", reflect.TypeOf(time.Time{})}, time.Unix(-1, 0), ""},
 
 		// err
 		{"err-nil", false, args{nil, reflect.TypeOf(1)}, nil, "^conv.SimpleToSimple: the source value should not be nil$"},
 		{"err-time-from-string", false, args{"date", reflect.TypeOf(time.Time{})}, nil, "^conv.SimpleToSimple: .+"},
+		{"err-time-from-float-string", false, args{"1.5", reflect.TypeOf(time.Time{})}, nil, "^conv.SimpleToSimple: .+"},
+		{"err-time-from-int-string-overflow", false, args{"99999999999999999999", reflect.TypeOf(time.Time{})}, nil, "^conv.SimpleToSimple: .+"},
 		{"err-time-from-complex", false, args{1 + 3i, reflect.TypeOf(time.Time{})}, nil, "lost imaginary part"},
 		{"err-time-to-int8", false, args{spLocalTime, reflect.TypeOf(int8(0))}, nil, `value overflow`},
 		{"err-struct-int", false, args{Empty{}, reflect.TypeOf(0)}, nil, `cannot convert from conv\.Empty to int`},
@@ -300,6 +365,181 @@ func TestConv_SimpleToSimple(t *testing.T) {
 	}
 }
 
+func TestConv_SimpleToSimple_epoch(t *testing.T) {
+	c := &Conv{Conf: Config{Epoch: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	tm := time.Date(2001, 1, 2, 0, 0, 1, 0, time.UTC)
+
+	got, err := c.SimpleToSimple(86401, typTime)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if want := tm.Local(); got != want {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	got, err = c.SimpleToSimple("-1", typTime)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if want := time.Date(2000, 12, 31, 23, 59, 59, 0, time.UTC).Local(); got != want {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	got, err = c.SimpleToSimple(tm, reflect.TypeOf(int64(0)))
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if got != int64(86401) {
+		t.Errorf("want 86401, got %v", got)
+	}
+
+	// The epoch has a sub-second part.
+	c = &Conv{Conf: Config{Epoch: time.Date(2001, 1, 1, 0, 0, 0, 900000000, time.UTC), TimestampUnit: UnitMillis}}
+	tm = time.Date(2001, 1, 1, 0, 0, 1, 800000000, time.UTC)
+
+	got, err = c.SimpleToSimple(900, typTime)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if want := tm.Local(); got != want {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	got, err = c.SimpleToSimple(tm, reflect.TypeOf(int64(0)))
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if got != int64(900) {
+		t.Errorf("want 900, got %v", got)
+	}
+}
+
+func TestConv_SimpleToSimple_timestampUnit(t *testing.T) {
+	tm := time.Date(2022, 4, 20, 3, 30, 40, 123456789, time.UTC)
+
+	tests := []struct {
+		unit      TimestampUnit
+		timestamp int64
+		want      time.Time
+	}{
+		{UnitSeconds, 1650425440, tm.Truncate(time.Second)},
+		{UnitMillis, 1650425440123, tm.Truncate(time.Millisecond)},
+		{UnitMicros, 1650425440123456, tm.Truncate(time.Microsecond)},
+		{UnitNanos, 1650425440123456789, tm},
+		{UnitMillis, -1500, time.Date(1969, 12, 31, 23, 59, 58, 500000000, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want.String(), func(t *testing.T) {
+			c := &Conv{Conf: Config{TimestampUnit: tt.unit}}
+
+			got, err := c.SimpleToSimple(tt.timestamp, typTime)
+			if err != nil {
+				t.Fatalf("got error %s", err)
+			}
+			if want := tt.want.Local(); got != want {
+				t.Errorf("want %v, got %v", want, got)
+			}
+
+			// Strings which can't be parsed as times are treated as timestamps.
+			got, err = c.SimpleToSimple(strconv.FormatInt(tt.timestamp, 10), typTime)
+			if err != nil {
+				t.Fatalf("got error %s", err)
+			}
+			if want := tt.want.Local(); got != want {
+				t.Errorf("want %v, got %v", want, got)
+			}
+
+			got, err = c.SimpleToSimple(tt.want, reflect.TypeOf(int64(0)))
+			if err != nil {
+				t.Fatalf("got error %s", err)
+			}
+			if got != tt.timestamp {
+				t.Errorf("want %v, got %v", tt.timestamp, got)
+			}
+		})
+	}
+
+	t.Run("epoch", func(t *testing.T) {
+		c := &Conv{Conf: Config{Epoch: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), TimestampUnit: UnitMillis}}
+		got, err := c.SimpleToSimple(86401500, typTime)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := time.Date(2001, 1, 2, 0, 0, 1, 500000000, time.UTC).Local(); got != want {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("warning", func(t *testing.T) {
+		var msg string
+		c := &Conv{Conf: Config{
+			TimestampUnit: UnitMillis,
+			OnWarning:     func(path, m string) { msg = m },
+		}}
+
+		if _, err := c.SimpleToSimple(tm, reflect.TypeOf(int64(0))); err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if !strings.Contains(msg, "the precision below 1ms") {
+			t.Errorf("unexpected warning: %v", msg)
+		}
+	})
+
+	t.Run("MapToStruct", func(t *testing.T) {
+		type T struct {
+			At  time.Time
+			Ptr *time.Time
+		}
+
+		c := &Conv{Conf: Config{TimestampUnit: UnitMillis}}
+		got, err := c.MapToStruct(map[string]interface{}{"At": "1650425440123", "Ptr": 1650425440123}, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want := tm.Truncate(time.Millisecond)
+		if v := got.(T); !v.At.Equal(want) || !v.Ptr.Equal(want) {
+			t.Errorf("want %v, got %v", want, v)
+		}
+
+		c = &Conv{Conf: Config{TimestampUnit: UnitNanos}}
+		got, err = c.MapToStruct(map[string]interface{}{"At": "1650425440123456789"}, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if v := got.(T); !v.At.Equal(tm) {
+			t.Errorf("want %v, got %v", tm, v.At)
+		}
+	})
+
+	t.Run("location", func(t *testing.T) {
+		loc := time.FixedZone("UTC+8", 8*3600)
+		c := &Conv{Conf: Config{TimeLocation: loc, TimestampUnit: UnitMillis}}
+		for _, src := range []interface{}{int64(1650425440123), "1650425440123"} {
+			got, err := c.SimpleToSimple(src, typTime)
+			if err != nil {
+				t.Fatalf("got error %s", err)
+			}
+			if want := tm.Truncate(time.Millisecond).In(loc); got != want {
+				t.Errorf("want %v, got %v", want, got)
+			}
+		}
+
+		// The time zone tag takes precedence.
+		type T struct {
+			At time.Time `tz:"UTC"`
+		}
+		c.Conf.TimeZoneTag = "tz"
+		got, err := c.MapToStruct(map[string]interface{}{"At": 0}, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if at := got.(T).At; at.Location() != time.UTC || !at.Equal(time.Unix(0, 0)) {
+			t.Errorf("unexpected time %v", at)
+		}
+	})
+}
+
 func TestConv_SliceToSlice(t *testing.T) {
 	var nilI []int
 	var nilStruct []struct{}
@@ -322,6 +562,8 @@ func TestConv_SliceToSlice(t *testing.T) {
 		{"bool-string", args{[]bool{true, true, false}, reflect.TypeOf([]string{})}, []string{"1", "1", "0"}, ""},
 		{"nil-nil", args{nilI, reflect.TypeOf([]struct{}{})}, nilStruct, ""},
 		{"interface-interface", args{[]interface{}{1, "v"}, reflect.TypeOf([]interface{}{})}, []interface{}{1, "v"}, ""},
+		{"array-slice", args{[2]string{"1", "2"}, reflect.TypeOf([]int{})}, []int{1, 2}, ""},
+		{"empty-array", args{[0]int{}, reflect.TypeOf([]int{})}, []int{}, ""},
 
 		{"err", args{[]struct{}{{}}, reflect.TypeOf([]string{})}, nil, "^conv.SliceToSlice: .+, at index 0.+"},
 		{"err-nil", args{nil, reflect.TypeOf([]string{})}, nil, "should not be nil"},
@@ -351,6 +593,157 @@ func TestConv_SliceToSlice(t *testing.T) {
 	}
 }
 
+func TestConv_SliceToArray(t *testing.T) {
+	type args struct {
+		src         interface{}
+		dstArrayTyp reflect.Type
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     interface{}
+		errRegex string
+	}{
+		{"slice-array", args{[]string{"1", "2", "3"}, reflect.TypeOf([3]int{})}, [3]int{1, 2, 3}, ""},
+		{"array-array", args{[2]int{1, 0}, reflect.TypeOf([2]bool{})}, [2]bool{true, false}, ""},
+		{"empty", args{[]int{}, reflect.TypeOf([0]string{})}, [0]string{}, ""},
+		{"nil-slice", args{[]int(nil), reflect.TypeOf([0]string{})}, [0]string{}, ""},
+		{"nested", args{[][]int{{1}, {2, 3}}, reflect.TypeOf([2][]string{})}, [2][]string{{"1"}, {"2", "3"}}, ""},
+		{"interface", args{[]interface{}{nil, "v"}, reflect.TypeOf([2]interface{}{})}, [2]interface{}{nil, "v"}, ""},
+
+		{"err-elem", args{[]string{"1", "x"}, reflect.TypeOf([2]int{})}, nil, `^conv.SliceToArray: cannot convert to \[2\]int, at index 1 : .+invalid syntax`},
+		{"err-short", args{[]int{1}, reflect.TypeOf([2]int{})}, nil, `^conv.SliceToArray: the length 1 does not match the length of \[2\]int`},
+		{"err-long", args{[3]int{}, reflect.TypeOf([2]int{})}, nil, `the length 3 does not match`},
+		{"err-nil", args{nil, reflect.TypeOf([1]int{})}, nil, "should not be nil"},
+		{"err-src", args{1, reflect.TypeOf([1]int{})}, nil, "src must be a slice or an array"},
+		{"err-dst", args{[]int{1}, reflect.TypeOf([]int{})}, nil, "the destination type must be array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := _defaultConv.SliceToArray(tt.args.src, tt.args.dstArrayTyp)
+
+			if err != nil {
+				if tt.errRegex == "" {
+					t.Errorf("SliceToArray() unexpected error = %v", err)
+				}
+
+				if match, _ := regexp.MatchString(tt.errRegex, err.Error()); !match {
+					t.Errorf("SliceToArray() error = %v , must match %v",
+						strconv.Quote(err.Error()), strconv.Quote(tt.errRegex))
+				}
+			} else if tt.errRegex != "" {
+				t.Errorf("SliceToArray() want error %v", tt.errRegex)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SliceToArray() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("convert-type", func(t *testing.T) {
+		type T struct {
+			A  [2]int
+			P  *[1]string
+			S  []int
+			AA [2][2]int
+		}
+
+		m := map[string]interface{}{
+			"A":  []interface{}{"1", 2},
+			"P":  []int{3},
+			"S":  [2]string{"4", "5"},
+			"AA": [][]int{{1, 2}, {3, 4}},
+		}
+		got, err := _defaultConv.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want := T{A: [2]int{1, 2}, P: &[1]string{"3"}, S: []int{4, 5}, AA: [2][2]int{{1, 2}, {3, 4}}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+
+		_, err = _defaultConv.MapToStruct(map[string]interface{}{"AA": [][]int{{1, 2}, {3}}}, reflect.TypeOf(T{}))
+		if err == nil || !strings.Contains(err.Error(), "at index 1 : conv.ConvertType: conv.SliceToArray: the length 1 does not match") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestConv_ConvertEach(t *testing.T) {
+	collect := func(src interface{}) ([]int, error) {
+		var res []int
+		err := _defaultConv.ConvertEach(src, reflect.TypeOf(0), func(i int, v interface{}) error {
+			if i != len(res) {
+				t.Fatalf("unexpected index %v", i)
+			}
+			res = append(res, v.(int))
+			return nil
+		})
+		return res, err
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		ch := make(chan string, 2)
+		ch <- "3"
+		ch <- "4"
+		close(ch)
+
+		tests := []struct {
+			src  interface{}
+			want []int
+		}{
+			{[]string{"1", "2"}, []int{1, 2}},
+			{[2]interface{}{1.0, "2"}, []int{1, 2}},
+			{ch, []int{3, 4}},
+			{[]int(nil), nil},
+			{(chan int)(nil), nil},
+		}
+		for _, tt := range tests {
+			got, err := collect(tt.src)
+			if err != nil {
+				t.Fatalf("got error %s", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		stop := errors.New("stop")
+		count := 0
+		err := _defaultConv.ConvertEach([]int{1, 2, 3}, reflect.TypeOf(""), func(i int, v interface{}) error {
+			count++
+			return stop
+		})
+		if err != stop || count != 1 {
+			t.Errorf("unexpected result %v, %v", count, err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			src  interface{}
+			want string
+		}{
+			{[]string{"1", "x"}, `^conv.ConvertEach: cannot convert to int, at index 1 : .+invalid syntax`},
+			{nil, "should not be nil"},
+			{1, "src must be a slice, an array or a channel, got int"},
+			{make(chan<- int), "cannot receive from chan<- int"},
+		}
+		for _, tt := range tests {
+			_, err := collect(tt.src)
+			if err == nil || !regexp.MustCompile(tt.want).MatchString(err.Error()) {
+				t.Errorf("want %v, got %v", tt.want, err)
+			}
+		}
+	})
+}
+
 func TestConv_MapToStruct(t *testing.T) {
 	type args struct {
 		c        *Conv
@@ -575,1149 +968,5678 @@ func TestConv_MapToStruct(t *testing.T) {
 			errRegex: "",
 		})
 	})
-}
 
-func TestConv_MapToMap(t *testing.T) {
-	type args struct {
-		m      interface{}
-		dstTyp reflect.Type
-	}
-	tests := []struct {
-		name     string
-		args     args
-		want     interface{}
-		errRegex string
-	}{
-		{
-			"nil",
-			args{
-				map[string]int(nil),
-				reflect.TypeOf(map[float32]int(nil)),
-			},
-			map[float32]int(nil),
-			"",
-		},
+	t.Run("bytes-to-string", func(t *testing.T) {
+		type T struct {
+			S  string
+			FS FromString
+			PS *string
+			B  []byte
+		}
 
-		{
-			"si-si",
-			args{
-				map[string]int{
-					"a": 1,
-					"b": 2,
-					"c": 3,
-				},
-				reflect.TypeOf(map[string]int(nil)),
-			},
-			map[string]int{
-				"a": 1,
-				"b": 2,
-				"c": 3,
+		s := "中文"
+		check(t, args{
+			c: _defaultConv,
+			m: map[string]interface{}{
+				"S":  []byte("hello"),
+				"FS": []byte("world"),
+				"PS": []byte(s),
+				"B":  []byte{1, 2},
 			},
-			"",
-		},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{S: "hello", FS: "world", PS: &s, B: []byte{1, 2}},
+			errRegex: "",
+		})
+	})
 
-		{
-			"si-is",
-			args{
-				map[string]int{
-					"1":  11,
-					"2":  22,
-					"33": 3,
-				},
-				reflect.TypeOf(map[int]string(nil)),
-			},
-			map[int]string{
-				1:  "11",
-				2:  "22",
-				33: "3",
-			},
-			"",
-		},
+	t.Run("trim-string-values", func(t *testing.T) {
+		type T struct {
+			S  string
+			FS FromString
+			I  int
+			SS []string
+		}
 
-		{
-			"[]interface-interface",
-			args{
-				map[string]interface{}{
-					"1": []interface{}{1, 2, 3},
-				},
-				reflect.TypeOf(map[interface{}]interface{}(nil)),
-			},
-			map[interface{}]interface{}{
-				"1": []interface{}{1, 2, 3},
+		c := &Conv{Conf: Config{TrimStringValues: true}}
+		check(t, args{
+			c: c,
+			m: map[string]interface{}{
+				"S":  "  hello  ",
+				"FS": "\tworld\n",
+				"I":  3,
+				"SS": []string{" a", "b "},
 			},
-			"",
-		},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{S: "hello", FS: "world", I: 3, SS: []string{"a", "b"}},
+			errRegex: "",
+		})
 
-		{
-			"err-src",
-			args{
-				123,
-				reflect.TypeOf(map[int]string(nil)),
-			},
-			nil,
-			"must be a map",
-		},
+		// Disabled by default.
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"S": "  hello  "},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{S: "  hello  "},
+			errRegex: "",
+		})
+	})
 
-		{
-			"err-typ",
-			args{
-				map[string]int{},
-				reflect.TypeOf(1),
-			},
-			nil,
-			"destination type must be map",
-		},
+	t.Run("max-fields", func(t *testing.T) {
+		type T struct{ A, B, C int }
 
-		{
-			"err-key",
-			args{
-				map[string]int{"a": 1},
-				reflect.TypeOf(map[int]int{}),
-			},
-			nil,
-			"cannot covert key 'a' to int: .+",
-		},
+		c := &Conv{Conf: Config{MaxFields: 2}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": 1, "B": 2},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: 1, B: 2},
+			errRegex: "",
+		})
 
-		{
-			"err-elem",
-			args{
-				map[string]string{"aa": "x"},
-				reflect.TypeOf(map[string]int{}),
-			},
-			nil,
-			"cannot covert value of key 'aa' to int: .+",
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := _defaultConv.MapToMap(tt.args.m, tt.args.dstTyp)
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": 1, "B": 2, "X": 3},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: too many keys, the limit is 2, got 3$`,
+		})
+		// The hooks are not called for oversized input.
+		called := false
+		c.Conf.PreProcessMap = func(m map[string]interface{}) map[string]interface{} {
+			called = true
+			return map[string]interface{}{}
+		}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": 1, "B": 2, "X": 3},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: too many keys, the limit is 2, got 3$`,
+		})
+		if called {
+			t.Errorf("PreProcessMap should not be called")
+		}
+	})
 
-			if err != nil {
-				if tt.errRegex == "" {
-					t.Errorf("MapToMap() unexpected error = %v", err)
-				}
+	t.Run("type-validators", func(t *testing.T) {
+		type Email string
+		type T struct {
+			Name string
+			Mail Email
+		}
 
-				if match, _ := regexp.MatchString(tt.errRegex, err.Error()); !match {
-					t.Errorf("MapToMap() error = %v , must match %v",
-						strconv.Quote(err.Error()), strconv.Quote(tt.errRegex))
-				}
-			}
+		c := &Conv{
+			Conf: Config{
+				TypeValidators: map[reflect.Type]func(v interface{}) error{
+					reflect.TypeOf(Email("")): func(v interface{}) error {
+						if !strings.Contains(string(v.(Email)), "@") {
+							return errors.New("bad email")
+						}
+						return nil
+					},
+				},
+			},
+		}
 
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("MapToMap() = %v, want %v", got, tt.want)
-			}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Name": "Bob", "Mail": "bob@example.org"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Name: "Bob", Mail: "bob@example.org"},
+			errRegex: "",
 		})
-	}
-}
-
-func TestConv_StructToMap(t *testing.T) {
-	type args struct {
-		c        *Conv
-		src      interface{}
-		want     map[string]interface{}
-		errRegex string
-	}
-	check := func(t *testing.T, args args) {
-		got, err := args.c.StructToMap(args.src)
 
-		if err != nil {
-			if args.errRegex == "" {
-				t.Errorf("StructToMap() unexpected error = %v", err)
-			}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Name": "Bob", "Mail": "bob"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: error on converting field 'Mail': bad email$`,
+		})
+	})
 
-			if match, _ := regexp.MatchString(args.errRegex, err.Error()); !match {
-				t.Errorf("StructToMap() error = %v , must match %v",
-					strconv.Quote(err.Error()), strconv.Quote(args.errRegex))
-			}
-		} else if args.errRegex != "" {
-			t.Errorf("StructToMap() want error, got nil, pattern = %v", args.errRegex)
+	t.Run("large-integer-strings", func(t *testing.T) {
+		type T struct {
+			U64 uint64
+			I64 int64
+			Min int64
 		}
 
-		if !reflect.DeepEqual(got, args.want) {
-			t.Errorf("StructToMap() = %v, want %v", got, args.want)
-		}
-	}
+		check(t, args{
+			c: _defaultConv,
+			m: map[string]interface{}{
+				"U64": "18446744073709551615",
+				"I64": json.Number("9223372036854775807"),
+				"Min": "-9223372036854775808",
+			},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{U64: math.MaxUint64, I64: math.MaxInt64, Min: math.MinInt64},
+			errRegex: "",
+		})
 
-	t.Run("nil", func(t *testing.T) {
+		// The float64 loses the precision, it is rounded to 2^64 and overflows.
 		check(t, args{
 			c:        _defaultConv,
-			src:      nil,
+			m:        map[string]interface{}{"U64": float64(math.MaxUint64)},
+			dstTyp:   reflect.TypeOf(T{}),
 			want:     nil,
-			errRegex: "^conv.StructToMap: .+should not be nil",
+			errRegex: `^conv.MapToStruct: error on converting field 'U64': .+value overflow`,
 		})
 	})
 
-	t.Run("simple", func(t *testing.T) {
+	t.Run("numeric-string-to-bool", func(t *testing.T) {
+		type T struct{ A, B, C, D, E bool }
+
+		c := &Conv{Conf: Config{NumericStringToBool: true}}
 		check(t, args{
-			c: _defaultConv,
-			src: struct {
-				Str   string
-				Flt   float64
-				inner int
-			}{"aa", 0.5, 4},
-			want: map[string]interface{}{
-				"Str": "aa",
-				"Flt": 0.5,
-			},
+			c:        c,
+			m:        map[string]interface{}{"A": "2", "B": "0", "C": "-1", "D": "0.0", "E": "true"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: true, B: false, C: true, D: false, E: true},
 			errRegex: "",
 		})
+
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"A": "2"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'A': .+invalid syntax`,
+		})
 	})
 
-	t.Run("field-map-slice-without-value", func(t *testing.T) {
+	t.Run("bool-strings", func(t *testing.T) {
 		type T struct {
-			MNil   map[string]int
-			MEmpty map[string]int
-			SNil   []struct{}
-			SEmpty []struct{}
+			A, B, C, D bool
+			P          *bool
 		}
 
+		yes := true
+		c := &Conv{Conf: Config{BoolStrings: map[string]bool{"yes": true, "no": false, "on": true}}}
 		check(t, args{
-			c: _defaultConv,
-			src: T{
-				MEmpty: map[string]int{},
-				SEmpty: []struct{}{},
-			},
-			want: map[string]interface{}{
-				"MNil":   map[string]interface{}(nil),
-				"MEmpty": map[string]interface{}{},
-				"SNil":   []map[string]interface{}(nil),
-				"SEmpty": []map[string]interface{}{},
-			},
+			c:        c,
+			m:        map[string]interface{}{"A": "Yes", "B": "NO", "C": "on", "D": "true", "P": "yes"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: true, B: false, C: true, D: true, P: &yes},
 			errRegex: "",
 		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": "maybe"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'A': .+invalid syntax`,
+		})
+
+		if v, err := c.SimpleToBool("No"); err != nil || v {
+			t.Errorf("want false, got %v, %v", v, err)
+		}
 	})
 
-	t.Run("field-map-with-value", func(t *testing.T) {
+	t.Run("lenient-preset", func(t *testing.T) {
 		type T struct {
-			M map[string]int
+			UserName string
+			Active   bool
+			Admin    *bool
+			Favorite Color
+			Colors   []Color
+			Level    int
 		}
 
+		c := &Conv{Conf: _caseInsensitiveConv.Conf}
+		c.Conf.TrimStringValues = true
+		c.Conf.NumericStringToBool = true
+		c.Conf.BoolStrings = map[string]bool{"yes": true, "no": false}
+		c.Conf.Enums = _colorEnums
+		c.Conf.EnumCaseInsensitive = true
+
+		no := false
 		check(t, args{
-			c: _defaultConv,
-			src: T{
-				M: map[string]int{"A": 1, "B": 2},
-			},
-			want: map[string]interface{}{
-				"M": map[string]interface{}{
-					"A": 1,
-					"B": 2,
-				},
+			c: c,
+			m: map[string]interface{}{
+				"USERNAME": "  bob  ",
+				"active":   " Yes ",
+				"Admin":    "NO",
+				"favorite": " bLuE",
+				"COLORS":   []interface{}{"red", "GREEN ", "3", 1},
+				"level":    "2",
 			},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{UserName: "bob", Active: true, Admin: &no, Favorite: 3, Colors: []Color{1, 2, 3, 1}, Level: 2},
 			errRegex: "",
 		})
+
+		// The strings are still validated.
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Favorite": "purple"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Favorite': .+unknown name 'purple'`,
+		})
 	})
 
-	t.Run("field-slice-with-value", func(t *testing.T) {
-		type Inner struct {
-			A string
-			B []byte
-		}
-		type T struct{ In []Inner }
+	t.Run("time-auto-detect", func(t *testing.T) {
+		type T struct{ Num, Str, NumStr time.Time }
 
 		check(t, args{
 			c: _defaultConv,
-			src: T{
-				In: []Inner{
-					{"A1", []byte{1, 2}},
-					{"A2", []byte{3, 4}},
-				},
+			m: map[string]interface{}{
+				"Num":    1622726482,
+				"Str":    "2021-06-03T13:21:22Z",
+				"NumStr": "1622726482",
 			},
-			want: map[string]interface{}{
-				"In": []map[string]interface{}{
-					{"A": "A1", "B": []byte{1, 2}},
-					{"A": "A2", "B": []byte{3, 4}},
-				},
+			dstTyp: reflect.TypeOf(T{}),
+			want: T{
+				Num:    time.Unix(1622726482, 0),
+				Str:    time.Date(2021, 6, 3, 13, 21, 22, 0, time.UTC),
+				NumStr: time.Unix(1622726482, 0),
 			},
-			errRegex: ``,
+			errRegex: "",
 		})
 	})
 
-	t.Run("err-src-kind", func(t *testing.T) {
+	t.Run("interface-fields", func(t *testing.T) {
+		type T struct {
+			R   io.Reader
+			S   fmt.Stringer
+			Nil io.Writer
+		}
+
+		buf := bytes.NewBufferString("abc")
 		check(t, args{
 			c:        _defaultConv,
-			src:      1,
-			want:     nil,
-			errRegex: "must be a struct",
+			m:        map[string]interface{}{"R": buf, "S": buf, "Nil": nil},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{R: buf, S: buf},
+			errRegex: "",
 		})
-	})
 
-	t.Run("err-field-not-simple", func(t *testing.T) {
 		check(t, args{
 			c:        _defaultConv,
-			src:      struct{ C chan int }{make(chan int)},
+			m:        map[string]interface{}{"R": "abc"},
+			dstTyp:   reflect.TypeOf(T{}),
 			want:     nil,
-			errRegex: "^conv.StructToMap: error on converting field C: must be a simple type, got chan$",
+			errRegex: `error on converting field 'R': conv.ConvertType: cannot convert string to io.Reader`,
 		})
 	})
 
-	t.Run("err-field-slice-elem-invalid", func(t *testing.T) {
-		type T struct{ V []chan int }
+	t.Run("allowed-keys", func(t *testing.T) {
+		type T struct {
+			Name    string
+			IsAdmin bool
+		}
 
+		c := &Conv{Conf: Config{AllowedKeys: []string{"Name"}}}
 		check(t, args{
-			c:        _defaultConv,
-			src:      T{[]chan int{}},
-			want:     nil,
-			errRegex: `cannot convert \[\]chan int`,
+			c:        c,
+			m:        map[string]interface{}{"Name": "a", "IsAdmin": true},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Name: "a"},
+			errRegex: "",
 		})
-	})
-
-	t.Run("err-field-map-key-invalid", func(t *testing.T) {
-		type T struct{ In map[chan int]int }
 
+		// Compared with the original keys.
+		c = &Conv{Conf: Config{AllowedKeys: []string{"Name"}, KeyAliases: map[string]string{"admin": "IsAdmin"}}}
 		check(t, args{
-			c:        _defaultConv,
-			src:      T{map[chan int]int{make(chan int): 1}},
-			want:     nil,
-			errRegex: `field In: key .+?: .+cannot convert chan int to string`,
+			c:        c,
+			m:        map[string]interface{}{"Name": "a", "admin": true},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Name: "a"},
+			errRegex: "",
 		})
 	})
 
-	t.Run("err-field-map-value-invalid", func(t *testing.T) {
-		type T struct{ In map[int]chan int }
+	t.Run("forbidden-keys", func(t *testing.T) {
+		type T struct {
+			Name    string
+			IsAdmin bool
+			Role    string
+		}
 
+		c := &Conv{Conf: Config{ForbiddenKeys: []string{"IsAdmin", "Role"}}}
 		check(t, args{
-			c:        _defaultConv,
-			src:      T{map[int]chan int{13: make(chan int)}},
-			want:     nil,
-			errRegex: `field In: value of key 13: must be a simple type, got chan`,
+			c:        c,
+			m:        map[string]interface{}{"Name": "a", "IsAdmin": true, "Role": "root"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Name: "a"},
+			errRegex: "",
 		})
-	})
-
-	t.Run("pointer-nil", func(t *testing.T) {
-		type T struct{ In *int }
 
+		// Forbidden wins.
+		c = &Conv{Conf: Config{AllowedKeys: []string{"Name", "Role"}, ForbiddenKeys: []string{"Role"}}}
 		check(t, args{
-			c:        _defaultConv,
-			src:      T{},
-			want:     map[string]interface{}{},
-			errRegex: ``,
+			c:        c,
+			m:        map[string]interface{}{"Name": "a", "IsAdmin": true, "Role": "root"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Name: "a"},
+			errRegex: "",
 		})
 	})
 
-	t.Run("pointer-value", func(t *testing.T) {
-		type T struct{ In *struct{ A int } }
+	t.Run("use-stringer", func(t *testing.T) {
+		type T struct {
+			Err  string
+			Name FromString
+			Ptr  *string
+		}
 
+		c := &Conv{Conf: Config{UseStringer: true}}
 		check(t, args{
-			c:   _defaultConv,
-			src: T{&struct{ A int }{33}},
-			want: map[string]interface{}{
-				"In": map[string]interface{}{"A": 33},
+			c: c,
+			m: map[string]interface{}{
+				"Err":  errors.New("something wrong"),
+				"Name": errors.New("named"),
+				"Ptr":  errors.New("pointer"),
 			},
-			errRegex: ``,
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Err: "something wrong", Name: "named", Ptr: func() *string { s := "pointer"; return &s }()},
+			errRegex: "",
 		})
-	})
 
-	t.Run("embedded-struct", func(t *testing.T) {
-		type E2 struct {
-			VV1, VV2 string
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"Err": errors.New("something wrong")},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Err': .+cannot convert`,
+		})
+
+		// fmt.Stringer.
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Err": stringerPoint{1, 2}, "Ptr": &stringerPoint{3, 4}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Err: "(1, 2)", Ptr: func() *string { s := "(3, 4)"; return &s }()},
+			errRegex: "",
+		})
+
+		s, err := c.SimpleToString(&stringerPoint{5, 6})
+		if err != nil || s != "(5, 6)" {
+			t.Errorf("unexpected result %v, %v", s, err)
 		}
-		type E struct {
-			E2
-			V1 int
+
+		if _, err := _defaultConv.SimpleToString(stringerPoint{}); err == nil {
+			t.Errorf("want error")
 		}
+
+		// Simple types are converted as usual.
+		d := 1500 * time.Millisecond
+		for _, src := range []interface{}{d, &d} {
+			want, _ := _defaultConv.ConvertType(src, reflect.TypeOf(""))
+			if got, err := c.ConvertType(src, reflect.TypeOf("")); err != nil || got != want {
+				t.Errorf("want %v, got %v, %v", want, got, err)
+			}
+		}
+	})
+
+	t.Run("thousands-separator", func(t *testing.T) {
 		type T struct {
-			S string
-			E
+			I  int
+			F  float64
+			S  string
+			Is []int
 		}
 
+		c := &Conv{Conf: Config{
+			ThousandsSeparator: ",",
+			StringSplitter:     func(v string) []string { return strings.Split(v, ",") },
+		}}
 		check(t, args{
-			c: _defaultConv,
-			src: T{
-				S: "ss",
-				E: E{
-					V1: 33,
-					E2: E2{
-						VV1: "vv1",
-						VV2: "vv2",
-					},
-				},
-			},
-			want: map[string]interface{}{
-				"S":   "ss",
-				"V1":  33,
-				"VV1": "vv1",
-				"VV2": "vv2",
-			},
-			errRegex: ``,
+			c:        c,
+			m:        map[string]interface{}{"I": "1,000,000", "F": "-1,234.5", "S": "1,000", "Is": "1,2,3"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{I: 1000000, F: -1234.5, S: "1,000", Is: []int{1, 2, 3}},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"I": "1,000"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'I': .+invalid syntax`,
 		})
 	})
 
-	t.Run("embedded-struct-with-tag", func(t *testing.T) {
-		type E2 struct {
-			VV2 string `conv:"value2"`
-		}
-		type E struct {
-			E2 `conv:"ee2"`
-			V1 int `conv:"value1"`
-		}
+	t.Run("dot-nested-keys", func(t *testing.T) {
+		type Meta struct{ Owner string }
 		type T struct {
-			E `conv:"ee"`
+			Name   string
+			Labels map[string]string
+			Meta   Meta
+			Dotted string `conv:"a.b"`
 		}
 
-		// TODO Convert from struct fields with tags are not supported now, it should come in the future.
+		c := &Conv{Conf: Config{
+			DotNestedKeys:       true,
+			FieldMatcherCreator: &SimpleMatcherCreator{Conf: SimpleMatcherConfig{CaseInsensitive: true, Tag: "conv"}},
+		}}
 		check(t, args{
-			c: _tagConv,
-			src: T{
-				E: E{
-					V1: 12,
-					E2: E2{
-						VV2: "vv2",
-					},
-				},
+			c: c,
+			m: map[string]interface{}{
+				"name":        "n",
+				"labels.env":  "prod",
+				"labels.app":  "web",
+				"labels.x.y":  1,
+				"meta.owner":  "me",
+				"a.b":         "matched",
+				"unknown.key": "v",
 			},
-			want: map[string]interface{}{
-				"V1":  12,
-				"VV2": "vv2",
+			dstTyp: reflect.TypeOf(T{}),
+			want: T{
+				Name:   "n",
+				Labels: map[string]string{"env": "prod", "app": "web", "x.y": "1"},
+				Meta:   Meta{Owner: "me"},
+				Dotted: "matched",
 			},
-			errRegex: ``,
+			errRegex: "",
 		})
-	})
-}
 
-func TestConv_StructToStruct(t *testing.T) {
-	type args struct {
-		c        *Conv
-		src      interface{}
-		dstTyp   reflect.Type
-		want     interface{}
-		errRegex string
-	}
-	check := func(t *testing.T, args args) {
-		got, err := args.c.StructToStruct(args.src, args.dstTyp)
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"labels": map[string]interface{}{}, "labels.env": "prod"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `the key 'labels' conflicts with the dotted keys`,
+		})
 
-		if err != nil {
-			if args.errRegex == "" {
-				t.Errorf("unexpected error = %v", err)
-			}
+		// Without the option.
+		check(t, args{
+			c:        _caseInsensitiveConv,
+			m:        map[string]interface{}{"labels.env": "prod"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{},
+			errRegex: "",
+		})
+	})
 
-			if match, _ := regexp.MatchString(args.errRegex, err.Error()); !match {
-				t.Errorf("error = %v , must match %v",
-					strconv.Quote(err.Error()), strconv.Quote(args.errRegex))
-			}
-		} else if args.errRegex != "" {
-			t.Errorf("want error, got nil, pattern = %v", args.errRegex)
+	t.Run("dot-nested-keys-multi-level", func(t *testing.T) {
+		type C struct{ Value, Other int }
+		type B struct {
+			C    C
+			PC   *C
+			Name string
 		}
-
-		if !reflect.DeepEqual(got, args.want) {
-			t.Errorf("want %v, got %v", args.want, got)
+		type A struct {
+			B  B
+			PB *B
+		}
+		type T struct {
+			A  A
+			PA *A
 		}
-	}
 
-	t.Run("err-nil", func(t *testing.T) {
+		c := &Conv{Conf: Config{DotNestedKeys: true}}
 		check(t, args{
-			c:        _defaultConv,
-			src:      nil,
-			dstTyp:   reflect.TypeOf(struct{}{}),
-			want:     nil,
-			errRegex: "^conv.StructToStruct: the source value should not be nil$",
+			c: c,
+			m: map[string]interface{}{
+				"A.B.C.Value":    1,
+				"A.B.C.Other":    2,
+				"A.B.PC.Value":   3,
+				"A.B.Name":       "ab",
+				"A.PB.C.Value":   4,
+				"PA.PB.PC.Value": 5,
+				"PA.B.Name":      "pab",
+			},
+			dstTyp: reflect.TypeOf(T{}),
+			want: T{
+				A: A{
+					B:  B{C: C{1, 2}, PC: &C{Value: 3}, Name: "ab"},
+					PB: &B{C: C{Value: 4}},
+				},
+				PA: &A{
+					B:  B{Name: "pab"},
+					PB: &B{PC: &C{Value: 5}},
+				},
+			},
+			errRegex: "",
 		})
-	})
 
-	t.Run("err-src", func(t *testing.T) {
 		check(t, args{
-			c:        _defaultConv,
-			src:      1,
-			dstTyp:   reflect.TypeOf(struct{}{}),
+			c:        c,
+			m:        map[string]interface{}{"A.B.C.Value": "x"},
+			dstTyp:   reflect.TypeOf(T{}),
 			want:     nil,
-			errRegex: "^conv.StructToStruct: the given value must be a struct, got int$",
+			errRegex: `error on converting field 'A.B.C.Value'`,
 		})
 	})
 
-	t.Run("err-dst", func(t *testing.T) {
+	t.Run("slice-of-interfaces", func(t *testing.T) {
+		type T struct {
+			FromInterfaces []interface{}
+			FromInts       []interface{}
+			FromStrings    []interface{}
+			WithNil        []interface{}
+			Map            map[string]interface{}
+		}
+
 		check(t, args{
-			c:        _defaultConv,
-			src:      struct{}{},
-			dstTyp:   reflect.TypeOf(1),
-			want:     nil,
-			errRegex: "^conv.StructToStruct: the destination type must be struct, got int$",
+			c: _defaultConv,
+			m: map[string]interface{}{
+				"FromInterfaces": []interface{}{1, "a", true},
+				"FromInts":       []int{1, 2, 3},
+				"FromStrings":    []string{"a", "b"},
+				"WithNil":        []interface{}{nil, 1},
+				"Map":            map[string]interface{}{"a": nil, "b": []int{1}},
+			},
+			dstTyp: reflect.TypeOf(T{}),
+			want: T{
+				FromInterfaces: []interface{}{1, "a", true},
+				FromInts:       []interface{}{1, 2, 3},
+				FromStrings:    []interface{}{"a", "b"},
+				WithNil:        []interface{}{nil, 1},
+				Map:            map[string]interface{}{"a": nil, "b": []int{1}},
+			},
+			errRegex: "",
 		})
 	})
 
-	t.Run("err-field-nil", func(t *testing.T) {
-		type from struct {
-			V interface{}
+	t.Run("collect-all-field-errors", func(t *testing.T) {
+		type T struct {
+			A, B, C int
+			D       string
 		}
-		type to struct {
-			V int
+
+		m := map[string]interface{}{"A": "x", "B": 1, "C": "y", "D": []int{1}}
+		c := &Conv{Conf: Config{CollectAllFieldErrors: true}}
+		_, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+
+		var me *MultiError
+		if !errors.As(err, &me) {
+			t.Fatalf("want *MultiError, got %v", err)
 		}
 
-		check(t, args{
-			c:        _defaultConv,
-			src:      from{},
-			dstTyp:   reflect.TypeOf(to{}),
-			want:     nil,
-			errRegex: "^conv.StructToStruct: error on converting field V: conv.ConvertType: cannot convert nil to int$",
-		})
-	})
+		errs := me.Errors()
+		if len(errs) != 3 {
+			t.Fatalf("want 3 errors, got %v", errs)
+		}
 
-	t.Run("err-field-type", func(t *testing.T) {
-		type from struct {
-			V chan int
+		for i, name := range []string{"A", "C", "D"} {
+			prefix := "error on converting field '" + name + "'"
+			if !strings.HasPrefix(errs[i].Error(), prefix) {
+				t.Errorf("errors[%v] should start with %v, got %v", i, prefix, errs[i])
+			}
 		}
-		type to struct {
-			V int
+
+		if !strings.HasPrefix(err.Error(), "conv.MapToStruct: error on converting field 'A'") {
+			t.Errorf("unexpected message: %v", err)
 		}
 
-		check(t, args{
-			c:        _defaultConv,
-			src:      from{V: make(chan int)},
-			dstTyp:   reflect.TypeOf(to{}),
-			want:     nil,
-			errRegex: "^conv.StructToStruct: error on converting field V: conv.ConvertType: cannot convert chan int to int$",
-		})
+		// Fail-fast by default.
+		_, err = _defaultConv.MapToStruct(m, reflect.TypeOf(T{}))
+		if errors.As(err, &me) {
+			t.Errorf("unexpected *MultiError: %v", err)
+		}
 	})
 
-	t.Run("field-mismatch", func(t *testing.T) {
-		type from struct {
-			V chan int // Mismatched field will not cause error.
+	t.Run("collect-all-field-errors-typed", func(t *testing.T) {
+		type Inner struct{ N int }
+		type T struct {
+			A     int
+			Inner Inner
+			Email string `conv:"email,readonly"`
 		}
-		type to struct {
-			Other int
+
+		m := map[string]interface{}{
+			"A":     "x",
+			"Inner": map[string]interface{}{"N": []int{1}},
+			"email": "e",
+		}
+		c := &Conv{Conf: Config{
+			CollectAllFieldErrors: true,
+			StrictReadonly:        true,
+			FieldMatcherCreator:   &SimpleMatcherCreator{Conf: SimpleMatcherConfig{Tag: "conv"}},
+		}}
+		_, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+
+		var me *MultiError
+		if !errors.As(err, &me) {
+			t.Fatalf("want *MultiError, got %v", err)
+		}
+
+		ces := me.ConvErrors()
+		if len(ces) != 3 {
+			t.Fatalf("want 3 errors, got %v", ces)
+		}
+
+		want := []struct {
+			path    string
+			srcType reflect.Type
+			dstType reflect.Type
+		}{
+			{"A", reflect.TypeOf(""), reflect.TypeOf(0)},
+			{"Inner.N", reflect.TypeOf([]int{}), reflect.TypeOf(0)},
+			{"email", reflect.TypeOf(""), nil}, // Not from converting a field, located by the key.
+		}
+		for i, w := range want {
+			ce := ces[i]
+			if ce.Path != w.path || ce.SrcType != w.srcType || ce.DstType != w.dstType || ce.Err == nil {
+				t.Errorf("errors[%v]: want %v %v %v, got %v %v %v", i, w.path, w.srcType, w.dstType, ce.Path, ce.SrcType, ce.DstType)
+			}
+		}
+
+		// Can be extracted by errors.As() .
+		var ce *ConvError
+		if !errors.As(err, &ce) || ce != ces[0] {
+			t.Errorf("want the first *ConvError, got %v", ce)
+		}
+	})
+
+	t.Run("named-time-type", func(t *testing.T) {
+		type T struct {
+			P *Timestamp
+			V Timestamp
+			S string
+			I int64
 		}
 
+		ts := Timestamp(time.Unix(1622726482, 0))
 		check(t, args{
 			c:        _defaultConv,
-			src:      from{V: make(chan int)},
-			dstTyp:   reflect.TypeOf(to{}),
-			want:     to{},
+			m:        map[string]interface{}{"P": "2021-06-03T13:21:22Z", "V": 1622726482},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{P: func() *Timestamp { v := Timestamp(time.Date(2021, 6, 3, 13, 21, 22, 0, time.UTC)); return &v }(), V: ts},
 			errRegex: "",
 		})
-	})
 
-	t.Run("clone", func(t *testing.T) {
-		type T struct {
-			Str   FromString
-			Int   int
-			Flt   float64
-			inner int
-		}
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"P": 1622726482, "V": &ts},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{P: &ts, V: ts},
+			errRegex: "",
+		})
 
 		check(t, args{
 			c:        _defaultConv,
-			src:      T{Str: "gg", Int: 333, Flt: -1.23, inner: 44},
+			m:        map[string]interface{}{"P": nil, "S": ts, "I": ts},
 			dstTyp:   reflect.TypeOf(T{}),
-			want:     T{Str: "gg", Int: 333, Flt: -1.23},
+			want:     T{S: time.Time(ts).Format(time.RFC3339), I: 1622726482},
 			errRegex: "",
 		})
 	})
 
-	t.Run("clone-case-insensitive", func(t *testing.T) {
-		type from struct {
-			Out string
-			out string //lint:ignore U1000 Ignored.
-			Sl  []byte
-		}
-		type to struct {
-			OUt float64
-			SL  []int
+	t.Run("null-strings", func(t *testing.T) {
+		type T struct {
+			I  int
+			P  *int
+			S  string
+			Is []int
+			A  interface{}
 		}
 
+		c := &Conv{Conf: Config{NullStrings: []string{"null", "NULL", "nil"}}}
 		check(t, args{
-			c:        _caseInsensitiveConv,
-			src:      from{Out: "-1999", Sl: []byte{3, 5, 77}},
-			dstTyp:   reflect.TypeOf(to{}),
-			want:     to{OUt: float64(-1999), SL: []int{3, 5, 77}},
+			c:        c,
+			m:        map[string]interface{}{"I": "null", "P": "NULL", "S": "nil", "Is": []string{"1", "null"}, "A": "null"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Is: []int{1, 0}},
 			errRegex: "",
 		})
-	})
 
-	t.Run("embedded-struct", func(t *testing.T) {
-		type EFrom struct {
-			V1 int
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"S": "Null", "P": "1"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{S: "Null", P: func() *int { v := 1; return &v }()},
+			errRegex: "",
+		})
+		// Convert works the same way as ConvertType.
+		n := 1
+		if err := c.Convert("null", &n); err != nil || n != 0 {
+			t.Errorf("unexpected result %v, %v", n, err)
 		}
-		type from struct {
-			EFrom
-			V2 int
+
+		p := &n
+		if err := c.Convert("NULL", &p); err != nil || p != nil {
+			t.Errorf("unexpected result %v, %v", p, err)
 		}
 
-		type ETo struct {
-			V2 string
+		// Map keys are not affected.
+		m, err := c.MapToMap(map[string]interface{}{"null": 1, "": 2}, reflect.TypeOf(map[string]int{}))
+		if want := map[string]int{"null": 1, "": 2}; err != nil || !reflect.DeepEqual(m, want) {
+			t.Errorf("want %v, got %v, %v", want, m, err)
 		}
-		type to struct {
-			V1 string
-			ETo
+	})
+
+	t.Run("url-decode-values", func(t *testing.T) {
+		type T struct {
+			S  string
+			I  int
+			Ss []string
+			M  map[string]string
 		}
 
+		c := &Conv{Conf: Config{URLDecodeValues: true}}
 		check(t, args{
-			c: _defaultConv,
-			src: from{
-				EFrom: EFrom{11},
-				V2:    22,
-			},
-			dstTyp: reflect.TypeOf(to{}),
-			want: to{
-				V1:  "11",
-				ETo: ETo{"22"},
+			c: c,
+			m: map[string]interface{}{
+				"S":  "a%20b+c",
+				"I":  "%31%32",
+				"Ss": []string{"%2F", "x"},
+				"M":  map[string]interface{}{"k": "%3D"},
 			},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{S: "a b c", I: 12, Ss: []string{"/", "x"}, M: map[string]string{"k": "="}},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"S": "%zz"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: error on decoding the value of 'S': invalid URL escape "%zz"$`,
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"M": map[string]interface{}{"k": "%"}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `cannot decode value of key 'k': invalid URL escape`,
+		})
+
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"S": "a%20b"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{S: "a%20b"},
 			errRegex: "",
 		})
 	})
 
-	t.Run("embedded-struct-with-tag", func(t *testing.T) {
-		type Ef struct {
-			V1 int
+	t.Run("pre-process-map", func(t *testing.T) {
+		type Inner struct{ Name, Kind string }
+		type T struct {
+			Name  string
+			Kind  string
+			Inner Inner
 		}
-		type from struct {
-			Ef `conv:"F"`
+
+		c := &Conv{Conf: Config{
+			PreProcessMap: func(m map[string]interface{}) map[string]interface{} {
+				res := make(map[string]interface{}, len(m)+1)
+				res["Kind"] = "default"
+				for k, v := range m {
+					if k == "title" {
+						k = "Name"
+					}
+					res[k] = v
+				}
+				return res
+			},
+		}}
+		m := map[string]interface{}{
+			"title": "t",
+			"Inner": map[string]interface{}{"title": "inner", "Kind": "k"},
 		}
+		check(t, args{
+			c:        c,
+			m:        m,
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Name: "t", Kind: "default", Inner: Inner{Name: "inner", Kind: "k"}},
+			errRegex: "",
+		})
 
-		type Et struct {
-			V int `conv:"V1"`
+		if _, ok := m["Kind"]; ok {
+			t.Errorf("the source map should not be modified")
 		}
-		type to struct {
-			F Et
+	})
+
+	t.Run("max-slice-len", func(t *testing.T) {
+		type T struct {
+			Is []int
+			Ss []string
 		}
 
-		// TODO Convert from struct fields with tags are not supported now, it should come in the future.
+		c := &Conv{Conf: Config{
+			MaxSliceLen:    2,
+			StringSplitter: func(v string) []string { return strings.Split(v, ",") },
+		}}
 		check(t, args{
-			c: _tagConv,
-			src: from{
-				Ef: Ef{V1: 33},
+			c:        c,
+			m:        map[string]interface{}{"Is": []int{1, 2}, "Ss": "a,b"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Is: []int{1, 2}, Ss: []string{"a", "b"}},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Is": []int{1, 2, 3}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Is': .+SliceToSlice: the length 3 exceeds the limit 2`,
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Ss": "a,b,c"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Ss': .+StringToSlice: the length 3 exceeds the limit 2`,
+		})
+
+		var warnings []string
+		c.Conf.TruncateLongSlices = true
+		c.Conf.OnWarning = func(path, msg string) { warnings = append(warnings, path+": "+msg) }
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Is": []int{1, 2, 3}, "Ss": "a,b,c"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Is: []int{1, 2}, Ss: []string{"a", "b"}},
+			errRegex: "",
+		})
+
+		if len(warnings) != 2 {
+			t.Errorf("want 2 warnings, got %v", warnings)
+		}
+	})
+
+	t.Run("readonly", func(t *testing.T) {
+		type T struct {
+			ID   int `conv:"id,readonly"`
+			Name string
+			Note string `conv:",readonly"`
+		}
+
+		check(t, args{
+			c:        _tagConv,
+			m:        map[string]interface{}{"id": 1, "Name": "n", "Note": "x"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Name: "n"},
+			errRegex: "",
+		})
+
+		c := &Conv{Conf: Config{
+			FieldMatcherCreator: &SimpleMatcherCreator{Conf: SimpleMatcherConfig{Tag: "conv"}},
+			StrictReadonly:      true,
+		}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"id": 1, "Name": "n"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `the field 'ID' is readonly`,
+		})
+
+		// The tag is unknown without a tag name.
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"ID": 1},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{ID: 1},
+			errRegex: "",
+		})
+	})
+
+	t.Run("value-transformer", func(t *testing.T) {
+		type T struct {
+			Name   string
+			Secret string
+			N      int
+			M      map[int]string
+		}
+
+		c := &Conv{Conf: Config{
+			ValueTransformer: func(key string, value interface{}) interface{} {
+				s, ok := value.(string)
+				if !ok {
+					return value
+				}
+
+				// Mock a decryption.
+				if key == "Secret" {
+					return strings.TrimPrefix(s, "enc:")
+				}
+				return strings.ToUpper(s)
 			},
-			dstTyp:   reflect.TypeOf(to{}),
-			want:     to{}, // Expect t{F: Et{V: 33}} when tags are supported.
+		}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Name": "abc", "Secret": "enc:pwd", "N": 1, "M": map[int]string{1: "x"}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Name: "ABC", Secret: "pwd", N: 1, M: map[int]string{1: "X"}},
 			errRegex: "",
 		})
 	})
-}
 
-func TestConv_ConvertType_convertPointers(t *testing.T) {
-	i := 1
-	pi := &i
-	ppi := &pi
-	pppi := &ppi
+	t.Run("presence-as-true", func(t *testing.T) {
+		type T struct {
+			Verbose bool
+			Debug   *bool
+			Quiet   bool
+			Force   bool
+			Name    string
+		}
 
-	s := "3"
-	ps := &s
-	pps := &ps
+		yes := true
+		c := &Conv{Conf: Config{PresenceAsTrue: true}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Verbose": "", "Debug": nil, "Force": "false", "Name": ""},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Verbose: true, Debug: &yes},
+			errRegex: "",
+		})
 
-	type args struct {
-		src    interface{}
-		dstTyp reflect.Type
-	}
-	tests := []struct {
-		name      string
-		args      args
-		wantVal   interface{}
-		wantDepth int
-		wantErr   bool
-	}{
-		{"i-i", args{i, reflect.TypeOf(i)}, i, 0, false},
-		{"pi-i", args{pi, reflect.TypeOf(i)}, i, 0, false},
-		{"ppi-i", args{ppi, reflect.TypeOf(i)}, i, 0, false},
-		{"pppi-i", args{pppi, reflect.TypeOf(i)}, i, 0, false},
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"Verbose": ""},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Verbose'`,
+		})
+	})
 
-		{"i-pi", args{i, reflect.TypeOf(pi)}, i, 1, false},
-		{"i-ppi", args{i, reflect.TypeOf(ppi)}, i, 2, false},
-		{"i-pppi", args{i, reflect.TypeOf(pppi)}, i, 3, false},
+	t.Run("enums", func(t *testing.T) {
+		type T struct {
+			C  Color
+			P  *Color
+			Cs []Color
+			I  int
+		}
 
-		{"pi-pi", args{pi, reflect.TypeOf(pi)}, i, 1, false},
-		{"ppi-ppi", args{ppi, reflect.TypeOf(ppi)}, i, 2, false},
-		{"pppi-pppi", args{pppi, reflect.TypeOf(pppi)}, i, 3, false},
+		blue := Color(3)
+		c := &Conv{Conf: Config{Enums: _colorEnums}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"C": "Red", "P": "Blue", "Cs": []string{"Green", "Red"}, "I": "3"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{C: 1, P: &blue, Cs: []Color{2, 1}, I: 3},
+			errRegex: "",
+		})
 
-		{"ppi-pi", args{pi, reflect.TypeOf(pi)}, i, 1, false},
-		{"pppi-pi", args{pppi, reflect.TypeOf(pi)}, i, 1, false},
-		{"pi-ppi", args{ppi, reflect.TypeOf(ppi)}, i, 2, false},
+		// Numbers are converted directly.
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"C": 2, "P": 3.0},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{C: 2, P: &blue},
+			errRegex: "",
+		})
 
-		{"s-s", args{s, reflect.TypeOf(s)}, s, 0, false},
-		{"ps-s", args{ps, reflect.TypeOf(s)}, s, 0, false},
-		{"s-ps", args{s, reflect.TypeOf(ps)}, s, 1, false},
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"C": "Purple"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'C': .+unknown name 'Purple' of the enum conv.Color`,
+		})
+
+		// Names are case-sensitive by default.
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"C": "red"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `unknown name 'red' of the enum conv.Color`,
+		})
+	})
+
+	t.Run("enums-fallback", func(t *testing.T) {
+		type T struct {
+			A Color
+			B Color
+			C Color
+			P *Color
+		}
+
+		green := Color(2)
+		c := &Conv{Conf: Config{Enums: _colorEnums, EnumCaseInsensitive: true}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": "RED", "B": "red", "C": "1", "P": "GrEeN"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: 1, B: 1, C: 1, P: &green},
+			errRegex: "",
+		})
+
+		// The numeric fallback does not require the value to be registered.
+		check(t, args{
+			c:        &Conv{Conf: Config{Enums: _colorEnums}},
+			m:        map[string]interface{}{"A": "9", "B": "-1"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: 9, B: -1},
+			errRegex: "",
+		})
+
+		for _, v := range []string{"1.5", "9999999999999999999999", "Purple", ""} {
+			check(t, args{
+				c:        c,
+				m:        map[string]interface{}{"A": v},
+				dstTyp:   reflect.TypeOf(T{}),
+				want:     nil,
+				errRegex: `error on converting field 'A': .+unknown name '` + regexp.QuoteMeta(v) + `' of the enum conv.Color`,
+			})
+		}
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		type T struct {
+			Name string
+			Raw  map[string]interface{} `conv:",raw"`
+		}
+
+		m := map[string]interface{}{
+			"Name":  "n",
+			"Raw":   "ignored key",
+			"Other": map[string]interface{}{"a": []interface{}{1, map[string]interface{}{"b": 2}}},
+		}
+		got, err := _tagConv.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		res := got.(T)
+		if res.Name != "n" || !reflect.DeepEqual(res.Raw, m) {
+			t.Errorf("unexpected result: %v", res)
+		}
+
+		// A deep copy.
+		res.Raw["Other"].(map[string]interface{})["a"].([]interface{})[1].(map[string]interface{})["b"] = 3
+		if m["Other"].(map[string]interface{})["a"].([]interface{})[1].(map[string]interface{})["b"] != 2 {
+			t.Errorf("the source map is modified")
+		}
+
+		type Two struct {
+			A map[string]interface{} `conv:",raw"`
+			B map[string]interface{} `conv:"b,raw"`
+		}
+		check(t, args{
+			c:        _tagConv,
+			m:        m,
+			dstTyp:   reflect.TypeOf(Two{}),
+			want:     nil,
+			errRegex: `only one raw field is allowed, got (A and B|B and A)`,
+		})
+
+		type WrongType struct {
+			A map[string]string `conv:",raw"`
+		}
+		check(t, args{
+			c:        _tagConv,
+			m:        m,
+			dstTyp:   reflect.TypeOf(WrongType{}),
+			want:     nil,
+			errRegex: `the raw field A must be map\[string\]interface\{\}, got map\[string\]string`,
+		})
+		// A partial result has the raw field too.
+		type Partial struct {
+			N   int
+			Raw map[string]interface{} `conv:",raw"`
+		}
+		c := &Conv{Conf: _tagConv.Conf}
+		c.Conf.CollectAllFieldErrors = true
+		c.Conf.ReturnPartialOnError = true
+		src := map[string]interface{}{"N": "x"}
+		got, err = c.MapToStruct(src, reflect.TypeOf(Partial{}))
+		if err == nil {
+			t.Fatal("want error")
+		}
+		if p, ok := got.(Partial); !ok || !reflect.DeepEqual(p.Raw, src) {
+			t.Errorf("want the raw map in the partial result, got %v", got)
+		}
+	})
+
+	t.Run("strict-types", func(t *testing.T) {
+		type Inner struct{ N int }
+		type T struct {
+			S     string
+			I     int
+			P     *int
+			Inner Inner
+		}
+
+		one := 1
+		c := &Conv{Conf: Config{StrictTypes: true}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"S": "s", "I": 1, "P": &one, "Inner": map[string]interface{}{"N": 2}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{S: "s", I: 1, P: &one, Inner: Inner{N: 2}},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"S": 1},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'S': strict types: cannot assign int to string`,
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"P": "1"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'P': strict types: cannot assign string to int`,
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Inner": map[string]interface{}{"N": 2.0}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Inner.N': strict types: cannot assign float64 to int`,
+		})
+
+		_, err := c.StructToStruct(struct{ I int64 }{1}, reflect.TypeOf(T{}))
+		if err == nil || !strings.Contains(err.Error(), "strict types: cannot assign int64 to int") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("matcher-chain", func(t *testing.T) {
+		type T struct {
+			UserName string
+			Username string
+			MaxAge   int
+			Note     string `conv:"memo,readonly"`
+		}
+
+		c := &Conv{Conf: Config{
+			MatcherChain: []FieldMatcherCreator{
+				&SimpleMatcherCreator{},
+				&SimpleMatcherCreator{Conf: SimpleMatcherConfig{Tag: "conv", CaseInsensitive: true}},
+				&SimpleMatcherCreator{Conf: SimpleMatcherConfig{CamelSnakeCase: true}},
+			},
+		}}
+		check(t, args{
+			c: c,
+			m: map[string]interface{}{
+				"Username": "exact",     // Exact matching.
+				"USERNAME": "ci",        // Case-insensitive, matches the first one.
+				"max_age":  1,           // Camel-snake-case.
+				"MEMO":     "read only", // Case-insensitive, the field is readonly.
+			},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{UserName: "ci", Username: "exact", MaxAge: 1},
+			errRegex: "",
+		})
+	})
+
+	t.Run("interface-field-types", func(t *testing.T) {
+		type Detail struct{ ID int }
+		type T struct {
+			Data   interface{}
+			Reader io.Reader
+			Other  interface{}
+		}
+
+		c := &Conv{Conf: Config{
+			InterfaceFieldTypes: map[string]reflect.Type{
+				"Data":   reflect.TypeOf(&Detail{}),
+				"Reader": reflect.TypeOf(&bytes.Buffer{}),
+			},
+		}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Data": map[string]interface{}{"ID": "1"}, "Other": map[string]interface{}{"ID": 2}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Data: &Detail{ID: 1}, Other: map[string]interface{}{"ID": 2}},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Data": nil},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{},
+			errRegex: "",
+		})
+
+		c.Conf.InterfaceFieldTypes["Reader"] = reflect.TypeOf(Detail{})
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Reader": map[string]interface{}{}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Reader': the type conv.Detail does not implement io.Reader`,
+		})
+		// The keys are paths, a field with the same name in a nested struct is not affected.
+		type Outer struct {
+			Data  interface{}
+			Inner struct{ Data interface{} }
+			Items []struct{ Data interface{} }
+		}
+		c = &Conv{Conf: Config{
+			InterfaceFieldTypes: map[string]reflect.Type{
+				"Inner.Data": reflect.TypeOf(&Detail{}),
+				"Items.Data": reflect.TypeOf(Detail{}),
+			},
+		}}
+		got, err := c.MapToStruct(map[string]interface{}{
+			"Data":  map[string]interface{}{"ID": 1},
+			"Inner": map[string]interface{}{"Data": map[string]interface{}{"ID": 2}},
+			"Items": []interface{}{map[string]interface{}{"Data": map[string]interface{}{"ID": 3}}},
+		}, reflect.TypeOf(Outer{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		o := got.(Outer)
+		if !reflect.DeepEqual(o.Data, map[string]interface{}{"ID": 1}) {
+			t.Errorf("the root field should not be affected, got %v", o.Data)
+		}
+		if !reflect.DeepEqual(o.Inner.Data, &Detail{ID: 2}) {
+			t.Errorf("want &{2}, got %v", o.Inner.Data)
+		}
+		if len(o.Items) != 1 || !reflect.DeepEqual(o.Items[0].Data, Detail{ID: 3}) {
+			t.Errorf("want [{3}], got %v", o.Items)
+		}
+	})
+
+	t.Run("enum-names", func(t *testing.T) {
+		type T struct {
+			Name  string
+			Names []string
+			Ptr   *string
+			Num   int
+		}
+
+		red := "Red"
+		c := &Conv{Conf: Config{Enums: _colorEnums}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Name": Color(2), "Names": []Color{3, 9}, "Ptr": Color(1), "Num": Color(3)},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Name: "Green", Names: []string{"Blue", "9"}, Ptr: &red, Num: 3},
+			errRegex: "",
+		})
+
+		s, err := c.SimpleToString(Color(3))
+		if err != nil || s != "Blue" {
+			t.Errorf("want Blue, got %v, %v", s, err)
+		}
+	})
+
+	t.Run("unwrap-singleton-slices", func(t *testing.T) {
+		type T struct {
+			I    int
+			P    *string
+			List []string
+			B    []byte
+		}
+
+		s := "a"
+		c := &Conv{Conf: Config{UnwrapSingletonSlices: true}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"I": []string{"42"}, "P": []interface{}{"a"}, "List": []string{"x"}, "B": []byte("b")},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{I: 42, P: &s, List: []string{"x"}, B: []byte("b")},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"P": []string{"a", "b"}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'P': cannot unwrap a slice of 2 elements to string`,
+		})
+
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"I": []string{"42"}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'I'`,
+		})
+	})
+
+	t.Run("flatten-keys-numeric", func(t *testing.T) {
+		type T struct {
+			F float64
+			P *float64
+			I int
+		}
+
+		f := 2.5
+		c := &Conv{Conf: Config{FlattenKeys: []string{"value"}}}
+		check(t, args{
+			c: c,
+			m: map[string]interface{}{
+				"F": map[string]interface{}{"value": 3.14},
+				"P": map[string]interface{}{"value": "2.5"},
+				"I": 7,
+			},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{F: 3.14, P: &f, I: 7},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"F": map[string]interface{}{"amount": 3.14}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'F'`,
+		})
+
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"F": map[string]interface{}{"value": 3.14}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'F'`,
+		})
+	})
+
+	t.Run("lenient-complex", func(t *testing.T) {
+		type T struct {
+			A, B, C, D, E, F complex128
+			G                complex64
+		}
+
+		m := map[string]interface{}{
+			"A": "3+4i",
+			"B": "3",
+			"C": "3i",
+			"D": "(3,4)",
+			"E": " 3 - 4j ",
+			"F": "i",
+			"G": "(1.5,-2)",
+		}
+		c := &Conv{Conf: Config{LenientComplex: true}}
+		check(t, args{
+			c:        c,
+			m:        m,
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: 3 + 4i, B: 3, C: 3i, D: 3 + 4i, E: 3 - 4i, F: 1i, G: 1.5 - 2i},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": "(3,4,5)"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'A': .*cannot convert "\(3,4,5\)" \(string\) to complex128`,
+		})
+
+		// The default parsing accepts the formats of strconv.ParseComplex() only.
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"A": "3+4i", "B": "3", "C": "3i"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: 3 + 4i, B: 3, C: 3i},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"D": "(3,4)"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'D'`,
+		})
+	})
+
+	t.Run("required-nonempty", func(t *testing.T) {
+		type Inner struct {
+			ID int `conv:"id,required"`
+		}
+		type T struct {
+			Name  string   `conv:"name,required,nonempty"`
+			Tags  []string `conv:"tags,nonempty"`
+			Note  *string  `conv:"note,nonempty"`
+			Age   int      `conv:"age,required"`
+			Inner *Inner   `conv:"inner"`
+		}
+
+		note := "n"
+		check(t, args{
+			c:        _tagConv,
+			m:        map[string]interface{}{"name": "Bob", "tags": []string{"a"}, "note": "n", "age": 0},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Name: "Bob", Tags: []string{"a"}, Note: &note},
+			errRegex: "",
+		})
+
+		// Absent nonempty fields are not checked.
+		check(t, args{
+			c:        _tagConv,
+			m:        map[string]interface{}{"name": "Bob", "age": 1},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Name: "Bob", Age: 1},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        _tagConv,
+			m:        map[string]interface{}{"age": 1},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: error on converting field 'Name': the field is required$`,
+		})
+
+		check(t, args{
+			c:        _tagConv,
+			m:        map[string]interface{}{"name": "", "age": 1},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: error on converting field 'Name': the value must not be empty$`,
+		})
+
+		check(t, args{
+			c:        _tagConv,
+			m:        map[string]interface{}{"name": "Bob", "age": 1, "tags": []string{}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Tags': the value must not be empty`,
+		})
+
+		check(t, args{
+			c:        _tagConv,
+			m:        map[string]interface{}{"name": "Bob", "age": 1, "note": ""},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Note': the value must not be empty`,
+		})
+
+		check(t, args{
+			c:        _tagConv,
+			m:        map[string]interface{}{"name": "Bob", "age": 1, "inner": map[string]interface{}{}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: error on converting field 'Inner.ID': the field is required$`,
+		})
+
+		c := &Conv{Conf: _tagConv.Conf}
+		c.Conf.CollectAllFieldErrors = true
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"name": " ", "tags": nil},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: error on converting field 'Age': the field is required; error on converting field 'Tags': the value must not be empty$`,
+		})
+
+		// All missing fields are reported at once.
+		check(t, args{
+			c:        _tagConv,
+			m:        map[string]interface{}{},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: error on converting field 'Age': the field is required; error on converting field 'Name': the field is required$`,
+		})
+	})
+
+	t.Run("require-all-fields", func(t *testing.T) {
+		type Inner struct{ X, Y int }
+		type T struct {
+			A     int
+			B     *string `conv:"b,optional"`
+			C     string  `conv:"c,omit"`
+			Inner Inner
+		}
+
+		c := &Conv{Conf: _tagConv.Conf}
+		c.Conf.RequireAllFields = true
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": 1, "Inner": map[string]interface{}{"X": 2, "Y": 3}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: 1, Inner: Inner{2, 3}},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": 1, "Inner": map[string]interface{}{}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: error on converting field 'Inner.X': the field is required; error on converting field 'Inner.Y': the field is required$`,
+		})
+
+		// Works without tags.
+		check(t, args{
+			c:        &Conv{Conf: Config{RequireAllFields: true}},
+			m:        map[string]interface{}{"Inner": map[string]interface{}{"X": 2, "Y": 3}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: error on converting field 'A': the field is required; error on converting field 'B': the field is required; error on converting field 'C': the field is required$`,
+		})
+
+		// The errors of the nested struct are flattened.
+		cc := &Conv{Conf: c.Conf}
+		cc.Conf.CollectAllFieldErrors = true
+		_, err := cc.MapToStruct(map[string]interface{}{"Inner": map[string]interface{}{"X": "x"}}, reflect.TypeOf(T{}))
+		var me *MultiError
+		if !errors.As(err, &me) || len(me.ConvErrors()) != 3 {
+			t.Fatalf("unexpected error %v", err)
+		}
+		for i, path := range []string{"A", "Inner.X", "Inner.Y"} {
+			if got := me.ConvErrors()[i].Path; got != path {
+				t.Errorf("want %v, got %v", path, got)
+			}
+		}
+	})
+
+	t.Run("default-values", func(t *testing.T) {
+		type Inner struct {
+			Level string `conv:"level,default=info"`
+		}
+		type T struct {
+			Host    string    `conv:"host,default=localhost"`
+			Port    int       `conv:"port,default=8080"`
+			Debug   *bool     `conv:"debug,default=true"`
+			Date    time.Time `conv:"date,default=2022-01-02,layout=2006-01-02"`
+			Name    string    `conv:"name,required,default=x"`
+			Omitted int       `conv:"omitted,omit,default=1"`
+			*Inner
+		}
+
+		debug := true
+		date := time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+		check(t, args{
+			c:      _tagConv,
+			m:      map[string]interface{}{},
+			dstTyp: reflect.TypeOf(T{}),
+			want: T{
+				Host: "localhost", Port: 8080, Debug: &debug, Date: date, Name: "x",
+				Inner: &Inner{"info"},
+			},
+			errRegex: "",
+		})
+
+		// The values in the map win, including nils.
+		check(t, args{
+			c:      _tagConv,
+			m:      map[string]interface{}{"host": "h", "port": "81", "debug": nil, "level": "warn", "omitted": 2},
+			dstTyp: reflect.TypeOf(T{}),
+			want: T{
+				Host: "h", Port: 81, Debug: nil, Date: date, Name: "x",
+				Inner: &Inner{"warn"},
+			},
+			errRegex: "",
+		})
+
+		type Invalid struct {
+			A int `conv:"a,default=x"`
+			B int `conv:"b,default=1"`
+		}
+		check(t, args{
+			c:        _tagConv,
+			m:        map[string]interface{}{},
+			dstTyp:   reflect.TypeOf(Invalid{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: error on converting field 'A': invalid default value 'x': conv.ConvertType: conv.SimpleToSimple: strconv.ParseInt`,
+		})
+
+		_, n, err := _tagConv.MapToStructCount(map[string]interface{}{"port": 1}, reflect.TypeOf(T{}))
+		if err != nil || n != 1 {
+			t.Errorf("want count 1, got %v, %v", n, err)
+		}
+	})
+
+	t.Run("accept-scientific-int", func(t *testing.T) {
+		type T struct {
+			A int
+			B int64
+			U uint16
+			P *int
+			S string
+		}
+
+		p := 7
+		c := &Conv{Conf: Config{AcceptScientificInt: true}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": "1e3", "B": "2.5e2", "U": "6.5E1", "P": "7", "S": "1e3"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: 1000, B: 250, U: 65, P: &p, S: "1e3"},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": "1.5e0"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'A': .*lost precision`,
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"U": "1e6"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'U': .*value overflow`,
+		})
+
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"A": "1e3"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'A'`,
+		})
+	})
+
+	t.Run("error-on-ambiguous-embedded", func(t *testing.T) {
+		type A struct{ ID, A int }
+		type B struct{ ID, B int }
+		type T struct {
+			A
+			*B
+		}
+
+		m := map[string]interface{}{"ID": 1, "A": 2, "B": 3}
+		check(t, args{
+			c:        _defaultConv,
+			m:        m,
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: A{ID: 1, A: 2}, B: &B{B: 3}},
+			errRegex: "",
+		})
+
+		c := &Conv{Conf: Config{ErrorOnAmbiguousEmbedded: true}}
+		check(t, args{
+			c:        c,
+			m:        m,
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: ambiguous fields A.ID and B.ID of conv.T$`,
+		})
+
+		// Fields of the outer struct hide the embedded ones, they are not ambiguous.
+		type U struct {
+			A
+			*B
+			ID int
+		}
+		check(t, args{
+			c:        c,
+			m:        m,
+			dstTyp:   reflect.TypeOf(U{}),
+			want:     U{A: A{A: 2}, B: &B{B: 3}, ID: 1},
+			errRegex: "",
+		})
+	})
+
+	t.Run("drain-channels", func(t *testing.T) {
+		type T struct {
+			Items   []int
+			Strings []string
+			Nil     []int
+		}
+
+		newSource := func() map[string]interface{} {
+			ints := make(chan int, 3)
+			ints <- 1
+			ints <- 2
+			ints <- 3
+			close(ints)
+
+			strs := make(chan interface{}, 2)
+			strs <- "a"
+			strs <- 1
+			close(strs)
+
+			var nilChan chan int
+			return map[string]interface{}{"Items": ints, "Strings": (<-chan interface{})(strs), "Nil": nilChan}
+		}
+
+		c := &Conv{Conf: Config{DrainChannels: true}}
+		check(t, args{
+			c:        c,
+			m:        newSource(),
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Items: []int{1, 2, 3}, Strings: []string{"a", "1"}},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Items": make(chan<- int)},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Items': .*cannot receive from chan<- int`,
+		})
+
+		check(t, args{
+			c:        _defaultConv,
+			m:        newSource(),
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `cannot convert (<-)?chan`,
+		})
+	})
+
+	t.Run("iso8601-durations", func(t *testing.T) {
+		type T struct {
+			A time.Duration
+			B time.Duration
+			C time.Duration
+			D *time.Duration
+			S string
+		}
+
+		d := -(time.Minute + 500*time.Millisecond)
+		c := &Conv{Conf: Config{ISO8601Durations: true}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": "PT1H30M", "B": "P1DT2H", "C": "P1W", "D": "-PT1M0.5S", "S": "PT1H"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: 90 * time.Minute, B: 26 * time.Hour, C: 7 * 24 * time.Hour, D: &d, S: "PT1H"},
+			errRegex: "",
+		})
+
+		// Non-ISO8601 values are converted as usual.
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": "1000", "B": 20},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: 1000, B: 20},
+			errRegex: "",
+		})
+
+		for _, v := range []string{"P", "PT", "P1DT", "P1H", "PT1D", "PT1M1H", "PT1.5M1S", "PT1.S", "P1D2", "PTxS", "P1DT1H1H"} {
+			check(t, args{
+				c:        c,
+				m:        map[string]interface{}{"A": v},
+				dstTyp:   reflect.TypeOf(T{}),
+				want:     nil,
+				errRegex: `error on converting field 'A': .*invalid ISO8601 duration "` + regexp.QuoteMeta(v) + `"`,
+			})
+		}
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": "P1Y"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `years and months are not supported`,
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": "P999999999W"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `invalid ISO8601 duration`,
+		})
+
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"A": "PT1H30M"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'A'`,
+		})
+	})
+
+	t.Run("migrate-map", func(t *testing.T) {
+		// v1: {"version": 1, "name": "first last", "age": 10}
+		// v2: {"version": 2, "FirstName": "first", "LastName": "last", "Age": 10}
+		type T struct {
+			Version   int
+			FirstName string
+			LastName  string
+			Age       int
+		}
+
+		var versions []int
+		c := &Conv{Conf: Config{
+			VersionKey: "Version",
+			MigrateMap: func(version int, m map[string]interface{}) map[string]interface{} {
+				versions = append(versions, version)
+				if version >= 2 {
+					return m
+				}
+
+				res := map[string]interface{}{"Version": 2, "Age": m["age"]}
+				parts := strings.SplitN(m["name"].(string), " ", 2)
+				res["FirstName"] = parts[0]
+				if len(parts) > 1 {
+					res["LastName"] = parts[1]
+				}
+				return res
+			},
+		}}
+
+		want := T{Version: 2, FirstName: "first", LastName: "last", Age: 10}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Version": "1", "name": "first last", "age": 10},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     want,
+			errRegex: "",
+		})
+
+		// A missing version is 0.
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"name": "first last", "age": 10},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     want,
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Version": 2, "FirstName": "first", "LastName": "last", "Age": 10},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     want,
+			errRegex: "",
+		})
+
+		if wantVersions := []int{1, 0, 2}; !reflect.DeepEqual(versions, wantVersions) {
+			t.Errorf("want versions %v, got %v", wantVersions, versions)
+		}
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Version": "x"},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: cannot parse the version 'x'`,
+		})
+	})
+
+	t.Run("return-partial-on-error", func(t *testing.T) {
+		type T struct {
+			A int
+			B string
+			C int
+		}
+
+		m := map[string]interface{}{"A": 1, "B": "b", "C": "x"}
+		c := &Conv{Conf: Config{ReturnPartialOnError: true}}
+		got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+		if err == nil {
+			t.Fatal("expect an error")
+		}
+
+		// The keys are iterated in random order, the fields set before the failing one are kept.
+		res, ok := got.(T)
+		if !ok {
+			t.Fatalf("want a T, got %#v", got)
+		}
+		if res.C != 0 || (res.A != 0 && res.A != 1) || (res.B != "" && res.B != "b") {
+			t.Errorf("unexpected partial result %#v", res)
+		}
+
+		// All valid fields are set when collecting errors.
+		c.Conf.CollectAllFieldErrors = true
+		got, n, err := c.MapToStructCount(m, reflect.TypeOf(T{}))
+		if err == nil || !regexp.MustCompile(`error on converting field 'C'`).MatchString(err.Error()) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := (T{A: 1, B: "b"}); !reflect.DeepEqual(got, want) || n != 2 {
+			t.Errorf("want %#v, 2, got %#v, %v", want, got, n)
+		}
+
+		// Disabled.
+		got, err = _defaultConv.MapToStruct(m, reflect.TypeOf(T{}))
+		if err == nil || got != nil {
+			t.Errorf("want nil and an error, got %#v, %v", got, err)
+		}
+
+		// Errors not from the fields.
+		got, err = c.MapToStruct(m, reflect.TypeOf(0))
+		if err == nil || got != nil {
+			t.Errorf("want nil and an error, got %#v, %v", got, err)
+		}
+	})
+
+	t.Run("decode-json-strings", func(t *testing.T) {
+		type Inner struct {
+			Name string
+			ID   int64
+		}
+		type T struct {
+			Inner  Inner
+			Ptr    *Inner
+			Map    map[string]int
+			List   []Inner
+			Arr    [2]int
+			Split  []string
+			String string
+		}
+
+		c := &Conv{Conf: Config{DecodeJSONStrings: true}}
+		check(t, args{
+			c: c,
+			m: map[string]interface{}{
+				"Inner":  `{"Name": "a", "ID": 9007199254740993}`,
+				"Ptr":    ` {"Name": "b"}`,
+				"Map":    `{"x": 1, "y": "2"}`,
+				"List":   `[{"Name": "c"}, {"ID": 3}]`,
+				"Arr":    `[4, 5]`,
+				"Split":  `a,b`,
+				"String": `{"Name": "d"}`,
+			},
+			dstTyp: reflect.TypeOf(T{}),
+			want: T{
+				Inner:  Inner{Name: "a", ID: 9007199254740993},
+				Ptr:    &Inner{Name: "b"},
+				Map:    map[string]int{"x": 1, "y": 2},
+				List:   []Inner{{Name: "c"}, {ID: 3}},
+				Arr:    [2]int{4, 5},
+				Split:  []string{"a,b"},
+				String: `{"Name": "d"}`,
+			},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Inner": `{"Name": `},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Inner': .*cannot decode the JSON string: unexpected EOF`,
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"List": `[] []`},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'List': .*unexpected data after the value`,
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Inner": `[]`},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Inner': .*cannot convert string to conv.Inner`,
+		})
+
+		// The error of the decoded value is not wrapped twice.
+		_, err := c.ConvertType(`["x"]`, reflect.TypeOf([]int{}))
+		want := `conv.ConvertType: conv.SliceToSlice: cannot convert to []int, at index 0 : ` +
+			`conv.ConvertType: conv.SimpleToSimple: strconv.ParseInt: parsing "x": invalid syntax`
+		if err == nil || err.Error() != want {
+			t.Errorf("want error %v, got %v", want, err)
+		}
+
+		check(t, args{
+			c:        _defaultConv,
+			m:        map[string]interface{}{"Inner": `{"Name": "a"}`},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Inner'`,
+		})
+
+		got, err := c.ConvertType(`{"Name": "e"}`, reflect.TypeOf(Inner{}))
+		if err != nil || got != (Inner{Name: "e"}) {
+			t.Errorf("unexpected result %v, %v", got, err)
+		}
+	})
+
+	t.Run("disallow-unknown-fields", func(t *testing.T) {
+		type Inner struct{ X int }
+		type T struct {
+			A     int
+			Inner Inner
+		}
+
+		c := &Conv{Conf: Config{DisallowUnknownFields: true}}
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": 1, "Inner": map[string]interface{}{"X": 2}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: 1, Inner: Inner{X: 2}},
+			errRegex: "",
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"A": 1, "c": 2, "b": 3},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `^conv.MapToStruct: unknown keys: 'b', 'c'$`,
+		})
+
+		check(t, args{
+			c:        c,
+			m:        map[string]interface{}{"Inner": map[string]interface{}{"Y": 2}},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     nil,
+			errRegex: `error on converting field 'Inner': .*unknown keys: 'Y'`,
+		})
+
+		// Forbidden keys are not checked.
+		cc := &Conv{Conf: c.Conf}
+		cc.Conf.ForbiddenKeys = []string{"b"}
+		check(t, args{
+			c:        cc,
+			m:        map[string]interface{}{"A": 1, "b": 2},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{A: 1},
+			errRegex: "",
+		})
+
+		cc = &Conv{Conf: c.Conf}
+		cc.Conf.CollectAllFieldErrors = true
+		_, err := cc.MapToStruct(map[string]interface{}{"A": "x", "b": 2}, reflect.TypeOf(T{}))
+		var me *MultiError
+		if !errors.As(err, &me) || len(me.ConvErrors()) != 2 || me.ConvErrors()[1].Path != "b" || me.ConvErrors()[1].Err != errUnknownKey {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("key-aliases", func(t *testing.T) {
+		type T struct {
+			MailAddr string
+			Name     string
+			Age      int
+		}
+
+		c := &Conv{
+			Conf: Config{
+				KeyAliases: map[string]string{
+					"emailAddress": "MailAddr",
+					"Age":          "Years", // Takes precedence, 'Age' is matched as 'Years', which matches nothing.
+				},
+			},
+		}
+
+		check(t, args{
+			c: c,
+			m: map[string]interface{}{
+				"emailAddress": "bob@example.org",
+				"Name":         "Bob",
+				"Age":          51,
+			},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{MailAddr: "bob@example.org", Name: "Bob"},
+			errRegex: "",
+		})
+	})
+
+	t.Run("ok-embedded-struct-squash", func(t *testing.T) {
+		type E struct {
+			V1 string
+			VE string `conv:"e"`
+		}
+		type P struct {
+			VP int `conv:"p"`
+		}
+		type T struct {
+			S  string              `conv:"str"`
+			E  `conv:"emb,squash"` // Matched at the parent level.
+			*P `conv:",squash"`
+		}
+
+		check(t, args{
+			c: _tagConv,
+			m: map[string]interface{}{
+				"str": "s",
+				"V1":  "v1",
+				"e":   "ve",
+				"p":   12,
+				"emb": map[string]interface{}{"V1": "x"}, // Not processed.
+			},
+			dstTyp: reflect.TypeOf(T{}),
+			want: T{
+				S: "s",
+				E: E{
+					V1: "v1",
+					VE: "ve",
+				},
+				P: &P{VP: 12},
+			},
+			errRegex: "",
+		})
+	})
+}
+
+func TestConv_MapToMap(t *testing.T) {
+	type args struct {
+		m      interface{}
+		dstTyp reflect.Type
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     interface{}
+		errRegex string
+	}{
+		{
+			"nil",
+			args{
+				map[string]int(nil),
+				reflect.TypeOf(map[float32]int(nil)),
+			},
+			map[float32]int(nil),
+			"",
+		},
+
+		{
+			"si-si",
+			args{
+				map[string]int{
+					"a": 1,
+					"b": 2,
+					"c": 3,
+				},
+				reflect.TypeOf(map[string]int(nil)),
+			},
+			map[string]int{
+				"a": 1,
+				"b": 2,
+				"c": 3,
+			},
+			"",
+		},
+
+		{
+			"si-is",
+			args{
+				map[string]int{
+					"1":  11,
+					"2":  22,
+					"33": 3,
+				},
+				reflect.TypeOf(map[int]string(nil)),
+			},
+			map[int]string{
+				1:  "11",
+				2:  "22",
+				33: "3",
+			},
+			"",
+		},
+
+		{
+			"[]interface-interface",
+			args{
+				map[string]interface{}{
+					"1": []interface{}{1, 2, 3},
+				},
+				reflect.TypeOf(map[interface{}]interface{}(nil)),
+			},
+			map[interface{}]interface{}{
+				"1": []interface{}{1, 2, 3},
+			},
+			"",
+		},
+
+		{
+			"err-src",
+			args{
+				123,
+				reflect.TypeOf(map[int]string(nil)),
+			},
+			nil,
+			"must be a map",
+		},
+
+		{
+			"err-typ",
+			args{
+				map[string]int{},
+				reflect.TypeOf(1),
+			},
+			nil,
+			"destination type must be map",
+		},
+
+		{
+			"err-key",
+			args{
+				map[string]int{"a": 1},
+				reflect.TypeOf(map[int]int{}),
+			},
+			nil,
+			"cannot covert key 'a' to int: .+",
+		},
+
+		{
+			"err-elem",
+			args{
+				map[string]string{"aa": "x"},
+				reflect.TypeOf(map[string]int{}),
+			},
+			nil,
+			"cannot covert value of key 'aa' to int: .+",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := _defaultConv.MapToMap(tt.args.m, tt.args.dstTyp)
+
+			if err != nil {
+				if tt.errRegex == "" {
+					t.Errorf("MapToMap() unexpected error = %v", err)
+				}
+
+				if match, _ := regexp.MatchString(tt.errRegex, err.Error()); !match {
+					t.Errorf("MapToMap() error = %v , must match %v",
+						strconv.Quote(err.Error()), strconv.Quote(tt.errRegex))
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MapToMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConv_MapToKVSlice(t *testing.T) {
+	type KV struct {
+		Key   int
+		Value string
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		got, err := _defaultConv.MapToKVSlice(map[int]int{10: 1, 2: 2, -1: 3}, reflect.TypeOf([]KV{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := []KV{{-1, "3"}, {2, "2"}, {10, "1"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+
+		got, err = _defaultConv.MapToKVSlice(map[string]interface{}{"b": 1, "a": "2"}, reflect.TypeOf([]*KV{}))
+		if err == nil || !strings.Contains(err.Error(), "error on converting field '[0].Key'") {
+			t.Errorf("unexpected result %v, %v", got, err)
+		}
+
+		got, err = _defaultConv.MapToKVSlice(map[int]int(nil), reflect.TypeOf([]KV{}))
+		if err != nil || !reflect.DeepEqual(got, []KV(nil)) {
+			t.Errorf("unexpected result %v, %v", got, err)
+		}
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		got, err := _defaultConv.KVSliceToMap([]*KV{{1, "a"}, nil, {2, "b"}, {1, "c"}}, reflect.TypeOf(map[string]string{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := map[string]string{"1": "c", "2": "b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+
+		got, err = _defaultConv.KVSliceToMap([]KV(nil), reflect.TypeOf(map[int]string{}))
+		if err != nil || !reflect.DeepEqual(got, map[int]string(nil)) {
+			t.Errorf("unexpected result %v, %v", got, err)
+		}
+	})
+
+	t.Run("convert-type", func(t *testing.T) {
+		type T struct {
+			Scores []KV
+		}
+		type M struct {
+			Scores map[int]string
+		}
+
+		c := &Conv{Conf: Config{MapKVSlices: true}}
+		src := M{map[int]string{3: "c", 1: "a"}}
+		got, err := c.ConvertType(src, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		want := T{[]KV{{1, "a"}, {3, "c"}}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+
+		back, err := c.ConvertType(got, reflect.TypeOf(M{}))
+		if err != nil || !reflect.DeepEqual(back, src) {
+			t.Errorf("want %v, got %v, %v", src, back, err)
+		}
+
+		// Disabled.
+		if _, err := _defaultConv.ConvertType(src, reflect.TypeOf(T{})); err == nil {
+			t.Errorf("want error")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := _defaultConv.MapToKVSlice(map[int]int{}, reflect.TypeOf([]int{}))
+		if want := "conv.MapToKVSlice: the element type must be a struct with the fields Key and Value, got int"; err == nil || err.Error() != want {
+			t.Errorf("want %v, got %v", want, err)
+		}
+
+		_, err = _defaultConv.KVSliceToMap([]struct{ Key int }{}, reflect.TypeOf(map[int]int{}))
+		if err == nil || !strings.Contains(err.Error(), "the element type must be a struct with the fields Key and Value") {
+			t.Errorf("unexpected error %v", err)
+		}
+
+		_, err = _defaultConv.KVSliceToMap([]KV{{1, "x"}}, reflect.TypeOf(map[int]int{}))
+		if err == nil || !strings.Contains(err.Error(), "cannot convert the value at index 0 to int") {
+			t.Errorf("unexpected error %v", err)
+		}
+
+		_, err = _defaultConv.MapToKVSlice(1, reflect.TypeOf([]KV{}))
+		if err == nil || !strings.Contains(err.Error(), "must be a map") {
+			t.Errorf("unexpected error %v", err)
+		}
+
+		_, err = _defaultConv.KVSliceToMap(1, reflect.TypeOf(map[int]int{}))
+		if err == nil || !strings.Contains(err.Error(), "src must be a slice or an array") {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+}
+
+func TestConv_StructToMap(t *testing.T) {
+	type args struct {
+		c        *Conv
+		src      interface{}
+		want     map[string]interface{}
+		errRegex string
+	}
+	check := func(t *testing.T, args args) {
+		got, err := args.c.StructToMap(args.src)
+
+		if err != nil {
+			if args.errRegex == "" {
+				t.Errorf("StructToMap() unexpected error = %v", err)
+			}
+
+			if match, _ := regexp.MatchString(args.errRegex, err.Error()); !match {
+				t.Errorf("StructToMap() error = %v , must match %v",
+					strconv.Quote(err.Error()), strconv.Quote(args.errRegex))
+			}
+		} else if args.errRegex != "" {
+			t.Errorf("StructToMap() want error, got nil, pattern = %v", args.errRegex)
+		}
+
+		if !reflect.DeepEqual(got, args.want) {
+			t.Errorf("StructToMap() = %v, want %v", got, args.want)
+		}
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		check(t, args{
+			c:        _defaultConv,
+			src:      nil,
+			want:     nil,
+			errRegex: "^conv.StructToMap: .+should not be nil",
+		})
+	})
+
+	t.Run("simple", func(t *testing.T) {
+		check(t, args{
+			c: _defaultConv,
+			src: struct {
+				Str   string
+				Flt   float64
+				inner int
+			}{"aa", 0.5, 4},
+			want: map[string]interface{}{
+				"Str": "aa",
+				"Flt": 0.5,
+			},
+			errRegex: "",
+		})
+	})
+
+	t.Run("field-map-slice-without-value", func(t *testing.T) {
+		type T struct {
+			MNil   map[string]int
+			MEmpty map[string]int
+			SNil   []struct{}
+			SEmpty []struct{}
+		}
+
+		check(t, args{
+			c: _defaultConv,
+			src: T{
+				MEmpty: map[string]int{},
+				SEmpty: []struct{}{},
+			},
+			want: map[string]interface{}{
+				"MNil":   map[string]interface{}(nil),
+				"MEmpty": map[string]interface{}{},
+				"SNil":   []map[string]interface{}(nil),
+				"SEmpty": []map[string]interface{}{},
+			},
+			errRegex: "",
+		})
+	})
+
+	t.Run("field-map-with-value", func(t *testing.T) {
+		type T struct {
+			M map[string]int
+		}
+
+		check(t, args{
+			c: _defaultConv,
+			src: T{
+				M: map[string]int{"A": 1, "B": 2},
+			},
+			want: map[string]interface{}{
+				"M": map[string]interface{}{
+					"A": 1,
+					"B": 2,
+				},
+			},
+			errRegex: "",
+		})
+	})
+
+	t.Run("field-slice-with-value", func(t *testing.T) {
+		type Inner struct {
+			A string
+			B []byte
+		}
+		type T struct{ In []Inner }
+
+		check(t, args{
+			c: _defaultConv,
+			src: T{
+				In: []Inner{
+					{"A1", []byte{1, 2}},
+					{"A2", []byte{3, 4}},
+				},
+			},
+			want: map[string]interface{}{
+				"In": []map[string]interface{}{
+					{"A": "A1", "B": []byte{1, 2}},
+					{"A": "A2", "B": []byte{3, 4}},
+				},
+			},
+			errRegex: ``,
+		})
+	})
+
+	t.Run("field-array", func(t *testing.T) {
+		type Inner struct{ A int }
+		type T struct {
+			X     [2]int
+			Empty [0]Inner
+			In    [1]Inner
+		}
+
+		check(t, args{
+			c:   _defaultConv,
+			src: T{X: [2]int{1, 2}, In: [1]Inner{{3}}},
+			want: map[string]interface{}{
+				"X":     []int{1, 2},
+				"Empty": []map[string]interface{}{},
+				"In":    []map[string]interface{}{{"A": 3}},
+			},
+			errRegex: ``,
+		})
+	})
+
+	t.Run("err-src-kind", func(t *testing.T) {
+		check(t, args{
+			c:        _defaultConv,
+			src:      1,
+			want:     nil,
+			errRegex: "must be a struct",
+		})
+	})
+
+	t.Run("err-field-not-simple", func(t *testing.T) {
+		check(t, args{
+			c:        _defaultConv,
+			src:      struct{ C chan int }{make(chan int)},
+			want:     nil,
+			errRegex: "^conv.StructToMap: error on converting field 'C': must be a simple type, got chan$",
+		})
+	})
+
+	t.Run("err-field-slice-elem-invalid", func(t *testing.T) {
+		type T struct{ V []chan int }
+
+		check(t, args{
+			c:        _defaultConv,
+			src:      T{[]chan int{}},
+			want:     nil,
+			errRegex: `cannot convert \[\]chan int`,
+		})
+	})
+
+	t.Run("err-field-map-key-invalid", func(t *testing.T) {
+		type T struct{ In map[chan int]int }
+
+		check(t, args{
+			c:        _defaultConv,
+			src:      T{map[chan int]int{make(chan int): 1}},
+			want:     nil,
+			errRegex: `field 'In': key .+?: .+cannot convert chan int to string`,
+		})
+	})
+
+	t.Run("err-field-map-value-invalid", func(t *testing.T) {
+		type T struct{ In map[int]chan int }
+
+		check(t, args{
+			c:        _defaultConv,
+			src:      T{map[int]chan int{13: make(chan int)}},
+			want:     nil,
+			errRegex: `field 'In': value of key 13: must be a simple type, got chan`,
+		})
+	})
+
+	t.Run("pointer-nil", func(t *testing.T) {
+		type T struct{ In *int }
+
+		check(t, args{
+			c:        _defaultConv,
+			src:      T{},
+			want:     map[string]interface{}{},
+			errRegex: ``,
+		})
+	})
+
+	t.Run("pointer-value", func(t *testing.T) {
+		type T struct{ In *struct{ A int } }
+
+		check(t, args{
+			c:   _defaultConv,
+			src: T{&struct{ A int }{33}},
+			want: map[string]interface{}{
+				"In": map[string]interface{}{"A": 33},
+			},
+			errRegex: ``,
+		})
+	})
+
+	t.Run("embedded-struct", func(t *testing.T) {
+		type E2 struct {
+			VV1, VV2 string
+		}
+		type E struct {
+			E2
+			V1 int
+		}
+		type T struct {
+			S string
+			E
+		}
+
+		check(t, args{
+			c: _defaultConv,
+			src: T{
+				S: "ss",
+				E: E{
+					V1: 33,
+					E2: E2{
+						VV1: "vv1",
+						VV2: "vv2",
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"S":   "ss",
+				"V1":  33,
+				"VV1": "vv1",
+				"VV2": "vv2",
+			},
+			errRegex: ``,
+		})
+	})
+
+	t.Run("embedded-struct-with-tag", func(t *testing.T) {
+		type E2 struct {
+			VV2 string `conv:"value2"`
+		}
+		type E struct {
+			E2 `conv:"ee2"`
+			V1 int `conv:"value1"`
+		}
+		type T struct {
+			E `conv:"ee"`
+		}
+
+		check(t, args{
+			c: _tagConv,
+			src: T{
+				E: E{
+					V1: 12,
+					E2: E2{
+						VV2: "vv2",
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"ee": map[string]interface{}{
+					"value1": 12,
+					"ee2": map[string]interface{}{
+						"value2": "vv2",
+					},
+				},
+			},
+			errRegex: ``,
+		})
+
+		// Tags are not processed without a tag name.
+		check(t, args{
+			c: _defaultConv,
+			src: T{
+				E: E{
+					V1: 12,
+					E2: E2{
+						VV2: "vv2",
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"V1":  12,
+				"VV2": "vv2",
+			},
+			errRegex: ``,
+		})
+	})
+}
+
+func TestConv_StructToMap_jsonSafe(t *testing.T) {
+	type Inner struct{ At time.Time }
+	type T struct {
+		Time      time.Time
+		TimePtr   *time.Time
+		Duration  time.Duration
+		Durations []time.Duration
+		C64       complex64
+		C128      complex128
+		Int       int
+		Inner     Inner
+		Times     map[string]time.Time
+	}
+
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	src := T{
+		Time:      tm,
+		TimePtr:   &tm,
+		Duration:  90 * time.Second,
+		Durations: []time.Duration{time.Millisecond, time.Hour},
+		C64:       complex(1, 2),
+		C128:      complex(1.5, -2),
+		Int:       3,
+		Inner:     Inner{tm},
+		Times:     map[string]time.Time{"k": tm},
+	}
+
+	c := &Conv{Conf: Config{JSONSafeMapValues: true}}
+	got, err := c.StructToMap(src)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	want := map[string]interface{}{
+		"Time":      "2020-01-02T03:04:05Z",
+		"TimePtr":   "2020-01-02T03:04:05Z",
+		"Duration":  "1m30s",
+		"Durations": []string{"1ms", "1h0m0s"},
+		"C64":       "(1+2i)",
+		"C128":      "(1.5-2i)",
+		"Int":       3,
+		"Inner":     map[string]interface{}{"At": "2020-01-02T03:04:05Z"},
+		"Times":     map[string]interface{}{"k": "2020-01-02T03:04:05Z"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	if _, err := json.Marshal(got); err != nil {
+		t.Errorf("cannot be encoded to JSON: %v", err)
+	}
+
+	// Disabled.
+	got, err = _defaultConv.StructToMap(src)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if _, err := json.Marshal(got); err == nil {
+		t.Errorf("complex numbers should not be encoded to JSON")
+	}
+}
+
+func TestConv_StructToMap_depth(t *testing.T) {
+	type C struct{ V int }
+	type B struct {
+		C  C
+		CC []*C
+	}
+	type A struct {
+		B   B
+		BP  *B
+		Nil *B
+		N   int
+	}
+
+	src := A{
+		B:  B{C: C{1}, CC: []*C{{2}}},
+		BP: &B{C: C{3}, CC: []*C{}},
+		N:  4,
+	}
+
+	t.Run("max-depth-1", func(t *testing.T) {
+		c := &Conv{Conf: Config{StructToMapMaxDepth: 1}}
+		got, err := c.StructToMap(src)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		want := map[string]interface{}{"B": src.B, "BP": src.BP, "N": 4}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+		if got["BP"] != src.BP {
+			t.Errorf("the pointer should be kept")
+		}
+	})
+
+	t.Run("max-depth-2", func(t *testing.T) {
+		c := &Conv{Conf: Config{StructToMapMaxDepth: 2}}
+		got, err := c.StructToMap(src)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		want := map[string]interface{}{
+			"B":  map[string]interface{}{"C": C{1}, "CC": []*C{src.B.CC[0]}},
+			"BP": map[string]interface{}{"C": C{3}, "CC": []*C{}},
+			"N":  4,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("no-limit", func(t *testing.T) {
+		v := src
+		v.BP = &B{C: C{3}, CC: []*C{{5}}}
+		got, err := _defaultConv.StructToMap(v)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		want := map[string]interface{}{
+			"B": map[string]interface{}{
+				"C":  map[string]interface{}{"V": 1},
+				"CC": []map[string]interface{}{{"V": 2}},
+			},
+			"BP": map[string]interface{}{"C": map[string]interface{}{"V": 3}, "CC": []map[string]interface{}{{"V": 5}}},
+			"N":  4,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("shallow", func(t *testing.T) {
+		type T struct {
+			A      A
+			P      *B
+			S      []int
+			Secret int `conv:",omit"`
+		}
+		c := &Conv{Conf: _tagConv.Conf}
+		c.Conf.StructToMapShallow = true
+
+		v := T{A: src, S: []int{1}}
+		got, err := c.StructToMap(v)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		want := map[string]interface{}{"A": v.A, "P": (*B)(nil), "S": v.S}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestConv_StructToStruct(t *testing.T) {
+	type args struct {
+		c        *Conv
+		src      interface{}
+		dstTyp   reflect.Type
+		want     interface{}
+		errRegex string
+	}
+	check := func(t *testing.T, args args) {
+		got, err := args.c.StructToStruct(args.src, args.dstTyp)
+
+		if err != nil {
+			if args.errRegex == "" {
+				t.Errorf("unexpected error = %v", err)
+			}
+
+			if match, _ := regexp.MatchString(args.errRegex, err.Error()); !match {
+				t.Errorf("error = %v , must match %v",
+					strconv.Quote(err.Error()), strconv.Quote(args.errRegex))
+			}
+		} else if args.errRegex != "" {
+			t.Errorf("want error, got nil, pattern = %v", args.errRegex)
+		}
+
+		if !reflect.DeepEqual(got, args.want) {
+			t.Errorf("want %v, got %v", args.want, got)
+		}
+	}
+
+	t.Run("err-nil", func(t *testing.T) {
+		check(t, args{
+			c:        _defaultConv,
+			src:      nil,
+			dstTyp:   reflect.TypeOf(struct{}{}),
+			want:     nil,
+			errRegex: "^conv.StructToStruct: the source value should not be nil$",
+		})
+	})
+
+	t.Run("err-src", func(t *testing.T) {
+		check(t, args{
+			c:        _defaultConv,
+			src:      1,
+			dstTyp:   reflect.TypeOf(struct{}{}),
+			want:     nil,
+			errRegex: "^conv.StructToStruct: the given value must be a struct, got int$",
+		})
+	})
+
+	t.Run("err-dst", func(t *testing.T) {
+		check(t, args{
+			c:        _defaultConv,
+			src:      struct{}{},
+			dstTyp:   reflect.TypeOf(1),
+			want:     nil,
+			errRegex: "^conv.StructToStruct: the destination type must be struct, got int$",
+		})
+	})
+
+	t.Run("err-field-nil", func(t *testing.T) {
+		type from struct {
+			V interface{}
+		}
+		type to struct {
+			V int
+		}
+
+		check(t, args{
+			c:        _defaultConv,
+			src:      from{},
+			dstTyp:   reflect.TypeOf(to{}),
+			want:     nil,
+			errRegex: "^conv.StructToStruct: error on converting field 'V': conv.ConvertType: cannot convert nil to int$",
+		})
+	})
+
+	t.Run("err-field-type", func(t *testing.T) {
+		type from struct {
+			V chan int
+		}
+		type to struct {
+			V int
+		}
+
+		check(t, args{
+			c:        _defaultConv,
+			src:      from{V: make(chan int)},
+			dstTyp:   reflect.TypeOf(to{}),
+			want:     nil,
+			errRegex: "^conv.StructToStruct: error on converting field 'V': conv.ConvertType: cannot convert chan int to int$",
+		})
+	})
+
+	t.Run("field-mismatch", func(t *testing.T) {
+		type from struct {
+			V chan int // Mismatched field will not cause error.
+		}
+		type to struct {
+			Other int
+		}
+
+		check(t, args{
+			c:        _defaultConv,
+			src:      from{V: make(chan int)},
+			dstTyp:   reflect.TypeOf(to{}),
+			want:     to{},
+			errRegex: "",
+		})
+	})
+
+	t.Run("clone", func(t *testing.T) {
+		type T struct {
+			Str   FromString
+			Int   int
+			Flt   float64
+			inner int
+		}
+
+		check(t, args{
+			c:        _defaultConv,
+			src:      T{Str: "gg", Int: 333, Flt: -1.23, inner: 44},
+			dstTyp:   reflect.TypeOf(T{}),
+			want:     T{Str: "gg", Int: 333, Flt: -1.23},
+			errRegex: "",
+		})
+	})
+
+	t.Run("clone-case-insensitive", func(t *testing.T) {
+		type from struct {
+			Out string
+			out string //lint:ignore U1000 Ignored.
+			Sl  []byte
+		}
+		type to struct {
+			OUt float64
+			SL  []int
+		}
+
+		check(t, args{
+			c:        _caseInsensitiveConv,
+			src:      from{Out: "-1999", Sl: []byte{3, 5, 77}},
+			dstTyp:   reflect.TypeOf(to{}),
+			want:     to{OUt: float64(-1999), SL: []int{3, 5, 77}},
+			errRegex: "",
+		})
+	})
+
+	t.Run("embedded-struct", func(t *testing.T) {
+		type EFrom struct {
+			V1 int
+		}
+		type from struct {
+			EFrom
+			V2 int
+		}
+
+		type ETo struct {
+			V2 string
+		}
+		type to struct {
+			V1 string
+			ETo
+		}
+
+		check(t, args{
+			c: _defaultConv,
+			src: from{
+				EFrom: EFrom{11},
+				V2:    22,
+			},
+			dstTyp: reflect.TypeOf(to{}),
+			want: to{
+				V1:  "11",
+				ETo: ETo{"22"},
+			},
+			errRegex: "",
+		})
+	})
+
+	t.Run("embedded-struct-with-tag", func(t *testing.T) {
+		type Ef struct {
+			V1 int
+		}
+		type from struct {
+			Ef `conv:"F"`
+		}
+
+		type Et struct {
+			V int `conv:"V1"`
+		}
+		type to struct {
+			F Et
+		}
+
+		check(t, args{
+			c: _tagConv,
+			src: from{
+				Ef: Ef{V1: 33},
+			},
+			dstTyp:   reflect.TypeOf(to{}),
+			want:     to{F: Et{V: 33}},
+			errRegex: "",
+		})
+	})
+}
+
+func TestConv_taggedEmbeddedRoundTrip(t *testing.T) {
+	type Inner struct {
+		V int `conv:"v"`
+	}
+	type Squashed struct {
+		S string `conv:"s"`
+	}
+	type T struct {
+		Inner     `conv:"inner"`
+		*Squashed `conv:",squash"`
+		N         int `conv:"n"`
+	}
+	type Flat struct {
+		Inner struct {
+			V int `conv:"v"`
+		} `conv:"inner"`
+		S string `conv:"s"`
+		N int    `conv:"n"`
+	}
+
+	src := T{Inner{1}, &Squashed{"x"}, 2}
+	wantMap := map[string]interface{}{
+		"inner": map[string]interface{}{"v": 1},
+		"s":     "x",
+		"n":     2,
+	}
+
+	m, err := _tagConv.StructToMap(src)
+	if err != nil {
+		t.Fatalf("StructToMap: %s", err)
+	}
+	if !reflect.DeepEqual(m, wantMap) {
+		t.Fatalf("StructToMap: want %v, got %v", wantMap, m)
+	}
+
+	back, err := _tagConv.MapToStruct(m, reflect.TypeOf(src))
+	if err != nil {
+		t.Fatalf("MapToStruct: %s", err)
+	}
+	if !reflect.DeepEqual(back, src) {
+		t.Fatalf("MapToStruct: want %+v, got %+v", src, back)
+	}
+
+	// The tagged embedded struct is a named field on the source side, in both directions.
+	flat, err := _tagConv.StructToStruct(src, reflect.TypeOf(Flat{}))
+	if err != nil {
+		t.Fatalf("StructToStruct: %s", err)
+	}
+	if f := flat.(Flat); f.Inner.V != 1 || f.S != "x" || f.N != 2 {
+		t.Fatalf("StructToStruct: got %+v", f)
+	}
+
+	back, err = _tagConv.StructToStruct(flat, reflect.TypeOf(src))
+	if err != nil {
+		t.Fatalf("StructToStruct: %s", err)
+	}
+	if !reflect.DeepEqual(back, src) {
+		t.Fatalf("StructToStruct: want %+v, got %+v", src, back)
+	}
+}
+
+func TestConv_StructToStruct_collectAllFieldErrors(t *testing.T) {
+	type Inner struct{ N string }
+	type From struct {
+		A     string
+		B     int
+		Inner Inner
+		C     []int
+	}
+	type To struct {
+		A     int
+		B     int
+		Inner struct{ N int }
+		C     string
+	}
+
+	src := From{A: "x", B: 1, Inner: Inner{N: "y"}, C: []int{1}}
+	c := &Conv{Conf: Config{CollectAllFieldErrors: true}}
+	_, err := c.StructToStruct(src, reflect.TypeOf(To{}))
+
+	var me *MultiError
+	if !errors.As(err, &me) {
+		t.Fatalf("want *MultiError, got %v", err)
+	}
+
+	ces := me.ConvErrors()
+	want := []struct {
+		path    string
+		srcType reflect.Type
+		dstType reflect.Type
+	}{
+		{"A", reflect.TypeOf(""), reflect.TypeOf(0)},
+		{"C", reflect.TypeOf([]int{}), reflect.TypeOf("")},
+		{"Inner.N", reflect.TypeOf(""), reflect.TypeOf(0)},
+	}
+	if len(ces) != len(want) {
+		t.Fatalf("want %v errors, got %v", len(want), ces)
+	}
+	for i, w := range want {
+		ce := ces[i]
+		if ce.Path != w.path || ce.SrcType != w.srcType || ce.DstType != w.dstType {
+			t.Errorf("errors[%v]: want %v %v %v, got %v %v %v", i, w.path, w.srcType, w.dstType, ce.Path, ce.SrcType, ce.DstType)
+		}
+	}
+
+	if !strings.HasPrefix(err.Error(), "conv.StructToStruct: error on converting field 'A'") {
+		t.Errorf("unexpected message: %v", err)
+	}
+
+	// Fail-fast by default.
+	_, err = _defaultConv.StructToStruct(src, reflect.TypeOf(To{}))
+	if err == nil || errors.As(err, &me) {
+		t.Errorf("want a single error, got %v", err)
+	}
+}
+
+func TestConv_ConvertType_convertPointers(t *testing.T) {
+	i := 1
+	pi := &i
+	ppi := &pi
+	pppi := &ppi
+
+	s := "3"
+	ps := &s
+	pps := &ps
+
+	type args struct {
+		src    interface{}
+		dstTyp reflect.Type
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantVal   interface{}
+		wantDepth int
+		wantErr   bool
+	}{
+		{"i-i", args{i, reflect.TypeOf(i)}, i, 0, false},
+		{"pi-i", args{pi, reflect.TypeOf(i)}, i, 0, false},
+		{"ppi-i", args{ppi, reflect.TypeOf(i)}, i, 0, false},
+		{"pppi-i", args{pppi, reflect.TypeOf(i)}, i, 0, false},
+
+		{"i-pi", args{i, reflect.TypeOf(pi)}, i, 1, false},
+		{"i-ppi", args{i, reflect.TypeOf(ppi)}, i, 2, false},
+		{"i-pppi", args{i, reflect.TypeOf(pppi)}, i, 3, false},
+
+		{"pi-pi", args{pi, reflect.TypeOf(pi)}, i, 1, false},
+		{"ppi-ppi", args{ppi, reflect.TypeOf(ppi)}, i, 2, false},
+		{"pppi-pppi", args{pppi, reflect.TypeOf(pppi)}, i, 3, false},
+
+		{"ppi-pi", args{pi, reflect.TypeOf(pi)}, i, 1, false},
+		{"pppi-pi", args{pppi, reflect.TypeOf(pi)}, i, 1, false},
+		{"pi-ppi", args{ppi, reflect.TypeOf(ppi)}, i, 2, false},
+
+		{"s-s", args{s, reflect.TypeOf(s)}, s, 0, false},
+		{"ps-s", args{ps, reflect.TypeOf(s)}, s, 0, false},
+		{"s-ps", args{s, reflect.TypeOf(ps)}, s, 1, false},
+
+		{"pi-s", args{pi, reflect.TypeOf(s)}, "1", 0, false},
+		{"ppi-ps", args{ppi, reflect.TypeOf(ps)}, "1", 1, false},
+		{"pi-pps", args{pi, reflect.TypeOf(pps)}, "1", 2, false},
+
+		{"ps-i", args{ps, reflect.TypeOf(i)}, 3, 0, false},
+		{"pps-ppi", args{pps, reflect.TypeOf(ppi)}, 3, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := _defaultConv.ConvertType(tt.args.src, tt.args.dstTyp)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ConvertType() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			var valGot interface{}
+			var valDepth int
+			switch v := got.(type) {
+			case int:
+				valGot, valDepth = v, 0
+			case *int:
+				valGot, valDepth = *v, 1
+			case **int:
+				valGot, valDepth = **v, 2
+			case ***int:
+				valGot, valDepth = ***v, 3
+			case string:
+				valGot, valDepth = v, 0
+			case *string:
+				valGot, valDepth = *v, 1
+			case **string:
+				valGot, valDepth = **v, 2
+			case ***string:
+				valGot, valDepth = ***v, 3
+			}
+
+			if valGot != tt.wantVal || valDepth != tt.wantDepth {
+				t.Errorf("ConvertType() = val %v, depth %v, want val %v depth %v", valGot, valDepth, tt.wantVal, tt.wantDepth)
+			}
+		})
+	}
+}
+
+func TestConv_ConvertType_mapToStructWithPointers(t *testing.T) {
+	type T struct {
+		S FromString
+		I int
+		F float64
+	}
+	type P struct {
+		S1    *T
+		inner *int
+		Out   *FromString
+		m     *map[string]int
+		Sl    *[]byte
+	}
+
+	fieldS1 := T{S: "23", I: 33, F: 44}
+	fieldOut := FromString("3.14")
+	p2 := P{S1: &fieldS1, Out: &fieldOut}
+	pp2 := &p2
+	in := map[string]interface{}{
+		"S1":    map[string]interface{}{"S": 23, "I": 33, "F": 44, "inner": 55},
+		"Out":   3.14,
+		"Sl":    nil,
+		"inner": -1,
+	}
+	res, err := _defaultConv.ConvertType(in, reflect.TypeOf(pp2))
+	if err != nil {
+		t.Errorf("ConvertType: %s", err)
+		return
+	}
+
+	// reflect.DeepEqual() doesn't compare the underlying values of pointers.
+	// We compare the fields manually.
+	out := *res.(*P)
+	if out.inner != nil {
+		t.Error("inner != nil")
+		return
+	}
+
+	if out.m != nil {
+		t.Error("m != nil")
+		return
+	}
+
+	if out.Sl != nil {
+		t.Error("Sl != nil")
+		return
+	}
+
+	if out.Out == nil || *out.Out != "3.14" {
+		t.Error("Out != 3.14")
+		return
+	}
+
+	if out.S1 == nil {
+		t.Error("S1 == nil")
+		return
+	}
+
+	if !reflect.DeepEqual(*out.S1, fieldS1) {
+		t.Errorf("S1: got %#v, want %#v", *out.S1, fieldS1)
+		return
+	}
+}
+
+func TestConv_ConvertType_sliceToSlice(t *testing.T) {
+	type s struct {
+		S     string
+		I     int64
+		F     float32
+		inner int
+	}
+	type sPtr struct {
+		S     *string
+		I     **int64
+		F     *float32
+		inner *int
+	}
+
+	in := []*s{
+		{S: "1", I: 3, inner: 5},
+		{S: "2", F: 4},
+	}
+
+	dstTyp := reflect.TypeOf([]*sPtr{})
+	out, err := _defaultConv.ConvertType(in, dstTyp)
+	if err != nil {
+		t.Errorf("err: %s", err.Error())
+		return
+	}
+
+	ss, ok := out.([]*sPtr)
+	if !ok {
+		t.Errorf("wrong type: %v", reflect.TypeOf(out))
+		return
+	}
+
+	if len(ss) != len(in) {
+		t.Errorf("wrong length, got %v, want %v", len(ss), len(in))
+		return
+	}
+
+	check := func(idx int, s string, i int64, f float32) {
+		e := ss[idx]
+		if e == nil {
+			t.Errorf("nil at index %v", idx)
+			return
+		}
+
+		if e.S == nil || *e.S != s {
+			t.Errorf("unexpected value at index %v field S", idx)
+			return
+		}
+
+		if e.I == nil || **e.I != i {
+			t.Errorf("unexpected value at index %v field I", idx)
+			return
+		}
+
+		if e.F == nil || *e.F != f {
+			t.Errorf("unexpected value at index %v field F", idx)
+			return
+		}
+
+		if e.inner != nil {
+			t.Errorf("unexpected value at index %v field inner", idx)
+			return
+		}
+	}
+
+	check(0, "1", 3, 0)
+	check(1, "2", 0, 4)
+}
+
+func TestConv_ConvertType_flatMap(t *testing.T) {
+
+	t.Run("simple", func(t *testing.T) {
+		src := map[string]interface{}{
+			"": 87654321,
+		}
+		got, err := _defaultConv.ConvertType(src, reflect.TypeOf(0))
+
+		if err != nil {
+			t.Fatalf("got error: %v", err)
+		}
+
+		if got != 87654321 {
+			t.Errorf("want %v, got %v", 87654321, got)
+		}
+	})
+
+	t.Run("map", func(t *testing.T) {
+		type T struct {
+			S FromString
+			I int
+			F float64
+		}
+
+		i := 1999
+		var pf *float32
+
+		src := map[string]interface{}{
+			"": map[interface{}]interface{}{
+				struct{ I *int }{&i}: nil,
+				struct{}{}:           pf,
+				struct{ S int }{123}: &pf,
+			},
+		}
+		got, err := _defaultConv.ConvertType(src, reflect.TypeOf(map[T][]int{}))
+
+		if err != nil {
+			t.Fatalf("got error: %v", err)
+		}
+
+		want := map[T][]int{
+			{I: 1999}:  nil,
+			{}:         nil,
+			{S: "123"}: nil,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", 87654321, got)
+		}
+	})
+}
+
+// stringerPoint implements fmt.Stringer in the form '(x, y)'.
+type stringerPoint struct{ X, Y int }
+
+func (p stringerPoint) String() string { return fmt.Sprintf("(%d, %d)", p.X, p.Y) }
+
+// textPoint implements encoding.TextMarshaler and encoding.TextUnmarshaler in the form 'x,y'.
+type textPoint struct{ X, Y int }
+
+func (p textPoint) MarshalText() ([]byte, error) {
+	if p.X < 0 {
+		return nil, errors.New("negative")
+	}
+	return []byte(fmt.Sprintf("%d,%d", p.X, p.Y)), nil
+}
+
+func (p *textPoint) UnmarshalText(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+func TestConv_ConvertType_textMethods(t *testing.T) {
+	t.Run("unmarshal", func(t *testing.T) {
+		got, err := _defaultConv.ConvertType("1,2", reflect.TypeOf(textPoint{}))
+		if err != nil || got != (textPoint{1, 2}) {
+			t.Errorf("want {1 2}, got %v, %v", got, err)
+		}
+
+		got, err = _defaultConv.ConvertType("127.0.0.1", reflect.TypeOf(net.IP{}))
+		if err != nil || !net.IPv4(127, 0, 0, 1).Equal(got.(net.IP)) {
+			t.Errorf("want 127.0.0.1, got %v, %v", got, err)
+		}
+
+		_, err = _defaultConv.ConvertType("x", reflect.TypeOf(textPoint{}))
+		if err == nil || !strings.Contains(err.Error(), "error on UnmarshalText of conv.textPoint") {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		type T struct {
+			P  textPoint
+			PP *textPoint
+			IP net.IP
+		}
+		got, err = _defaultConv.MapToStruct(map[string]interface{}{"P": "3,4", "PP": "5,6", "IP": "::1"}, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		want := T{P: textPoint{3, 4}, PP: &textPoint{5, 6}, IP: net.ParseIP("::1")}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("marshal", func(t *testing.T) {
+		got, err := _defaultConv.ConvertType(textPoint{1, 2}, reflect.TypeOf(""))
+		if err != nil || got != "1,2" {
+			t.Errorf("want 1,2, got %v, %v", got, err)
+		}
+
+		got, err = _defaultConv.ConvertType(&textPoint{3, 4}, reflect.TypeOf(""))
+		if err != nil || got != "3,4" {
+			t.Errorf("want 3,4, got %v, %v", got, err)
+		}
+
+		got, err = _defaultConv.ConvertType(net.IPv4(10, 0, 0, 1), reflect.TypeOf(""))
+		if err != nil || got != "10.0.0.1" {
+			t.Errorf("want 10.0.0.1, got %v, %v", got, err)
+		}
+
+		_, err = _defaultConv.ConvertType(textPoint{-1, 0}, reflect.TypeOf(""))
+		if err == nil || !strings.Contains(err.Error(), "error on MarshalText of conv.textPoint: negative") {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		s, err := _defaultConv.SimpleToString(net.IP{})
+		if err != nil || s != "" {
+			t.Errorf("want empty string, got %v, %v", s, err)
+		}
+	})
+
+	t.Run("time", func(t *testing.T) {
+		// time.Time implements the text methods, but it is handled by Conv.
+		c := &Conv{Conf: Config{TimeToString: func(t time.Time) (string, error) { return "time", nil }}}
+		got, err := c.ConvertType(time.Unix(0, 0), reflect.TypeOf(""))
+		if err != nil || got != "time" {
+			t.Errorf("want time, got %v, %v", got, err)
+		}
+
+		got, err = _defaultConv.ConvertType("1622726482", reflect.TypeOf(time.Time{}))
+		if err != nil || got.(time.Time).Unix() != 1622726482 {
+			t.Errorf("unexpected result: %v, %v", got, err)
+		}
+	})
+}
+
+func TestConv_ConvertType_sqlNull(t *testing.T) {
+	tm := time.Date(2021, 6, 3, 13, 21, 22, 0, time.UTC)
+
+	t.Run("from-null", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			src    interface{}
+			dstTyp reflect.Type
+			want   interface{}
+		}{
+			{"string", sql.NullString{String: "a", Valid: true}, reflect.TypeOf(""), "a"},
+			{"string-int", sql.NullString{String: "12", Valid: true}, reflect.TypeOf(0), 12},
+			{"int64-string", sql.NullInt64{Int64: 3, Valid: true}, reflect.TypeOf(""), "3"},
+			{"bool", &sql.NullBool{Bool: true, Valid: true}, reflect.TypeOf(false), true},
+			{"time", sql.NullTime{Time: tm, Valid: true}, reflect.TypeOf(time.Time{}), tm},
+			{"invalid", sql.NullString{String: "a"}, reflect.TypeOf(""), ""},
+			{"invalid-int", sql.NullInt64{Int64: 3}, reflect.TypeOf(0), 0},
+			{"invalid-ptr", sql.NullString{}, reflect.TypeOf((*string)(nil)), (*string)(nil)},
+			{"interface", sql.NullInt32{Int32: 1, Valid: true}, reflect.TypeOf((*interface{})(nil)).Elem(), sql.NullInt32{Int32: 1, Valid: true}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := _defaultConv.ConvertType(tt.src, tt.dstTyp)
+				if err != nil {
+					t.Fatalf("got error %s", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("want %#v, got %#v", tt.want, got)
+				}
+			})
+		}
+	})
+
+	t.Run("to-null", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			src    interface{}
+			dstTyp reflect.Type
+			want   interface{}
+		}{
+			{"string", "a", reflect.TypeOf(sql.NullString{}), sql.NullString{String: "a", Valid: true}},
+			{"int-string", 12, reflect.TypeOf(sql.NullString{}), sql.NullString{String: "12", Valid: true}},
+			{"string-int", "12", reflect.TypeOf(sql.NullInt64{}), sql.NullInt64{Int64: 12, Valid: true}},
+			{"uint", uint8(7), reflect.TypeOf(sql.NullInt32{}), sql.NullInt32{Int32: 7, Valid: true}},
+			{"float", float32(1.5), reflect.TypeOf(sql.NullFloat64{}), sql.NullFloat64{Float64: 1.5, Valid: true}},
+			{"time", tm, reflect.TypeOf(sql.NullTime{}), sql.NullTime{Time: tm, Valid: true}},
+			{"nil", nil, reflect.TypeOf(sql.NullString{}), sql.NullString{}},
+			{"null-null", sql.NullInt64{Int64: 5, Valid: true}, reflect.TypeOf(sql.NullString{}), sql.NullString{String: "5", Valid: true}},
+			{"ptr", "b", reflect.TypeOf(&sql.NullString{}), &sql.NullString{String: "b", Valid: true}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := _defaultConv.ConvertType(tt.src, tt.dstTyp)
+				if err != nil {
+					t.Fatalf("got error %s", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("want %#v, got %#v", tt.want, got)
+				}
+			})
+		}
+
+		_, err := _defaultConv.ConvertType("x", reflect.TypeOf(sql.NullInt64{}))
+		if err == nil || !strings.Contains(err.Error(), "error on calling Scan() of sql.NullInt64") {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		_, err = _defaultConv.ConvertType([]int{1}, reflect.TypeOf(sql.NullInt64{}))
+		if err == nil || !strings.Contains(err.Error(), "cannot convert []int to sql.NullInt64") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		type T struct {
+			Name  sql.NullString
+			Age   sql.NullInt64
+			Score *sql.NullFloat64
+		}
+
+		got, err := _defaultConv.MapToStruct(map[string]interface{}{"Name": "n", "Age": nil, "Score": "1.5"}, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		want := T{Name: sql.NullString{String: "n", Valid: true}, Score: &sql.NullFloat64{Float64: 1.5, Valid: true}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+
+		// Invalid values are omitted in maps, like nil pointers.
+		m, err := _defaultConv.StructToMap(want)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if wantMap := map[string]interface{}{"Name": "n", "Score": 1.5}; !reflect.DeepEqual(m, wantMap) {
+			t.Errorf("want %v, got %v", wantMap, m)
+		}
+
+		// Cloning keeps the values.
+		clone, err := _defaultConv.ConvertType(want, reflect.TypeOf(T{}))
+		if err != nil || !reflect.DeepEqual(clone, want) {
+			t.Errorf("want %v, got %v, %v", want, clone, err)
+		}
+	})
+}
+
+func TestConv_ConvertType_byteSliceEncoding(t *testing.T) {
+	type Bytes []byte
+
+	tests := []struct {
+		encoding string
+		str      string
+		bytes    []byte
+	}{
+		{"raw", "233", []byte("233")},
+		{"base64", "AQI=", []byte{1, 2}},
+		{"hex", "0102", []byte{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			c := &Conv{Conf: Config{ByteSliceEncoding: tt.encoding}}
+
+			got, err := c.ConvertType(tt.str, reflect.TypeOf(Bytes{}))
+			if err != nil || !reflect.DeepEqual(got, Bytes(tt.bytes)) {
+				t.Errorf("want %v, got %v, %v", tt.bytes, got, err)
+			}
+
+			got, err = c.ConvertType(tt.bytes, reflect.TypeOf(""))
+			if err != nil || got != tt.str {
+				t.Errorf("want %v, got %v, %v", tt.str, got, err)
+			}
+
+			m, err := c.StructToMap(struct{ Data []byte }{tt.bytes})
+			if want := map[string]interface{}{"Data": tt.str}; err != nil || !reflect.DeepEqual(m, want) {
+				t.Errorf("want %v, got %v, %v", want, m, err)
+			}
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		got, err := _defaultConv.ConvertType("233", reflect.TypeOf([]byte{}))
+		if err != nil || !reflect.DeepEqual(got, []byte{233}) {
+			t.Errorf("unexpected result %v, %v", got, err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		c := &Conv{Conf: Config{ByteSliceEncoding: "base64"}}
+		_, err := c.ConvertType("!", reflect.TypeOf([]byte{}))
+		if err == nil || !strings.Contains(err.Error(), "error on decoding base64") {
+			t.Errorf("unexpected error %v", err)
+		}
+
+		c = &Conv{Conf: Config{ByteSliceEncoding: "x"}}
+		_, err = c.ConvertType([]byte{1}, reflect.TypeOf(""))
+		if err == nil || !strings.Contains(err.Error(), "unknown encoding 'x'") {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	// The encoding tag takes precedence.
+	t.Run("tag", func(t *testing.T) {
+		type T struct {
+			A []byte `encoding:"hex"`
+			B []byte
+		}
+		c := &Conv{Conf: Config{ByteSliceEncoding: "raw", EncodingTag: "encoding"}}
+		got, err := c.MapToStruct(map[string]interface{}{"A": "0102", "B": "0102"}, reflect.TypeOf(T{}))
+		if want := (T{[]byte{1, 2}, []byte("0102")}); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v, %v", want, got, err)
+		}
+	})
+}
+
+func TestConv_ConvertType_structToTypedMap(t *testing.T) {
+	type Key string
+	type T struct {
+		Name  string
+		Count int
+		At    time.Time `conv:"at,layout=2006-01-02"`
+		Ptr   *int
+	}
+
+	c := &Conv{Conf: _tagConv.Conf}
+	src := T{Name: "n", Count: 3, At: time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)}
+
+	got, err := c.ConvertType(src, reflect.TypeOf(map[Key]string{}))
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if want := map[Key]string{"Name": "n", "Count": "3", "at": "2022-03-04"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// Works for nested values.
+	type U struct {
+		Scores map[string]int
+		Inner  T
+	}
+	got, err = c.ConvertType(U{Inner: src}, reflect.TypeOf(map[string]map[string]interface{}{}))
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	inner := got.(map[string]map[string]interface{})["Inner"]
+	if inner["at"] != "2022-03-04" || inner["Count"] != 3 {
+		t.Errorf("unexpected result %v", got)
+	}
+
+	_, err = c.ConvertType(struct{ Name string }{"n"}, reflect.TypeOf(map[string]int{}))
+	if err == nil || !strings.Contains(err.Error(), "cannot covert value of key 'Name' to int") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+type shape interface{ Area() float64 }
+
+type circle struct{ R float64 }
+
+func (c *circle) Area() float64 { return 3 * c.R * c.R }
+
+func TestConv_ConvertType_interfaceImplementations(t *testing.T) {
+	typShape := reflect.TypeOf((*shape)(nil)).Elem()
+	c := &Conv{Conf: Config{
+		InterfaceImplementations: map[reflect.Type]reflect.Type{typShape: reflect.TypeOf(&circle{})},
+	}}
+
+	got, err := c.ConvertType(map[string]interface{}{"R": "2"}, typShape)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if want := (&circle{R: 2}); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// Values implementing the interface are returned directly.
+	src := &circle{R: 1}
+	if got, _ := c.ConvertType(src, typShape); got != src {
+		t.Errorf("want %p, got %p", src, got)
+	}
+
+	// Works for struct fields and slice elements.
+	type T struct {
+		S  shape
+		SS []shape
+	}
+	got, err = c.MapToStruct(map[string]interface{}{"S": map[string]interface{}{"R": 1}, "SS": []interface{}{map[string]interface{}{"R": 3}}}, reflect.TypeOf(T{}))
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if want := (T{&circle{R: 1}, []shape{&circle{R: 3}}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// Unregistered interfaces fail as before.
+	if _, err := _defaultConv.ConvertType(map[string]interface{}{"R": 1}, typShape); err == nil {
+		t.Errorf("want error")
+	}
+
+	c.Conf.InterfaceImplementations[typShape] = reflect.TypeOf(circle{})
+	_, err = c.ConvertType(map[string]interface{}{"R": 1}, typShape)
+	if want := "conv.ConvertType: the type conv.circle does not implement conv.shape"; err == nil || err.Error() != want {
+		t.Errorf("want %v, got %v", want, err)
+	}
+}
+
+func TestConv_ConvertType(t *testing.T) {
+	now := time.Now()
+
+	type args struct {
+		src    interface{}
+		dstTyp reflect.Type
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     interface{}
+		errRegex string
+	}{
+		// simple to simple
+		{"string-int", args{"-387656", reflect.TypeOf(0)}, -387656, ""},
+		{"time-uint", args{now, reflect.TypeOf(uint(0))}, uint(now.Unix()), ""},
+
+		// string to slice
+		{"string-[]byte", args{"233", reflect.TypeOf([]byte{})}, []byte{233}, ""},
+
+		// struct to map
+		{
+			"struct-map",
+			args{
+				struct{ A, B interface{} }{},
+				reflect.TypeOf(map[string]interface{}{}),
+			},
+			map[string]interface{}{},
+			"",
+		},
+		{
+			"struct-typed-map",
+			args{
+				struct {
+					A int
+					B string
+				}{1, "2"},
+				reflect.TypeOf(map[string]int{}),
+			},
+			map[string]int{"A": 1, "B": 2},
+			"",
+		},
+		{
+			"err-struct-wrong-map",
+			args{
+				struct{ A int }{1},
+				reflect.TypeOf(map[int]interface{}{}),
+			},
+			nil,
+			`^conv.ConvertType: conv.MapToMap: cannot covert key 'A' to int: .+invalid syntax$`,
+		},
+
+		// map to struct
+		{
+			"err-wrong-map-key",
+			args{
+				map[[1]int]interface{}{{1}: 1},
+				reflect.TypeOf(struct{}{}),
+			},
+			nil,
+			`^conv.ConvertType: .+the keys must be convertible to strings: .+`,
+		},
+
+		// to empty interface
+		{
+			"empty-interface",
+			args{
+				[]int{1, 2, 3},
+				typEmptyInterface,
+			},
+			[]int{1, 2, 3},
+			"",
+		},
+		{
+			"interface",
+			args{
+				errors.New("e"),
+				reflect.TypeOf((*error)(nil)).Elem(),
+			},
+			errors.New("e"),
+			"",
+		},
+		{
+			"interface-nil",
+			args{
+				nil,
+				reflect.TypeOf((*error)(nil)).Elem(),
+			},
+			nil,
+			"",
+		},
+		{
+			"err-interface-not-implemented",
+			args{
+				1,
+				reflect.TypeOf((*error)(nil)).Elem(),
+			},
+			nil,
+			`^conv.ConvertType: cannot convert int to error$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := _defaultConv.ConvertType(tt.args.src, tt.args.dstTyp)
+
+			if err != nil {
+				if tt.errRegex == "" {
+					t.Errorf("ConvertType() unexpected error = %v", err)
+				}
+
+				if match, _ := regexp.MatchString(tt.errRegex, err.Error()); !match {
+					t.Errorf("ConvertType() error = %v , must match %v",
+						strconv.Quote(err.Error()), strconv.Quote(tt.errRegex))
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConvertType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConv_Convert_panic(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		defer func() {
+			var err interface{}
+			if err = recover(); err == nil {
+				t.Fatalf("should panic an error")
+			}
+
+			const wantMsg = "conv.Convert: the destination value must be a pointer"
+			if err.(error).Error() != wantMsg {
+				t.Fatalf("should panic an error with message: '%v', got '%v'", wantMsg, err)
+			}
+		}()
+
+		_defaultConv.Convert(nil, 0)
+	})
+
+	t.Run("uninitialized", func(t *testing.T) {
+		defer func() {
+			var err interface{}
+			if err = recover(); err == nil {
+				t.Fatalf("should panic an error")
+			}
+
+			const wantMsg = "conv.Convert: the pointer must be initialized"
+			if err.(error).Error() != wantMsg {
+				t.Fatalf("should panic an error with message: '%v', got '%v'", wantMsg, err)
+			}
+		}()
+
+		var p *int
+		_defaultConv.Convert("", p)
+	})
+}
+
+func TestConv_Convert_ptr(t *testing.T) {
+	i := 1
+	pi := &i
+	ppi := &pi
+
+	t.Run("nil", func(t *testing.T) {
+		_defaultConv.Convert(nil, pi)
+		if *pi != 1 {
+			t.Errorf("want %v, got %v", i, *pi)
+		}
+	})
+
+	t.Run("string-p-int", func(t *testing.T) {
+		_defaultConv.Convert("-54321", pi)
+		if *pi != -54321 {
+			t.Errorf("want %v, got %v", i, *pi)
+		}
+	})
+
+	t.Run("string-pp-int", func(t *testing.T) {
+		_defaultConv.Convert("12345", ppi)
+		if **ppi != 12345 {
+			t.Errorf("want %v, got %v", i, *pi)
+		}
+	})
+
+	t.Run("nil-ptr", func(t *testing.T) {
+		var p *int
+		if err := _defaultConv.Convert("123", &p); err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if p == nil || *p != 123 {
+			t.Errorf("want 123, got %v", p)
+		}
+	})
+
+	t.Run("nil-pp-struct", func(t *testing.T) {
+		type T struct{ A int }
+		var p **T
+		if err := _defaultConv.Convert(map[string]interface{}{"A": 1}, &p); err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if p == nil || *p == nil || (*p).A != 1 {
+			t.Errorf("want A=1, got %v", p)
+		}
+	})
+
+	t.Run("nil-ptr-error", func(t *testing.T) {
+		var p *int
+		if err := _defaultConv.Convert("x", &p); err == nil {
+			t.Fatal("want error")
+		}
+		if p != nil {
+			t.Errorf("want nil, got %v", *p)
+		}
+	})
+}
+
+func TestConv_ConvertValue(t *testing.T) {
+	typInt := reflect.TypeOf(0)
+	typPtrInt := reflect.TypeOf((*int)(nil))
+	typError := reflect.TypeOf((*error)(nil)).Elem()
+
+	tests := []struct {
+		name     string
+		src      reflect.Value
+		dstTyp   reflect.Type
+		want     interface{}
+		errRegex string
+	}{
+		{"string-int", reflect.ValueOf("12"), typInt, 12, ""},
+		{"string-ptr-int", reflect.ValueOf("12"), typPtrInt, 12, ""},
+		{"invalid-ptr", reflect.Value{}, typPtrInt, (*int)(nil), ""},
+		{"invalid-interface", reflect.Value{}, typError, nil, ""},
+		{"interface-elem", reflect.ValueOf([]interface{}{"3"}).Index(0), typInt, 3, ""},
+		{"slice", reflect.ValueOf([]string{"1", "2"}), reflect.TypeOf([]int{}), []int{1, 2}, ""},
+		{"err", reflect.ValueOf("x"), typInt, nil, `^conv.ConvertValue: conv.SimpleToSimple: strconv.ParseInt`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := _defaultConv.ConvertValue(tt.src, tt.dstTyp)
+			if tt.errRegex != "" {
+				if err == nil {
+					t.Fatalf("want error, got %v", got)
+				}
+				if match, _ := regexp.MatchString(tt.errRegex, err.Error()); !match {
+					t.Fatalf("error = %v , must match %v", strconv.Quote(err.Error()), strconv.Quote(tt.errRegex))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("got error %s", err)
+			}
+			if !got.IsValid() || !got.Type().AssignableTo(tt.dstTyp) {
+				t.Fatalf("want a value of %v, got %v", tt.dstTyp, got)
+			}
+
+			gotValue := got.Interface()
+			if got.Kind() == reflect.Ptr && !got.IsNil() {
+				gotValue = got.Elem().Interface()
+			}
+			if !reflect.DeepEqual(gotValue, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, gotValue)
+			}
+		})
+	}
+}
+
+func TestConv_withLayoutTag(t *testing.T) {
+	type T struct {
+		Date     time.Time  `layout:"2006-01-02"`
+		DateTime *time.Time `layout:"2006-01-02 15:04:05"`
+		Default  time.Time
+	}
+
+	c := &Conv{Conf: Config{LayoutTag: "layout"}}
+	date := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	dateTime := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	def := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	m := map[string]interface{}{
+		"Date":     "2020-01-02",
+		"DateTime": "2020-01-02 15:04:05",
+		"Default":  "2021-03-04T05:06:07Z",
+	}
+
+	t.Run("MapToStruct", func(t *testing.T) {
+		got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want := T{Date: date, DateTime: &dateTime, Default: def}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("StructToMap", func(t *testing.T) {
+		got, err := c.StructToMap(T{Date: date, DateTime: &dateTime, Default: def})
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want := map[string]interface{}{
+			"Date":     "2020-01-02",
+			"DateTime": "2020-01-02 15:04:05",
+			"Default":  def, // No layout, kept as it is.
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := c.MapToStruct(map[string]interface{}{"Date": "2020/01/02"}, reflect.TypeOf(T{}))
+		if err == nil || !strings.Contains(err.Error(), "error on converting field 'Date'") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("default-location", func(t *testing.T) {
+		type L struct {
+			Naive    time.Time  `layout:"2006-01-02 15:04:05"`
+			NaivePtr *time.Time `layout:"2006-01-02 15:04:05"`
+			Zoned    time.Time  `layout:"2006-01-02 15:04:05 -0700"`
+			Tagged   time.Time  `layout:"2006-01-02 15:04:05" tz:"America/New_York"`
+		}
+
+		loc, err := time.LoadLocation("Asia/Shanghai")
+		if err != nil {
+			t.Fatal(err)
+		}
+		newYork, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := &Conv{Conf: Config{LayoutTag: "layout", TimeZoneTag: "tz", DefaultLocation: loc}}
+		m := map[string]interface{}{
+			"Naive":    "2020-01-02 15:04:05",
+			"NaivePtr": "2020-01-02 15:04:05",
+			"Zoned":    "2020-01-02 15:04:05 +0100",
+			"Tagged":   "2020-01-02 15:04:05",
+		}
+		got, err := c.MapToStruct(m, reflect.TypeOf(L{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		v := got.(L)
+		naive := time.Date(2020, 1, 2, 15, 4, 5, 0, loc)
+		if !v.Naive.Equal(naive) || v.Naive.Location() != loc {
+			t.Errorf("want %v, got %v", naive, v.Naive)
+		}
+		if !v.NaivePtr.Equal(naive) {
+			t.Errorf("want %v, got %v", naive, v.NaivePtr)
+		}
+		if want := time.Date(2020, 1, 2, 14, 4, 5, 0, time.UTC); !v.Zoned.Equal(want) {
+			t.Errorf("want %v, got %v", want, v.Zoned)
+		}
+		if want := time.Date(2020, 1, 2, 15, 4, 5, 0, newYork); !v.Tagged.Equal(want) {
+			t.Errorf("want %v, got %v", want, v.Tagged)
+		}
+	})
+}
+
+func TestConv_withTimeZoneTag(t *testing.T) {
+	type T struct {
+		Shanghai time.Time  `tz:"Asia/Shanghai" layout:"2006-01-02 15:04:05"`
+		NewYork  *time.Time `tz:"America/New_York"`
+		UTC      time.Time  `tz:"UTC"`
+	}
+
+	c := &Conv{Conf: Config{LayoutTag: "layout", TimeZoneTag: "tz"}}
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	tm := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	m := map[string]interface{}{
+		"Shanghai": "2020-01-02 08:00:00",
+		"NewYork":  "2020-01-01T19:00:00-05:00",
+		"UTC":      "2020-01-02T08:00:00+08:00",
+	}
+
+	t.Run("MapToStruct", func(t *testing.T) {
+		got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		v := got.(T)
+		if !v.Shanghai.Equal(tm) || v.Shanghai.Location().String() != "Asia/Shanghai" {
+			t.Errorf("unexpected Shanghai: %v", v.Shanghai)
+		}
+		if !v.NewYork.Equal(tm) || v.NewYork.Location().String() != "America/New_York" {
+			t.Errorf("unexpected NewYork: %v", v.NewYork)
+		}
+		if !v.UTC.Equal(tm) || v.UTC.Location() != time.UTC {
+			t.Errorf("unexpected UTC: %v", v.UTC)
+		}
+	})
+
+	t.Run("StructToMap", func(t *testing.T) {
+		got, err := c.StructToMap(T{Shanghai: tm, NewYork: &tm, UTC: tm.In(shanghai)})
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want := map[string]interface{}{
+			"Shanghai": "2020-01-02 08:00:00",
+			"NewYork":  "2020-01-01T19:00:00-05:00",
+			"UTC":      "2020-01-02T00:00:00Z",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("round-trip", func(t *testing.T) {
+		src := T{Shanghai: tm, NewYork: &tm, UTC: tm}
+		mm, err := c.StructToMap(src)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		got, err := c.MapToStruct(mm, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		v := got.(T)
+		if !v.Shanghai.Equal(tm) || !v.NewYork.Equal(tm) || !v.UTC.Equal(tm) {
+			t.Errorf("unexpected result: %v", v)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		type Bad struct {
+			V time.Time `tz:"No/Such_Zone"`
+		}
+
+		_, err := c.MapToStruct(map[string]interface{}{"V": "2020-01-02T00:00:00Z"}, reflect.TypeOf(Bad{}))
+		if err == nil || !strings.Contains(err.Error(), "invalid time zone 'No/Such_Zone' of field 'V'") {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		_, err = c.StructToMap(Bad{})
+		if err == nil || !strings.Contains(err.Error(), "invalid time zone") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestConv_withSeparatorTag(t *testing.T) {
+	type T struct {
+		Tags  []string `sep:";"`
+		IDs   []int    `sep:"|"`
+		Names []string
+	}
+
+	c := &Conv{Conf: Config{
+		SeparatorTag:   "sep",
+		StringSplitter: func(v string) []string { return strings.Split(v, ",") },
+	}}
+
+	m := map[string]interface{}{
+		"Tags":  "a,b;c",
+		"IDs":   "1|2|3",
+		"Names": "x,y",
+	}
+	got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	want := T{
+		Tags:  []string{"a,b", "c"},
+		IDs:   []int{1, 2, 3},
+		Names: []string{"x", "y"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// Without StringSplitter, only tagged fields are split.
+	c = &Conv{Conf: Config{SeparatorTag: "sep"}}
+	got, err = c.MapToStruct(m, reflect.TypeOf(T{}))
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	want.Names = []string{"x,y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestConv_withEncodingTag(t *testing.T) {
+	type T struct {
+		B64   []byte  `encoding:"base64"`
+		Hex   *[]byte `encoding:"hex"`
+		Raw   []byte  `encoding:"raw"`
+		Plain []byte
+	}
+
+	c := &Conv{Conf: Config{EncodingTag: "encoding"}}
+
+	t.Run("ok", func(t *testing.T) {
+		m := map[string]interface{}{
+			"B64":   "aGVsbG8=",
+			"Hex":   "776f726c64",
+			"Raw":   "aGVsbG8=",
+			"Plain": "12", // Converted as usual.
+		}
+		got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		v := got.(T)
+		if string(v.B64) != "hello" || string(*v.Hex) != "world" || string(v.Raw) != "aGVsbG8=" || !bytes.Equal(v.Plain, []byte{12}) {
+			t.Errorf("unexpected result: %#v", v)
+		}
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		got, err := c.MapToStruct(map[string]interface{}{"B64": []byte("x")}, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if string(got.(T).B64) != "x" {
+			t.Errorf("unexpected result: %#v", got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := c.MapToStruct(map[string]interface{}{"B64": "!!"}, reflect.TypeOf(T{}))
+		if err == nil || !strings.Contains(err.Error(), "error on converting field 'B64': error on decoding base64") {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		_, err = c.MapToStruct(map[string]interface{}{"Hex": "zz"}, reflect.TypeOf(T{}))
+		if err == nil || !strings.Contains(err.Error(), "error on converting field 'Hex': error on decoding hex") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		type Bad struct {
+			V []byte `encoding:"base32"`
+		}
+
+		_, err := c.MapToStruct(map[string]interface{}{"V": "a"}, reflect.TypeOf(Bad{}))
+		if err == nil || !strings.Contains(err.Error(), "unknown encoding 'base32'") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestConv_withMaxLenTag(t *testing.T) {
+	type Name string
+	type T struct {
+		Name  string  `maxlen:"5"`
+		Named Name    `maxlen:"3"`
+		Ptr   *string `maxlen:"2"`
+		Free  string
+	}
+
+	c := &Conv{Conf: Config{MaxLenTag: "maxlen"}}
+
+	t.Run("under", func(t *testing.T) {
+		m := map[string]interface{}{"Name": "hello", "Named": "你好吗", "Ptr": "ab", "Free": "a long string"}
+		got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		ab := "ab"
+		want := T{Name: "hello", Named: "你好吗", Ptr: &ab, Free: "a long string"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := c.MapToStruct(map[string]interface{}{"Name": "hello!"}, reflect.TypeOf(T{}))
+		if err == nil || err.Error() != "conv.MapToStruct: error on converting field 'Name': the length 6 exceeds the limit 5" {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		_, err = c.MapToStruct(map[string]interface{}{"Ptr": 123}, reflect.TypeOf(T{}))
+		if err == nil || !strings.Contains(err.Error(), "field 'Ptr': the length 3 exceeds the limit 2") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		var warnings []string
+		c := &Conv{Conf: Config{
+			MaxLenTag:           "maxlen",
+			TruncateLongStrings: true,
+			OnWarning:           func(path, msg string) { warnings = append(warnings, path+": "+msg) },
+		}}
+
+		s := "abc"
+		m := map[string]interface{}{"Name": "hello world", "Named": "你好吗?", "Ptr": &s}
+		got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		ab := "ab"
+		want := T{Name: "hello", Named: "你好吗", Ptr: &ab}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+		if s != "abc" {
+			t.Errorf("the source should not be modified")
+		}
+
+		sort.Strings(warnings)
+		wantWarnings := []string{
+			"Name: the string is truncated from 11 to 5 runes",
+			"Named: the string is truncated from 4 to 3 runes",
+			"Ptr: the string is truncated from 3 to 2 runes",
+		}
+		if !reflect.DeepEqual(warnings, wantWarnings) {
+			t.Errorf("want %v, got %v", wantWarnings, warnings)
+		}
+	})
+
+	t.Run("invalid-tag", func(t *testing.T) {
+		type Bad struct {
+			V string `maxlen:"x"`
+		}
+
+		_, err := c.MapToStruct(map[string]interface{}{"V": "v"}, reflect.TypeOf(Bad{}))
+		if err == nil || !strings.Contains(err.Error(), "invalid maximum length 'x'") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestConv_withRangeTag(t *testing.T) {
+	type T struct {
+		Port  int      `conv:"port" min:"1" max:"65535"`
+		Count uint8    `min:"2"`
+		Ratio *float64 `min:"-0.5" max:"0.5"`
+		Free  int
+	}
+
+	c := &Conv{Conf: _tagConv.Conf}
+	c.Conf.MinTag = "min"
+	c.Conf.MaxTag = "max"
+
+	t.Run("in-range", func(t *testing.T) {
+		m := map[string]interface{}{"port": "65535", "Count": 2, "Ratio": "-0.5", "Free": -1}
+		got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		ratio := -0.5
+		want := T{Port: 65535, Count: 2, Ratio: &ratio, Free: -1}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("out-of-range", func(t *testing.T) {
+		tests := []struct {
+			m    map[string]interface{}
+			want string
+		}{
+			{map[string]interface{}{"port": 0}, "error on converting field 'Port': the value 0 is less than the minimum 1"},
+			{map[string]interface{}{"port": 65536}, "error on converting field 'Port': the value 65536 is greater than the maximum 65535"},
+			{map[string]interface{}{"Count": 1}, "error on converting field 'Count': the value 1 is less than the minimum 2"},
+			{map[string]interface{}{"Ratio": 0.75}, "error on converting field 'Ratio': the value 0.75 is greater than the maximum 0.5"},
+		}
+		for _, tt := range tests {
+			_, err := c.MapToStruct(tt.m, reflect.TypeOf(T{}))
+			if want := "conv.MapToStruct: " + tt.want; err == nil || err.Error() != want {
+				t.Errorf("want %v, got %v", want, err)
+			}
+		}
+	})
+
+	t.Run("invalid-bound", func(t *testing.T) {
+		type T struct {
+			A uint `min:"-1"`
+			B int  `max:"1.5"`
+		}
+
+		_, err := c.MapToStruct(map[string]interface{}{"A": 1}, reflect.TypeOf(T{}))
+		if err == nil || !strings.Contains(err.Error(), "field 'A': invalid minimum value '-1'") {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		_, err = c.MapToStruct(map[string]interface{}{"B": 1}, reflect.TypeOf(T{}))
+		if err == nil || !strings.Contains(err.Error(), "field 'B': invalid maximum value '1.5'") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		got, err := _tagConv.MapToStruct(map[string]interface{}{"port": 0}, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := (T{}); !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestConv_withPipeTag(t *testing.T) {
+	type T struct {
+		Name  string   `pipe:"trim,lower"`
+		Code  string   `pipe:" trim , upper "`
+		Count int      `pipe:"trim"`
+		Tags  []string `pipe:"positive"`
+		Raw   string
+	}
+
+	str := func(f func(string) string) func(v interface{}) (interface{}, error) {
+		return func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return f(s), nil
+			}
+			return v, nil
+		}
+	}
+
+	c := &Conv{Conf: Config{
+		PipeTag: "pipe",
+		Pipes: map[string]func(v interface{}) (interface{}, error){
+			"trim":  str(strings.TrimSpace),
+			"lower": str(strings.ToLower),
+			"upper": str(strings.ToUpper),
+			"positive": func(v interface{}) (interface{}, error) {
+				if n, ok := v.(int); ok && n <= 0 {
+					return nil, errors.New("must be positive")
+				}
+				return v, nil
+			},
+		},
+	}}
+
+	t.Run("ok", func(t *testing.T) {
+		m := map[string]interface{}{
+			"Name":  "  Bob Smith ",
+			"Code":  " ab ",
+			"Count": " 12 ",
+			"Raw":   " Raw ",
+		}
+		got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want := T{Name: "bob smith", Code: "AB", Count: 12, Raw: " Raw "}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %#v, got %#v", want, got)
+		}
+	})
+
+	t.Run("pipe-error", func(t *testing.T) {
+		_, err := c.MapToStruct(map[string]interface{}{"Tags": 0}, reflect.TypeOf(T{}))
+		if err == nil || err.Error() != "conv.MapToStruct: error on converting field 'Tags': error on pipe 'positive': must be positive" {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unknown-pipe", func(t *testing.T) {
+		type Bad struct {
+			V string `pipe:"trim,nope"`
+		}
+
+		_, err := c.MapToStruct(map[string]interface{}{"V": "v"}, reflect.TypeOf(Bad{}))
+		if err == nil || !strings.Contains(err.Error(), "unknown pipe 'nope'") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestConv_withTagOptions(t *testing.T) {
+	type T struct {
+		ID      int       `conv:"id,converter=hex"`
+		Date    time.Time `conv:"date,layout=2006-01-02"`
+		Created time.Time `conv:"created,unix"`
+		Secret  string    `conv:"secret,omit"`
+		Name    string    `conv:"name"`
+	}
+
+	c := &Conv{Conf: _tagConv.Conf}
+	c.Conf.NamedConverters = map[string]ConvertFunc{
+		"hex": func(value interface{}, typ reflect.Type) (interface{}, error) {
+			if typ == typEmptyInterface {
+				return fmt.Sprintf("%x", value), nil
+			}
+
+			n, err := strconv.ParseInt(fmt.Sprint(value), 16, 64)
+			if err != nil {
+				return nil, errors.New("bad hex")
+			}
+			return int(n), nil
+		},
+	}
+
+	date := time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)
+	created := time.Unix(1622726482, 0)
+	want := T{ID: 255, Date: date, Created: created, Name: "n"}
+
+	t.Run("map-to-struct", func(t *testing.T) {
+		m := map[string]interface{}{"id": "ff", "date": "2022-03-04", "created": "1622726482", "secret": "s", "name": "n"}
+		got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+
+		// Numbers are timestamps in seconds, regardless of the timestamp unit.
+		cc := &Conv{Conf: c.Conf}
+		cc.Conf.TimestampUnit = UnitMillis
+		got, err = cc.MapToStruct(map[string]interface{}{"created": 1622726482}, reflect.TypeOf(T{}))
+		if err != nil || !got.(T).Created.Equal(created) {
+			t.Errorf("unexpected result %v, %v", got, err)
+		}
+	})
+
+	t.Run("struct-to-map", func(t *testing.T) {
+		src := want
+		src.Secret = "s"
+		got, err := c.StructToMap(src)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		wantMap := map[string]interface{}{"id": "ff", "date": "2022-03-04", "created": int64(1622726482), "name": "n"}
+		if !reflect.DeepEqual(got, wantMap) {
+			t.Errorf("want %v, got %v", wantMap, got)
+		}
+	})
+
+	t.Run("struct-to-struct", func(t *testing.T) {
+		type From struct {
+			ID      string `conv:"id"`
+			Secret  string `conv:"secret"`
+			Created int64  `conv:"created"`
+			Name    string `conv:"name,omit"`
+		}
+
+		got, err := c.StructToStruct(From{ID: "10", Secret: "s", Created: 1622726482, Name: "n"}, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := (T{ID: 16, Created: created}); !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			m    map[string]interface{}
+			want string
+		}{
+			{map[string]interface{}{"id": "x"}, "error on converting field 'ID': converter 'hex': bad hex"},
+			{map[string]interface{}{"created": "2022-03-04"}, "error on converting field 'Created': .*invalid Unix timestamp '2022-03-04'"},
+			{map[string]interface{}{"date": "2022/03/04"}, "error on converting field 'Date': .*cannot parse"},
+		}
+		for _, tt := range tests {
+			_, err := c.MapToStruct(tt.m, reflect.TypeOf(T{}))
+			if err == nil || !regexp.MustCompile(tt.want).MatchString(err.Error()) {
+				t.Errorf("want %v, got %v", tt.want, err)
+			}
+		}
+
+		type U struct {
+			A int `conv:"a,converter=none"`
+			B int `conv:"b,converter=str"`
+		}
+		cc := &Conv{Conf: c.Conf}
+		cc.Conf.NamedConverters = map[string]ConvertFunc{
+			"str": func(value interface{}, typ reflect.Type) (interface{}, error) { return "s", nil },
+		}
+
+		_, err := cc.MapToStruct(map[string]interface{}{"a": 1}, reflect.TypeOf(U{}))
+		if err == nil || !strings.Contains(err.Error(), "error on converting field 'A': unknown converter 'none'") {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		_, err = cc.MapToStruct(map[string]interface{}{"b": 1}, reflect.TypeOf(U{}))
+		if err == nil || !strings.Contains(err.Error(), "the converter 'str' returned string, which is not assignable to int") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestConv_withJSONStyleTagOptions(t *testing.T) {
+	type T struct {
+		Skip  int      `conv:"-"`
+		Dash  int      `conv:"-,"`
+		Name  string   `conv:"name,omitempty"`
+		Count int      `conv:",omitempty"`
+		Ptr   *int     `conv:"ptr,omitempty"`
+		List  []int    `conv:"list,omitempty"`
+		Price float64  `conv:"price,string"`
+		OK    bool     `conv:"ok,string"`
+		Tags  []string `conv:"tags,string"` // Not a number, the option is ignored.
+	}
+
+	c := &Conv{Conf: _tagConv.Conf}
+
+	t.Run("map-to-struct", func(t *testing.T) {
+		m := map[string]interface{}{"Skip": 1, "-": 2, "name": "n", "price": "1.5"}
+		got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := (T{Dash: 2, Name: "n", Price: 1.5}); !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("struct-to-map", func(t *testing.T) {
+		got, err := c.StructToMap(T{Skip: 1, Price: 1.5})
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want := map[string]interface{}{"-": 0, "price": "1.5", "ok": "false", "tags": []string(nil)}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+
+		zero := 0
+		got, err = c.StructToMap(T{Name: "n", Count: 3, Ptr: &zero, List: []int{1}, OK: true})
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want = map[string]interface{}{
+			"-": 0, "name": "n", "Count": 3, "ptr": 0, "list": []int{1}, "price": "0", "ok": "true", "tags": []string(nil),
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestConv_withCustomConverters(t *testing.T) {
+	type Name struct{ FirstName, LastName string }
+	namePtrTyp := reflect.TypeOf(&Name{})
+	nameTyp := namePtrTyp.Elem()
+
+	//  "A B" -> Name{A, B}.
+	nameStructConverter := func(value interface{}, typ reflect.Type) (result interface{}, err error) {
+		if typ != nameTyp {
+			return nil, nil
+		}
+
+		s, ok := value.(string)
+		if !ok {
+			return nil, nil
+		}
+
+		parts := strings.Split(s, " ")
+		if len(parts) != 2 {
+			return nil, errors.New("bad name")
+		}
+
+		return Name{parts[0], parts[1]}, nil
+	}
+
+	// string/Name/*Name -> *Name.
+	namePointerConverter := func(value interface{}, typ reflect.Type) (result interface{}, err error) {
+		if typ != namePtrTyp {
+			return nil, nil
+		}
+
+		if _, ok := value.(string); ok {
+			n, err := nameStructConverter(value, nameTyp)
+			if err != nil {
+				return nil, err
+			}
+			return &n, nil
+		}
+
+		if v, ok := value.(Name); ok {
+			return &Name{v.FirstName, v.LastName}, nil
+		}
+
+		if v, ok := value.(*Name); ok {
+			return &Name{v.FirstName, v.LastName}, nil
+		}
+
+		return nil, nil
+	}
+
+	c := &Conv{
+		Conf: Config{
+			CustomConverters: []ConvertFunc{nameStructConverter, namePointerConverter},
+		},
+	}
+
+	t.Run("StringToName", func(t *testing.T) {
+		var got Name
+		err := c.Convert("John Doe", &got)
+		if err != nil {
+			t.Errorf("got error %s", err)
+		}
+
+		want := Name{"John", "Doe"}
+		if got != want {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("NameToNamePtr", func(t *testing.T) {
+		got, err := c.ConvertType(Name{"John", "Doe"}, reflect.TypeOf(&Name{}))
+		if err != nil {
+			t.Errorf("got error %s", err)
+		}
+
+		n := got.(*Name)
+		want := &Name{"John", "Doe"}
+		if n.FirstName != want.FirstName || n.LastName != want.LastName {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var got Name
+		err := c.Convert("John X Doe", &got)
+		if err == nil {
+			t.Errorf("want error")
+		}
+
+		want := "conv.Convert: converter[0]: bad name"
+		if err.Error() != want {
+			t.Errorf("want error %s, got %s", want, err)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		var got string
+		err := c.Convert("John Doe", &got)
+		if err != nil {
+			t.Errorf("got error %s", err)
+		}
+
+		want := "John Doe"
+		if got != want {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+}
+
+func TestConv_withCustomConverters_nestedMap(t *testing.T) {
+	type Point struct{ X, Y int }
+	type T struct {
+		P  Point
+		PP *Point
+	}
+
+	var received []interface{}
+	pointTyp := reflect.TypeOf(Point{})
+
+	// {"xy": [x, y]} -> Point{x, y}.
+	pointConverter := func(value interface{}, typ reflect.Type) (interface{}, error) {
+		if typ != pointTyp {
+			return nil, nil
+		}
+
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+
+		received = append(received, value)
+		xy := m["xy"].([]int)
+		return Point{xy[0], xy[1]}, nil
+	}
+
+	c := &Conv{
+		Conf: Config{
+			CustomConverters: []ConvertFunc{pointConverter},
+		},
+	}
+
+	p := map[string]interface{}{"xy": []int{1, 2}}
+	pp := map[string]interface{}{"xy": []int{3, 4}}
+	got, err := c.MapToStruct(map[string]interface{}{"P": p, "PP": pp}, reflect.TypeOf(T{}))
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	res := got.(T)
+	if res.P != (Point{1, 2}) {
+		t.Errorf("P: want %v, got %v", Point{1, 2}, res.P)
+	}
+
+	if res.PP == nil || *res.PP != (Point{3, 4}) {
+		t.Errorf("PP: want %v, got %v", Point{3, 4}, res.PP)
+	}
+
+	// The converter must receive the raw nested maps.
+	if len(received) != 2 {
+		t.Fatalf("want 2 calls, got %v", len(received))
+	}
+
+	for _, v := range received {
+		if !reflect.DeepEqual(v, p) && !reflect.DeepEqual(v, pp) {
+			t.Errorf("unexpected value received: %v", v)
+		}
+	}
+}
+
+func TestConv_withCustomConvertersEx(t *testing.T) {
+	type T struct {
+		S  string
+		P  *int
+		N  int
+		NP *int
+	}
+
+	c := &Conv{Conf: Config{
+		CustomConvertersEx: []ConvertFuncEx{
+			// Typed nils become "nil" strings.
+			func(src reflect.Value, dstTyp reflect.Type) (reflect.Value, bool, error) {
+				if dstTyp.Kind() != reflect.String || src.Kind() != reflect.Ptr || !src.IsNil() {
+					return reflect.Value{}, false, nil
+				}
+				return reflect.ValueOf("nil"), true, nil
+			},
+			// Bools to ints, false is the zero value.
+			func(src reflect.Value, dstTyp reflect.Type) (reflect.Value, bool, error) {
+				if dstTyp.Kind() != reflect.Int || src.Kind() != reflect.Bool {
+					return reflect.Value{}, false, nil
+				}
+				if !src.Bool() {
+					return reflect.Value{}, true, nil
+				}
+				return reflect.ValueOf(-1), true, nil
+			},
+			func(src reflect.Value, dstTyp reflect.Type) (reflect.Value, bool, error) {
+				if src.Kind() == reflect.Float64 {
+					return reflect.Value{}, false, errors.New("no floats")
+				}
+				return reflect.Value{}, false, nil
+			},
+		},
+	}}
 
-		{"pi-s", args{pi, reflect.TypeOf(s)}, "1", 0, false},
-		{"ppi-ps", args{ppi, reflect.TypeOf(ps)}, "1", 1, false},
-		{"pi-pps", args{pi, reflect.TypeOf(pps)}, "1", 2, false},
+	got, err := c.MapToStruct(map[string]interface{}{"S": (*int)(nil), "P": true, "N": false, "NP": 3}, reflect.TypeOf(T{}))
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	minus, three := -1, 3
+	if want := (T{S: "nil", P: &minus, NP: &three}); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
 
-		{"ps-i", args{ps, reflect.TypeOf(i)}, 3, 0, false},
-		{"pps-ppi", args{pps, reflect.TypeOf(ppi)}, 3, 2, false},
+	_, err = c.ConvertType(1.5, reflect.TypeOf(0))
+	if want := "conv.ConvertType: converterEx[2]: no floats"; err == nil || err.Error() != want {
+		t.Errorf("want %v, got %v", want, err)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := _defaultConv.ConvertType(tt.args.src, tt.args.dstTyp)
 
-			if (err != nil) != tt.wantErr {
-				t.Errorf("ConvertType() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
+	// Convert uses the converters too, without any CustomConverters.
+	var n int
+	if err := c.Convert(true, &n); err != nil || n != -1 {
+		t.Errorf("unexpected result %v, %v", n, err)
+	}
 
-			var valGot interface{}
-			var valDepth int
-			switch v := got.(type) {
-			case int:
-				valGot, valDepth = v, 0
-			case *int:
-				valGot, valDepth = *v, 1
-			case **int:
-				valGot, valDepth = **v, 2
-			case ***int:
-				valGot, valDepth = ***v, 3
-			case string:
-				valGot, valDepth = v, 0
-			case *string:
-				valGot, valDepth = *v, 1
-			case **string:
-				valGot, valDepth = **v, 2
-			case ***string:
-				valGot, valDepth = ***v, 3
-			}
+	var p *int
+	if err := c.Convert(true, &p); err != nil || p == nil || *p != -1 {
+		t.Errorf("unexpected result %v, %v", p, err)
+	}
 
-			if valGot != tt.wantVal || valDepth != tt.wantDepth {
-				t.Errorf("ConvertType() = val %v, depth %v, want val %v depth %v", valGot, valDepth, tt.wantVal, tt.wantDepth)
-			}
-		})
+	err = c.Convert(1.5, &n)
+	if want := "conv.Convert: converterEx[2]: no floats"; err == nil || err.Error() != want {
+		t.Errorf("want %v, got %v", want, err)
 	}
 }
 
-func TestConv_ConvertType_mapToStructWithPointers(t *testing.T) {
+func TestConv_withTypedConverters(t *testing.T) {
+	type ID int
 	type T struct {
-		S FromString
-		I int
-		F float64
-	}
-	type P struct {
-		S1    *T
-		inner *int
-		Out   *FromString
-		m     *map[string]int
-		Sl    *[]byte
+		ID  ID
+		PID *ID
+		N   int
 	}
 
-	fieldS1 := T{S: "23", I: 33, F: 44}
-	fieldOut := FromString("3.14")
-	p2 := P{S1: &fieldS1, Out: &fieldOut}
-	pp2 := &p2
-	in := map[string]interface{}{
-		"S1":    map[string]interface{}{"S": 23, "I": 33, "F": 44, "inner": 55},
-		"Out":   3.14,
-		"Sl":    nil,
-		"inner": -1,
+	var calls int
+	c := new(Conv)
+	c.RegisterConverter(reflect.TypeOf(ID(0)), func(value interface{}, typ reflect.Type) (interface{}, error) {
+		calls++
+		s, ok := value.(string)
+		if !ok {
+			return nil, nil // Fallback.
+		}
+		if !strings.HasPrefix(s, "#") {
+			return nil, errors.New("bad id")
+		}
+		n, err := strconv.Atoi(s[1:])
+		return ID(n), err
+	})
+	c.Conf.CustomConverters = []ConvertFunc{
+		func(value interface{}, typ reflect.Type) (interface{}, error) {
+			if typ == reflect.TypeOf(ID(0)) {
+				return ID(-1), nil
+			}
+			return nil, nil
+		},
 	}
-	res, err := _defaultConv.ConvertType(in, reflect.TypeOf(pp2))
+
+	got, err := c.MapToStruct(map[string]interface{}{"ID": "#12", "PID": "#3", "N": 4}, reflect.TypeOf(T{}))
 	if err != nil {
-		t.Errorf("ConvertType: %s", err)
-		return
+		t.Fatalf("got error %s", err)
+	}
+	three := ID(3)
+	if want := (T{ID: 12, PID: &three, N: 4}); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
 	}
 
-	// reflect.DeepEqual() doesn't compare the underlying values of pointers.
-	// We compare the fields manually.
-	out := *res.(*P)
-	if out.inner != nil {
-		t.Error("inner != nil")
-		return
+	// Called for ID and *ID only.
+	if calls != 2 {
+		t.Errorf("want 2 calls, got %v", calls)
 	}
 
-	if out.m != nil {
-		t.Error("m != nil")
-		return
+	// The CustomConverters are called if the typed converter returns nil.
+	got, err = c.ConvertType(1, reflect.TypeOf(ID(0)))
+	if err != nil || got != ID(-1) {
+		t.Errorf("unexpected result %v, %v", got, err)
 	}
 
-	if out.Sl != nil {
-		t.Error("Sl != nil")
-		return
+	_, err = c.ConvertType("12", reflect.TypeOf(ID(0)))
+	if want := "conv.ConvertType: converter for conv.ID: bad id"; err == nil || err.Error() != want {
+		t.Errorf("want %v, got %v", want, err)
 	}
 
-	if out.Out == nil || *out.Out != "3.14" {
-		t.Error("Out != 3.14")
-		return
+	// Convert uses the converters too.
+	var id ID
+	if err := c.Convert("#42", &id); err != nil || id != 42 {
+		t.Errorf("unexpected result %v, %v", id, err)
 	}
 
-	if out.S1 == nil {
-		t.Error("S1 == nil")
-		return
+	var pid *ID
+	if err := c.Convert("#43", &pid); err != nil || pid == nil || *pid != 43 {
+		t.Errorf("unexpected result %v, %v", pid, err)
 	}
 
-	if !reflect.DeepEqual(*out.S1, fieldS1) {
-		t.Errorf("S1: got %#v, want %#v", *out.S1, fieldS1)
-		return
+	err = c.Convert("12", &id)
+	if want := "conv.Convert: converter for conv.ID: bad id"; err == nil || err.Error() != want {
+		t.Errorf("want %v, got %v", want, err)
+	}
+
+	// Copies are not affected by registrations.
+	cc := &Conv{Conf: c.Conf}
+	cc.RegisterConverter(reflect.TypeOf(0), func(value interface{}, typ reflect.Type) (interface{}, error) { return 0, nil })
+	if len(c.Conf.TypedConverters) != 1 || len(cc.Conf.TypedConverters) != 2 {
+		t.Errorf("the map should be copied")
 	}
 }
 
-func TestConv_ConvertType_sliceToSlice(t *testing.T) {
-	type s struct {
-		S     string
-		I     int64
-		F     float32
-		inner int
+func TestConv_withOnWarning(t *testing.T) {
+	type warning struct{ path, msg string }
+	var warnings []warning
+	c := &Conv{
+		Conf: Config{
+			OnWarning: func(path, msg string) {
+				warnings = append(warnings, warning{path, msg})
+			},
+		},
 	}
-	type sPtr struct {
-		S     *string
-		I     **int64
-		F     *float32
-		inner *int
+
+	type Item struct {
+		Price float32
+		At    int64
+	}
+	type T struct {
+		Items []Item
+		Big   float64
+		Exact float32
 	}
 
-	in := []*s{
-		{S: "1", I: 3, inner: 5},
-		{S: "2", F: 4},
+	m := map[string]interface{}{
+		"Items": []interface{}{
+			map[string]interface{}{"Price": 1.5, "At": time.Unix(100, 0)},
+			map[string]interface{}{"Price": 0.1, "At": time.Unix(200, 500)},
+		},
+		"Big":   int64(1<<53 + 1),
+		"Exact": 2.25,
 	}
 
-	dstTyp := reflect.TypeOf([]*sPtr{})
-	out, err := _defaultConv.ConvertType(in, dstTyp)
+	_, err := c.MapToStruct(m, reflect.TypeOf(T{}))
 	if err != nil {
-		t.Errorf("err: %s", err.Error())
-		return
+		t.Fatalf("got error %s", err)
 	}
 
-	ss, ok := out.([]*sPtr)
-	if !ok {
-		t.Errorf("wrong type: %v", reflect.TypeOf(out))
-		return
+	want := map[string]string{
+		"Items[1].Price": "lost precision when converting 0.1 (float64) to float32",
+		"Items[1].At":    "the fractional second",
+		"Big":            "lost precision when converting 9007199254740993 (int64) to float64",
 	}
-
-	if len(ss) != len(in) {
-		t.Errorf("wrong length, got %v, want %v", len(ss), len(in))
-		return
+	if len(warnings) != len(want) {
+		t.Fatalf("want %v warnings, got %v", len(want), warnings)
 	}
 
-	check := func(idx int, s string, i int64, f float32) {
-		e := ss[idx]
-		if e == nil {
-			t.Errorf("nil at index %v", idx)
-			return
+	for _, w := range warnings {
+		msg, ok := want[w.path]
+		if !ok {
+			t.Errorf("unexpected warning at '%v': %v", w.path, w.msg)
+			continue
 		}
 
-		if e.S == nil || *e.S != s {
-			t.Errorf("unexpected value at index %v field S", idx)
-			return
+		if !strings.HasPrefix(w.msg, msg) {
+			t.Errorf("at '%v', want message starts with '%v', got '%v'", w.path, msg, w.msg)
 		}
+	}
+}
 
-		if e.I == nil || **e.I != i {
-			t.Errorf("unexpected value at index %v field I", idx)
-			return
-		}
+func TestConv_ConvertType_interfaceKeyMap(t *testing.T) {
+	type Inner struct {
+		Name string
+		Tags []string
+	}
+	type Middle struct {
+		Inner  Inner
+		Ptr    *Inner
+		Values map[string]int
+	}
+	type T struct {
+		ID     int
+		Middle Middle
+		List   []Inner
+	}
 
-		if e.F == nil || *e.F != f {
-			t.Errorf("unexpected value at index %v field F", idx)
-			return
-		}
+	// The style of maps produced by YAML decoders.
+	src := map[interface{}]interface{}{
+		"ID": 1,
+		"Middle": map[interface{}]interface{}{
+			"Inner":  map[interface{}]interface{}{"Name": "a", "Tags": []interface{}{"x", "y"}},
+			"Ptr":    map[interface{}]interface{}{"Name": "b"},
+			"Values": map[interface{}]interface{}{"k": "2"},
+		},
+		"List": []interface{}{
+			map[interface{}]interface{}{"Name": "c"},
+		},
+	}
 
-		if e.inner != nil {
-			t.Errorf("unexpected value at index %v field inner", idx)
-			return
-		}
+	want := T{
+		ID: 1,
+		Middle: Middle{
+			Inner:  Inner{Name: "a", Tags: []string{"x", "y"}},
+			Ptr:    &Inner{Name: "b"},
+			Values: map[string]int{"k": 2},
+		},
+		List: []Inner{{Name: "c"}},
 	}
 
-	check(0, "1", 3, 0)
-	check(1, "2", 0, 4)
+	got, err := _defaultConv.ConvertType(src, reflect.TypeOf(T{}))
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	t.Run("bad-key", func(t *testing.T) {
+		type K struct{ A int }
+		src := map[interface{}]interface{}{
+			"Middle": map[interface{}]interface{}{
+				K{}: 1,
+			},
+		}
+		_, err := _defaultConv.ConvertType(src, reflect.TypeOf(T{}))
+		if err == nil || !strings.Contains(err.Error(), "the keys must be convertible to strings") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
 }
 
-func TestConv_ConvertType_flatMap(t *testing.T) {
+func TestConv_AnyMapToStruct(t *testing.T) {
+	type T struct {
+		Name  string
+		Count int
+		Red   bool
+		N2    int `conv:"2"`
+	}
 
-	t.Run("simple", func(t *testing.T) {
-		src := map[string]interface{}{
-			"": 87654321,
+	t.Run("keys", func(t *testing.T) {
+		c := &Conv{Conf: _tagConv.Conf}
+		c.Conf.Enums = _colorEnums
+		src := map[interface{}]interface{}{
+			"Name":   "a",
+			"Count":  "2",
+			Color(1): true,
+			2:        22,
 		}
-		got, err := _defaultConv.ConvertType(src, reflect.TypeOf(0))
-
+		got, err := c.AnyMapToStruct(src, reflect.TypeOf(T{}))
 		if err != nil {
-			t.Fatalf("got error: %v", err)
+			t.Fatalf("got error %s", err)
 		}
 
-		if got != 87654321 {
-			t.Errorf("want %v, got %v", 87654321, got)
+		// Enums are converted to their names.
+		want := T{Name: "a", Count: 2, Red: true, N2: 22}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
 		}
 	})
 
-	t.Run("map", func(t *testing.T) {
-		type T struct {
-			S FromString
-			I int
-			F float64
-		}
-
-		i := 1999
-		var pf *float32
-
-		src := map[string]interface{}{
-			"": map[interface{}]interface{}{
-				struct{ I *int }{&i}: nil,
-				struct{}{}:           pf,
-				struct{ S int }{123}: &pf,
-			},
+	t.Run("other-maps", func(t *testing.T) {
+		got, err := _defaultConv.AnyMapToStruct(map[FromString]string{"Name": "n"}, reflect.TypeOf(T{}))
+		if err != nil || got != (T{Name: "n"}) {
+			t.Errorf("unexpected result %v, %v", got, err)
 		}
-		got, err := _defaultConv.ConvertType(src, reflect.TypeOf(map[T][]int{}))
 
-		if err != nil {
-			t.Fatalf("got error: %v", err)
+		got, err = _defaultConv.AnyMapToStruct(map[string]interface{}{"Count": 3}, reflect.TypeOf(T{}))
+		if err != nil || got != (T{Count: 3}) {
+			t.Errorf("unexpected result %v, %v", got, err)
 		}
+	})
 
-		want := map[T][]int{
-			{I: 1999}:  nil,
-			{}:         nil,
-			{S: "123"}: nil,
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			src  interface{}
+			want string
+		}{
+			{"nil", nil, "conv.AnyMapToStruct: the source value should not be nil"},
+			{"not-map", 1, "conv.AnyMapToStruct: the given value must be a map, got int"},
+			{"nil-map", map[int]int(nil), "conv.AnyMapToStruct: the map should not be nil"},
+			{"bad-key", map[interface{}]interface{}{[1]int{}: 1}, "conv.AnyMapToStruct: the keys must be convertible to strings: got [1]int{0} of type [1]int"},
+			{"duplicate", map[interface{}]interface{}{1: 1, "1": 2}, "conv.AnyMapToStruct: the keys "},
+			{"duplicate-bool", map[interface{}]interface{}{true: 1, 1: 2}, "conv.AnyMapToStruct: the keys "},
+			{"field", map[interface{}]interface{}{"Count": "x"}, "conv.AnyMapToStruct: error on converting field 'Count'"},
 		}
-		if !reflect.DeepEqual(got, want) {
-			t.Errorf("want %v, got %v", 87654321, got)
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := _defaultConv.AnyMapToStruct(tt.src, reflect.TypeOf(T{}))
+				if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+					t.Errorf("want %v, got %v", tt.want, err)
+				}
+			})
 		}
 	})
 }
 
-func TestConv_ConvertType(t *testing.T) {
-	now := time.Now()
-
-	type args struct {
-		src    interface{}
-		dstTyp reflect.Type
+func TestConv_convErrorPath(t *testing.T) {
+	type Item struct{ Age int }
+	type Group struct {
+		Items []Item
+		ByKey map[string]*Item
 	}
+	type T struct{ Groups []Group }
+
 	tests := []struct {
-		name     string
-		args     args
-		want     interface{}
-		errRegex string
+		name string
+		src  interface{}
+		typ  reflect.Type
+		path string
+		msg  string
 	}{
-		// simple to simple
-		{"string-int", args{"-387656", reflect.TypeOf(0)}, -387656, ""},
-		{"time-uint", args{now, reflect.TypeOf(uint(0))}, uint(now.Unix()), ""},
-
-		// string to slice
-		{"string-[]byte", args{"233", reflect.TypeOf([]byte{})}, []byte{233}, ""},
-
-		// struct to map
 		{
-			"struct-map",
-			args{
-				struct{ A, B interface{} }{},
-				reflect.TypeOf(map[string]interface{}{}),
+			"slice-in-slice",
+			map[string]interface{}{
+				"Groups": []interface{}{
+					map[string]interface{}{},
+					map[string]interface{}{
+						"Items": []interface{}{
+							map[string]interface{}{"Age": 1},
+							map[string]interface{}{"Age": 2},
+							map[string]interface{}{"Age": "x"},
+						},
+					},
+				},
 			},
-			map[string]interface{}{},
-			"",
+			reflect.TypeOf(T{}),
+			"Groups[1].Items[2].Age",
+			`conv.ConvertType: conv.MapToStruct: error on converting field 'Groups[1].Items[2].Age': conv.ConvertType: conv.SimpleToSimple: strconv.ParseInt: parsing "x": invalid syntax`,
 		},
 		{
-			"err-struct-wrong-map",
-			args{
-				struct{}{},
-				reflect.TypeOf(map[int]interface{}{}),
+			"map",
+			map[string]interface{}{
+				"Groups": []interface{}{
+					map[string]interface{}{
+						"ByKey": map[string]interface{}{"k": map[string]interface{}{"Age": "x"}},
+					},
+				},
 			},
-			nil,
-			`^conv.ConvertType: .+destination type must be map\[string\]interface\{\}, got map\[int\]interface.?\{\}$`,
+			reflect.TypeOf(T{}),
+			"Groups[0].ByKey[k].Age",
+			`conv.ConvertType: conv.MapToStruct: error on converting field 'Groups[0].ByKey[k].Age': conv.ConvertType: conv.SimpleToSimple: strconv.ParseInt: parsing "x": invalid syntax`,
 		},
-
-		// map to struct
 		{
-			"err-wrong-map-string",
-			args{
-				map[float32]interface{}{},
-				reflect.TypeOf(struct{}{}),
-			},
-			nil,
-			`^conv.ConvertType: .+the map must be map\[string\]interface\{\}, got map\[float32\]interface.?\{\}$`,
+			"root-slice",
+			[]interface{}{map[string]interface{}{"Age": "x"}},
+			reflect.TypeOf([]Item{}),
+			"[0].Age",
+			`conv.ConvertType: conv.SliceToSlice: error on converting field '[0].Age': conv.ConvertType: conv.SimpleToSimple: strconv.ParseInt: parsing "x": invalid syntax`,
 		},
-
-		// to empty interface
 		{
-			"empty-interface",
-			args{
-				[]int{1, 2, 3},
-				typEmptyInterface,
+			"struct-to-struct",
+			struct {
+				Groups []struct{ Items []struct{ Age string } }
+			}{
+				Groups: []struct{ Items []struct{ Age string } }{{Items: []struct{ Age string }{{"x"}}}},
 			},
-			[]int{1, 2, 3},
-			"",
+			reflect.TypeOf(T{}),
+			"Groups[0].Items[0].Age",
+			`conv.ConvertType: conv.StructToStruct: error on converting field 'Groups[0].Items[0].Age': conv.ConvertType: conv.SimpleToSimple: strconv.ParseInt: parsing "x": invalid syntax`,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := _defaultConv.ConvertType(tt.args.src, tt.args.dstTyp)
-
-			if err != nil {
-				if tt.errRegex == "" {
-					t.Errorf("ConvertType() unexpected error = %v", err)
-				}
+			_, err := _defaultConv.ConvertType(tt.src, tt.typ)
+			if err == nil {
+				t.Fatal("want error")
+			}
 
-				if match, _ := regexp.MatchString(tt.errRegex, err.Error()); !match {
-					t.Errorf("ConvertType() error = %v , must match %v",
-						strconv.Quote(err.Error()), strconv.Quote(tt.errRegex))
-				}
+			if err.Error() != tt.msg {
+				t.Errorf("want message:\n%v\ngot:\n%v", tt.msg, err)
 			}
 
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("ConvertType() = %v, want %v", got, tt.want)
+			var ce *ConvError
+			if !errors.As(err, &ce) {
+				t.Fatalf("want *ConvError, got %v", err)
+			}
+			if ce.Path != tt.path {
+				t.Errorf("want path %v, got %v", tt.path, ce.Path)
 			}
 		})
 	}
 }
 
-func TestConv_Convert_panic(t *testing.T) {
-	t.Run("nil", func(t *testing.T) {
-		defer func() {
-			var err interface{}
-			if err = recover(); err == nil {
-				t.Fatalf("should panic an error")
-			}
+func TestConv_MapToStructContext(t *testing.T) {
+	type Item struct{ V int }
+	type T struct {
+		A     int
+		Items []Item
+	}
 
-			const wantMsg = "conv.Convert: the destination value must be a pointer"
-			if err.(error).Error() != wantMsg {
-				t.Fatalf("should panic an error with message: '%v', got '%v'", wantMsg, err)
-			}
-		}()
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = map[string]interface{}{"V": i}
+	}
+	m := map[string]interface{}{"A": 1, "Items": items}
 
-		_defaultConv.Convert(nil, 0)
+	t.Run("ok", func(t *testing.T) {
+		got, err := _defaultConv.MapToStructContext(context.Background(), m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if len(got.(T).Items) != len(items) {
+			t.Errorf("unexpected result")
+		}
 	})
 
-	t.Run("uninitialized", func(t *testing.T) {
-		defer func() {
-			var err interface{}
-			if err = recover(); err == nil {
-				t.Fatalf("should panic an error")
-			}
+	t.Run("canceled-before", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
 
-			const wantMsg = "conv.Convert: the pointer must be initialized"
-			if err.(error).Error() != wantMsg {
-				t.Fatalf("should panic an error with message: '%v', got '%v'", wantMsg, err)
-			}
-		}()
+		_, err := _defaultConv.MapToStructContext(ctx, m, reflect.TypeOf(T{}))
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+		if err.Error() != "conv.MapToStruct: context canceled" {
+			t.Errorf("unexpected message: %v", err)
+		}
+	})
 
-		var p *int
-		_defaultConv.Convert("", p)
+	t.Run("canceled-nested", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Cancel when converting the 10th item.
+		calls := 0
+		c := &Conv{Conf: Config{
+			ValueTransformer: func(key string, value interface{}) interface{} {
+				if key == "V" {
+					calls++
+					if calls == 10 {
+						cancel()
+					}
+				}
+				return value
+			},
+		}}
+
+		_, err := c.MapToStructContext(ctx, m, reflect.TypeOf(T{}))
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+		if calls != 10 {
+			t.Errorf("want 10 calls, got %v", calls)
+		}
+
+		var ce *ConvError
+		if !errors.As(err, &ce) || ce.Path != "Items[10]" {
+			t.Errorf("unexpected error: %v", err)
+		}
 	})
+}
 
-	t.Run("ptr-to-nil-ptr", func(t *testing.T) {
-		defer func() {
-			var err interface{}
-			if err = recover(); err == nil {
-				t.Fatalf("should panic an error")
-			}
+func TestConv_MapToStructCount(t *testing.T) {
+	type T struct {
+		A int
+		B string
+		C float64
+	}
 
-			const wantMsg = "conv.Convert: the underlying pointer must be initialized"
-			if err.(error).Error() != wantMsg {
-				t.Fatalf("should panic an error with message: '%v', got '%v'", wantMsg, err)
+	tests := []struct {
+		name      string
+		m         map[string]interface{}
+		want      T
+		wantCount int
+	}{
+		{"full", map[string]interface{}{"A": 1, "B": "b", "C": 1.5}, T{1, "b", 1.5}, 3},
+		{"partial", map[string]interface{}{"A": 1, "X": "x", "y": 2}, T{A: 1}, 1},
+		{"none", map[string]interface{}{"a": 1, "b": "b", "Z": 2}, T{}, 0},
+		{"empty", map[string]interface{}{}, T{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n, err := _defaultConv.MapToStructCount(tt.m, reflect.TypeOf(T{}))
+			if err != nil {
+				t.Fatalf("got error %s", err)
 			}
-		}()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+			if n != tt.wantCount {
+				t.Errorf("want count %v, got %v", tt.wantCount, n)
+			}
+		})
+	}
 
-		var p *int
-		_defaultConv.Convert("", &p)
+	t.Run("setters", func(t *testing.T) {
+		c := &Conv{Conf: Config{UseSetters: true}}
+		_, n, err := c.MapToStructCount(map[string]interface{}{"Exported": "e", "Name": "n", "Other": 1}, reflect.TypeOf(setterTarget{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if n != 2 {
+			t.Errorf("want count 2, got %v", n)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, n, err := _defaultConv.MapToStructCount(map[string]interface{}{"A": "x"}, reflect.TypeOf(T{}))
+		if err == nil || !strings.HasPrefix(err.Error(), "conv.MapToStructCount: ") {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if n != 0 {
+			t.Errorf("want count 0, got %v", n)
+		}
 	})
 }
 
-func TestConv_Convert_ptr(t *testing.T) {
-	i := 1
-	pi := &i
-	ppi := &pi
+func TestConv_MapToStruct_onFieldMatch(t *testing.T) {
+	type T struct {
+		UserName string
+		MaxAge   int
+		Note     string `conv:"memo"`
+	}
 
-	t.Run("nil", func(t *testing.T) {
-		_defaultConv.Convert(nil, pi)
-		if *pi != 1 {
-			t.Errorf("want %v, got %v", i, *pi)
+	var trace []string
+	onMatch := func(key, fieldName, matcherName string) {
+		trace = append(trace, key+" "+fieldName+" "+matcherName)
+	}
+	m := map[string]interface{}{
+		"UserName": "a",
+		"max_age":  1,
+		"MEMO":     "n",
+		"Other":    2,
+	}
+
+	t.Run("chain", func(t *testing.T) {
+		trace = nil
+		c := &Conv{Conf: Config{
+			OnFieldMatch: onMatch,
+			MatcherChain: []FieldMatcherCreator{
+				&SimpleMatcherCreator{},
+				&SimpleMatcherCreator{Conf: SimpleMatcherConfig{Tag: "conv", CaseInsensitive: true}},
+				&SimpleMatcherCreator{Conf: SimpleMatcherConfig{CamelSnakeCase: true}},
+			},
+		}}
+		if _, err := c.MapToStruct(m, reflect.TypeOf(T{})); err != nil {
+			t.Fatalf("got error %s", err)
 		}
-	})
 
-	t.Run("string-p-int", func(t *testing.T) {
-		_defaultConv.Convert("-54321", pi)
-		if *pi != -54321 {
-			t.Errorf("want %v, got %v", i, *pi)
+		sort.Strings(trace)
+		want := []string{
+			"MEMO Note MatcherChain[1]",
+			"UserName UserName MatcherChain[0]",
+			"max_age MaxAge MatcherChain[2]",
+		}
+		if !reflect.DeepEqual(trace, want) {
+			t.Errorf("want %v, got %v", want, trace)
 		}
 	})
 
-	t.Run("string-pp-int", func(t *testing.T) {
-		_defaultConv.Convert("12345", ppi)
-		if **ppi != 12345 {
-			t.Errorf("want %v, got %v", i, *pi)
+	t.Run("single", func(t *testing.T) {
+		trace = nil
+		c := &Conv{Conf: Config{
+			OnFieldMatch:        onMatch,
+			FieldMatcherCreator: &SimpleMatcherCreator{Conf: SimpleMatcherConfig{CamelSnakeCase: true}},
+			KeyAliases:          map[string]string{"Other": "user_name"},
+		}}
+		if _, err := c.MapToStruct(map[string]interface{}{"max_age": 1, "Other": "x"}, reflect.TypeOf(T{})); err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		sort.Strings(trace)
+		want := []string{
+			"Other UserName FieldMatcherCreator",
+			"max_age MaxAge FieldMatcherCreator",
+		}
+		if !reflect.DeepEqual(trace, want) {
+			t.Errorf("want %v, got %v", want, trace)
 		}
 	})
 }
 
-func TestConv_withCustomConverters(t *testing.T) {
-	type Name struct{ FirstName, LastName string }
-	namePtrTyp := reflect.TypeOf(&Name{})
-	nameTyp := namePtrTyp.Elem()
+func TestConv_MapToStruct_deepCopyInputs(t *testing.T) {
+	type T struct {
+		Any  interface{}
+		Data map[string]interface{}
+		List []interface{}
+	}
 
-	//  "A B" -> Name{A, B}.
-	nameStructConverter := func(value interface{}, typ reflect.Type) (result interface{}, err error) {
-		if typ != nameTyp {
-			return nil, nil
+	newSource := func() map[string]interface{} {
+		return map[string]interface{}{
+			"Any":  map[string]interface{}{"k": []interface{}{1}},
+			"Data": map[string]interface{}{"list": []interface{}{1, 2}},
+			"List": []interface{}{map[string]interface{}{"a": 1}},
 		}
+	}
+	mutate := func(m map[string]interface{}) {
+		m["Any"].(map[string]interface{})["k"].([]interface{})[0] = 9
+		m["Data"].(map[string]interface{})["list"].([]interface{})[0] = 9
+		m["List"].([]interface{})[0].(map[string]interface{})["a"] = 9
+	}
+	want := T{
+		Any:  map[string]interface{}{"k": []interface{}{1}},
+		Data: map[string]interface{}{"list": []interface{}{1, 2}},
+		List: []interface{}{map[string]interface{}{"a": 1}},
+	}
 
-		s, ok := value.(string)
-		if !ok {
-			return nil, nil
+	t.Run("enabled", func(t *testing.T) {
+		m := newSource()
+		c := &Conv{Conf: Config{DeepCopyInputs: true}}
+		got, err := c.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
 		}
 
-		parts := strings.Split(s, " ")
-		if len(parts) != 2 {
-			return nil, errors.New("bad name")
+		mutate(m)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
 		}
+	})
 
-		return Name{parts[0], parts[1]}, nil
-	}
-
-	// string/Name/*Name -> *Name.
-	namePointerConverter := func(value interface{}, typ reflect.Type) (result interface{}, err error) {
-		if typ != namePtrTyp {
-			return nil, nil
+	t.Run("disabled", func(t *testing.T) {
+		m := newSource()
+		got, err := _defaultConv.MapToStruct(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
 		}
 
-		if _, ok := value.(string); ok {
-			n, err := nameStructConverter(value, nameTyp)
-			if err != nil {
-				return nil, err
-			}
-			return &n, nil
+		// The values stored as they are share the storage with the source.
+		mutate(m)
+		if reflect.DeepEqual(got, want) {
+			t.Errorf("should share the storage with the source")
 		}
+	})
 
-		if v, ok := value.(Name); ok {
-			return &Name{v.FirstName, v.LastName}, nil
+	t.Run("interface", func(t *testing.T) {
+		type R struct{ Reader io.Reader }
+
+		buf := bytes.NewBufferString("abc")
+		c := &Conv{Conf: Config{DeepCopyInputs: true}}
+		got, err := c.MapToStruct(map[string]interface{}{"Reader": buf}, reflect.TypeOf(R{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if got.(R).Reader == io.Reader(buf) {
+			t.Errorf("should be copied")
 		}
+	})
+}
 
-		if v, ok := value.(*Name); ok {
-			return &Name{v.FirstName, v.LastName}, nil
+func TestConv_DeepClone(t *testing.T) {
+	type Node struct {
+		Value    int
+		Children []*Node
+		Parent   *Node
+	}
+
+	root := &Node{Value: 1}
+	root.Children = []*Node{{Value: 2, Parent: root}, {Value: 3, Parent: root}}
+
+	got, err := _defaultConv.DeepClone(root)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	clone := got.(*Node)
+	if clone == root || len(clone.Children) != 2 || clone.Children[0] == root.Children[0] {
+		t.Fatalf("should be a deep copy: %v", clone)
+	}
+	for i, child := range clone.Children {
+		if child.Value != i+2 || child.Parent != clone {
+			t.Errorf("unexpected child %v: %v", i, child)
 		}
+	}
 
-		return nil, nil
+	// Values are not converted.
+	got, err = _defaultConv.DeepClone(map[string]interface{}{"a": []interface{}{1, "2"}})
+	if want := map[string]interface{}{"a": []interface{}{1, "2"}}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v, %v", want, got, err)
 	}
 
-	c := &Conv{
-		Conf: Config{
-			CustomConverters: []ConvertFunc{nameStructConverter, namePointerConverter},
-		},
+	_, err = _defaultConv.DeepClone(nil)
+	if err == nil || err.Error() != "conv.DeepClone: the source value should not be nil" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConv_ConvertType_preserveIdentity(t *testing.T) {
+	type Node struct {
+		Name string
+		Next *Node
+	}
+	type Graph struct {
+		A, B *Node
+		C    Node
 	}
 
-	t.Run("StringToName", func(t *testing.T) {
-		var got Name
-		err := c.Convert("John Doe", &got)
+	shared := &Node{Name: "shared"}
+	src := Graph{A: shared, B: shared, C: Node{Name: "c", Next: shared}}
+	c := &Conv{Conf: Config{PreserveIdentity: true}}
+
+	t.Run("shared", func(t *testing.T) {
+		got, err := c.ConvertType(src, reflect.TypeOf(Graph{}))
 		if err != nil {
-			t.Errorf("got error %s", err)
+			t.Fatalf("got error %s", err)
 		}
 
-		want := Name{"John", "Doe"}
-		if got != want {
-			t.Errorf("want %v, got %v", want, got)
+		g := got.(Graph)
+		if g.A == shared || g.A != g.B || g.C.Next != g.A || g.A.Name != "shared" {
+			t.Errorf("unexpected result %+v", g)
+		}
+
+		// Copies by default.
+		got, _ = _defaultConv.ConvertType(src, reflect.TypeOf(Graph{}))
+		if g := got.(Graph); g.A == g.B || !reflect.DeepEqual(g, src) {
+			t.Errorf("unexpected result %+v", g)
 		}
 	})
 
-	t.Run("NameToNamePtr", func(t *testing.T) {
-		got, err := c.ConvertType(Name{"John", "Doe"}, reflect.TypeOf(&Name{}))
+	t.Run("cycle", func(t *testing.T) {
+		a := &Node{Name: "a"}
+		b := &Node{Name: "b", Next: a}
+		a.Next = b
+
+		res, err := c.ConvertType(a, reflect.TypeOf(a))
 		if err != nil {
-			t.Errorf("got error %s", err)
+			t.Fatalf("got error %s", err)
 		}
-
-		n := got.(*Name)
-		want := &Name{"John", "Doe"}
-		if n.FirstName != want.FirstName || n.LastName != want.LastName {
-			t.Errorf("want %v, got %v", want, got)
+		got := res.(*Node)
+		if got == a || got.Name != "a" || got.Next.Name != "b" || got.Next.Next != got {
+			t.Errorf("unexpected result %+v", got)
 		}
 	})
 
-	t.Run("error", func(t *testing.T) {
-		var got Name
-		err := c.Convert("John X Doe", &got)
-		if err == nil {
-			t.Errorf("want error")
+	t.Run("different-types", func(t *testing.T) {
+		type Other struct{ Name string }
+		type T struct {
+			N *Node
+			O *Other
 		}
-
-		want := "conv.Convert: converter[0]: bad name"
-		if err.Error() != want {
-			t.Errorf("want error %s, got %s", want, err)
+		type From struct {
+			N, O *Node
 		}
-	})
 
-	t.Run("default", func(t *testing.T) {
-		var got string
-		err := c.Convert("John Doe", &got)
+		got, err := c.ConvertType(From{shared, shared}, reflect.TypeOf(T{}))
 		if err != nil {
-			t.Errorf("got error %s", err)
+			t.Fatalf("got error %s", err)
+		}
+		if v := got.(T); v.N.Name != "shared" || v.O.Name != "shared" {
+			t.Errorf("unexpected result %+v", v)
 		}
+	})
 
-		want := "John Doe"
-		if got != want {
-			t.Errorf("want %v, got %v", want, got)
+	t.Run("error", func(t *testing.T) {
+		type Num struct{ Name int }
+		type T struct{ A, B *Num }
+
+		_, err := c.ConvertType(Graph{A: shared, B: shared}, reflect.TypeOf(T{}))
+		if err == nil || !strings.Contains(err.Error(), "error on converting field 'A.Name'") {
+			t.Errorf("unexpected error %v", err)
 		}
 	})
 }
@@ -1750,3 +6672,56 @@ func TestConv_tryFlattenEmptyKeyMap(t *testing.T) {
 		})
 	}
 }
+
+func TestConv_tryFlattenKeyMap(t *testing.T) {
+	c := &Conv{Conf: Config{FlattenKeys: []string{"value", "Value", "v"}}}
+
+	type args struct {
+		v      interface{}
+		dstTyp reflect.Type
+	}
+	tests := []struct {
+		name string
+		args args
+		want interface{}
+	}{
+		{"empty-key", args{map[string]interface{}{"": 1}, reflect.TypeOf(0)}, 1},
+		{"empty-key-to-map", args{map[string]interface{}{"": 1}, typStringMap}, 1},
+		{"key1", args{map[string]interface{}{"value": 1}, reflect.TypeOf(0)}, 1},
+		{"key2", args{map[string]interface{}{"v": "a"}, reflect.TypeOf("")}, "a"},
+		{"to-time", args{map[string]interface{}{"v": 1}, typTime}, 1},
+		{"to-slice", args{map[string]interface{}{"v": 1}, reflect.TypeOf([]int{})}, 1},
+		{"other-key", args{map[string]interface{}{"other": 1}, reflect.TypeOf(0)}, nil},
+		{"multiple-keys", args{map[string]interface{}{"value": 1, "v": 2}, reflect.TypeOf(0)}, nil},
+		{"to-map", args{map[string]interface{}{"value": 1}, typStringMap}, nil},
+		{"to-struct", args{map[string]interface{}{"value": 1}, reflect.TypeOf(struct{ Value int }{})}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.tryFlattenKeyMap(tt.args.v, tt.args.dstTyp); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tryFlattenKeyMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("convert", func(t *testing.T) {
+		type T struct {
+			A int
+			B struct{ Value int }
+		}
+		m := map[string]interface{}{
+			"A": map[string]interface{}{"value": "12"},
+			"B": map[string]interface{}{"Value": 34},
+		}
+		got, err := c.ConvertType(m, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want := T{A: 12}
+		want.B.Value = 34
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+}
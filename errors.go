@@ -0,0 +1,136 @@
+package conv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConvError is the error of converting a field of a struct. It contains the full path of the field, so the
+// error of a deeply nested field can be located at once, e.g. 'Items[2].Age' .
+//
+// When converting maps or structs, the *ConvError of a nested field is passed through the outer layers,
+// and is wrapped with the name of the function at the root level, it can be extracted with errors.As() .
+type ConvError struct {
+	// Path is the path of the field, such as 'Items[2].Age' .
+	Path string
+
+	// SrcType is the type of the source value, it is nil if the source value is nil or is unknown, e.g. the error
+	// of a missing required field.
+	SrcType reflect.Type
+
+	// DstType is the type of the field, it is nil if unknown.
+	DstType reflect.Type
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ConvError) Error() string {
+	return fmt.Sprintf("error on converting field '%v': %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ConvError) Unwrap() error {
+	return e.Err
+}
+
+// funcError is an error with the name of the function which returns it, it wraps another error.
+// It is like the one returned by errForFunction() , but keeps the wrapped error for errors.As() .
+type funcError struct {
+	fnName string
+	err    error
+}
+
+func (e *funcError) Error() string {
+	return "conv." + e.fnName + ": " + e.err.Error()
+}
+
+func (e *funcError) Unwrap() error {
+	return e.err
+}
+
+// errUnknownKey is returned by Conv.bindMapValue() for a key matching no field, see Conv.Conf.DisallowUnknownFields .
+var errUnknownKey = errors.New("unknown key")
+
+// passConvError returns the error that should be returned by the function if the given error contains a *ConvError,
+// otherwise returns nil. When converting nested values, the *ConvError itself is returned, since its path is complete;
+// at the root level, the error is wrapped with the function name.
+func (c *Conv) passConvError(fnName string, err error) error {
+	// The errors of a nested struct are passed the same way.
+	var me *MultiError
+	if errors.As(err, &me) {
+		if c.path != "" {
+			return me
+		}
+		return newMultiError(fnName, me.Errors())
+	}
+
+	var ce *ConvError
+	if !errors.As(err, &ce) {
+		return nil
+	}
+
+	if c.path != "" {
+		return ce
+	}
+	return &funcError{fnName, err}
+}
+
+// MultiError is an error that contains multiple errors, e.g. the errors of all failed fields when
+// Conv.Conf.CollectAllFieldErrors is true. The errors are sorted by their messages.
+type MultiError struct {
+	fnName string
+	errs   []error
+}
+
+func newMultiError(fnName string, errs []error) *MultiError {
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+	return &MultiError{fnName, errs}
+}
+
+// Error implements the error interface. The messages of all errors are joined with '; '.
+func (e *MultiError) Error() string {
+	var b strings.Builder
+	b.WriteString("conv.")
+	b.WriteString(e.fnName)
+	b.WriteString(": ")
+
+	for i, err := range e.errs {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Errors returns the errors contained.
+func (e *MultiError) Errors() []error {
+	res := make([]error, len(e.errs))
+	copy(res, e.errs)
+	return res
+}
+
+// ConvErrors returns the *ConvError of each error contained, errors without a *ConvError are skipped.
+// e.g. when Conv.Conf.CollectAllFieldErrors is true, they can be mapped to the fields of a form by their paths.
+func (e *MultiError) ConvErrors() []*ConvError {
+	var res []*ConvError
+	for _, err := range e.errs {
+		var ce *ConvError
+		if errors.As(err, &ce) {
+			res = append(res, ce)
+		}
+	}
+	return res
+}
+
+// Unwrap returns the errors contained, it is used by errors.Is() and errors.As() since Go 1.20 .
+func (e *MultiError) Unwrap() []error {
+	return e.Errors()
+}
@@ -0,0 +1,38 @@
+package conv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMultiError(t *testing.T) {
+	e1 := errors.New("b")
+	e2 := errors.New("a")
+	err := newMultiError("Fn", []error{e1, e2})
+
+	if want := "conv.Fn: a; b"; err.Error() != want {
+		t.Errorf("want %v, got %v", want, err.Error())
+	}
+
+	if got := err.Errors(); !reflect.DeepEqual(got, []error{e2, e1}) {
+		t.Errorf("unexpected errors: %v", got)
+	}
+
+	// The returned slice is a copy.
+	err.Errors()[0] = nil
+	if got := err.Unwrap(); !reflect.DeepEqual(got, []error{e2, e1}) {
+		t.Errorf("unexpected errors: %v", got)
+	}
+}
+
+func TestMultiError_ConvErrors(t *testing.T) {
+	ce1 := &ConvError{Path: "A", Err: errors.New("x")}
+	ce2 := &ConvError{Path: "B", Err: errors.New("y")}
+	err := newMultiError("Fn", []error{ce2, errors.New("plain"), &funcError{"Fn", ce1}})
+
+	got := err.ConvErrors()
+	if want := []*ConvError{ce1, ce2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -2,6 +2,7 @@ package conv
 
 import (
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"unicode"
@@ -43,6 +44,18 @@ type SimpleMatcherConfig struct {
 	//
 	Tag string
 
+	// MatchTagOrName specifies whether a tagged field can also be matched by its raw field name, besides the name
+	// given by the tag. It eases the migration when some sources use Go names and others use tag names.
+	// This field is ignored if Tag is empty.
+	//
+	// e.g. When 'conv' is given as the tag name, both 'new_name' and 'OldName' can match the field:
+	//   type Target struct {
+	//       OldName int `conv:"new_name"`
+	//   }
+	//
+	// If a name can match more than one field, the first declared one is used.
+	MatchTagOrName bool
+
 	// CaseInsensitive specifies whether the matcher matches field names in a case-insensitive manner.
 	// If this field is true, CamelSnakeCase is ignored.
 	//
@@ -90,6 +103,72 @@ func (c *SimpleMatcherCreator) GetMatcher(typ reflect.Type) FieldMatcher {
 	return v.(*simpleMatcher)
 }
 
+// TagName returns the tag name given by SimpleMatcherConfig.Tag .
+// Conv uses it to read the tag options of fields, such as 'readonly'.
+func (c *SimpleMatcherCreator) TagName() string {
+	return c.Conf.Tag
+}
+
+// matcherChainCreator is the FieldMatcherCreator used for Conv.Conf.MatcherChain .
+type matcherChainCreator []FieldMatcherCreator
+
+// GetMatcher implements FieldMatcherCreator.GetMatcher().
+func (mc matcherChainCreator) GetMatcher(typ reflect.Type) FieldMatcher {
+	res := make(matcherChain, len(mc))
+	for i, c := range mc {
+		res[i] = c.GetMatcher(typ)
+	}
+	return res
+}
+
+// TagName returns the first non-empty tag name of the creators.
+func (mc matcherChainCreator) TagName() string {
+	for _, c := range mc {
+		if tn, ok := c.(tagNamer); ok {
+			if name := tn.TagName(); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// matcherChain is a FieldMatcher which tries the matchers in order.
+type matcherChain []FieldMatcher
+
+func (ms matcherChain) MatchField(name string) (reflect.StructField, bool) {
+	f, _, ok := ms.matchFieldAt(name)
+	return f, ok
+}
+
+// matchFieldAt is like MatchField, but also returns the index of the matcher which matches the name.
+func (ms matcherChain) matchFieldAt(name string) (reflect.StructField, int, bool) {
+	for i, m := range ms {
+		if f, ok := m.MatchField(name); ok {
+			return f, i, true
+		}
+	}
+	return reflect.StructField{}, -1, false
+}
+
+// matchFieldWithName calls FieldMatcher.MatchField() and also returns the name of the matcher which matches the
+// name, see Conv.Conf.OnFieldMatch .
+func matchFieldWithName(m FieldMatcher, name string) (reflect.StructField, string, bool) {
+	if chain, ok := m.(matcherChain); ok {
+		f, i, ok := chain.matchFieldAt(name)
+		if !ok {
+			return f, "", false
+		}
+		return f, "MatcherChain[" + strconv.Itoa(i) + "]", true
+	}
+
+	f, ok := m.MatchField(name)
+	if !ok {
+		return f, "", false
+	}
+	return f, "FieldMatcherCreator", true
+}
+
 // simpleMatcher is the FieldMatcher returned by SimpleMatcherCreator.
 type simpleMatcher struct {
 	conf SimpleMatcherConfig // Conf configures the matcher.
@@ -131,6 +210,10 @@ func (ix *simpleMatcher) initFieldMap() {
 		// As FieldMatcher.IndexName() says, it returns the first matched name,
 		// When two field named may be transformed to the same name, we keep the first one.
 		m.LoadOrStore(name, fi)
+
+		if ix.conf.MatchTagOrName && fi.TagValue != "" {
+			m.LoadOrStore(ix.fixName(fi.Name), fi)
+		}
 		return true
 	})
 	ix.fs = m
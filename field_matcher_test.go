@@ -147,6 +147,68 @@ func TestSimpleMatcherCreator_withTag(t *testing.T) {
 	}
 }
 
+func TestSimpleMatcherCreator_matchTagOrName(t *testing.T) {
+	type s struct {
+		Name  string `conv:"user_name"`
+		Age   int    `conv:"Name2"`
+		Name2 string `conv:"Age"` // Collides with the field name of Age, which is declared first.
+		Email string
+	}
+
+	// Disable the warning from static-check.
+	ss := s{}
+	_, _, _, _ = ss.Name, ss.Age, ss.Name2, ss.Email
+
+	ctor := SimpleMatcherCreator{
+		Conf: SimpleMatcherConfig{
+			Tag:            "conv",
+			MatchTagOrName: true,
+		},
+	}
+	typ := reflect.TypeOf(s{})
+
+	tests := []struct {
+		name     string
+		wantName string
+		ok       bool
+	}{
+		{"user_name", "Name", true},
+		{"Name", "Name", true},
+		{"Name2", "Age", true},
+		{"Age", "Age", true}, // First declared.
+		{"Email", "Email", true},
+		{"email", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mather := ctor.GetMatcher(typ)
+			f, ok := mather.MatchField(tt.name)
+			if f.Name != tt.wantName {
+				t.Errorf("MatchField() name = %v, want %v", f.Name, tt.wantName)
+			}
+			if ok != tt.ok {
+				t.Errorf("MatchField() ok = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+
+	t.Run("MapToStruct", func(t *testing.T) {
+		type T struct {
+			UserName string `conv:"user_name"`
+			Age      int    `conv:"age"`
+		}
+
+		c := &Conv{Conf: Config{FieldMatcherCreator: &ctor}}
+		got, err := c.MapToStruct(map[string]interface{}{"user_name": "Bob", "Age": 21}, reflect.TypeOf(T{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := (T{UserName: "Bob", Age: 21}); got != want {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+}
+
 func TestSimpleMatcherCreator_camelSnakeCase(t *testing.T) {
 	type s struct {
 		A, A__, Ab, A_b, A_B, A__B, AaBB, AaBBCc int
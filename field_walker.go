@@ -2,6 +2,7 @@ package conv
 
 import (
 	"reflect"
+	"strings"
 	"sync"
 )
 
@@ -60,11 +61,24 @@ var fieldWalkerCache syncMap
 //	PATH     INDEX    TAG
 //	B        {1}      X
 //	A.A      {0, 0}
+//
+// A tag value can be followed by a group of comma-separated options, like 'name,opt1,opt2', only the name part is
+// used as the name of the field. If the name part is empty, the field is treated as an untagged field.
+// The option 'squash' on an embedded struct makes the traverse go into the struct, even if the name is given:
+//
+//	type T struct {
+//	  A `json:"a,squash"` // The fields of A are read as the fields of T.
+//	}
+//
+// Like encoding/json, a field with the tag value '-' is excluded; use '-,' to name a field '-'.
 type FieldWalker struct {
 	typ     reflect.Type
 	tagName string
 	mu      sync.Mutex
 	fields  []FieldInfo
+
+	// The pairs of paths of ambiguous fields, see ambiguousFields().
+	ambiguous [][2]string
 }
 
 // FieldInfo describes a field in a struct.
@@ -75,8 +89,46 @@ type FieldInfo struct {
 	// the path is a dot-split string like A.B.C; otherwise it's equal to F.Name.
 	Path string
 
-	// The tag value of the field.
+	// The tag value of the field, without the options.
 	TagValue string
+
+	// The options that follow the tag value. e.g. the options of `json:"name,omitempty"` are ['omitempty'].
+	TagOptions TagOptions
+}
+
+// TagOptions is the options part of a tag value. e.g. the options of `json:"name,omitempty,string"` are
+// ['omitempty', 'string'].
+type TagOptions []string
+
+// Has returns true if the given option is present.
+func (o TagOptions) Has(option string) bool {
+	for _, v := range o {
+		if v == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns the value of an option in the form 'name=value', e.g. the value of 'layout' in the options
+// ['layout=2006-01-02'] is '2006-01-02'. The second return value is false if the option is not present.
+func (o TagOptions) Value(name string) (string, bool) {
+	prefix := name + "="
+	for _, v := range o {
+		if strings.HasPrefix(v, prefix) {
+			return v[len(prefix):], true
+		}
+	}
+	return "", false
+}
+
+// parseTag splits a tag value like 'name,opt1,opt2' into the name and the options.
+func parseTag(tag string) (string, TagOptions) {
+	parts := strings.Split(tag, ",")
+	if len(parts) == 1 {
+		return tag, nil
+	}
+	return parts[0], TagOptions(parts[1:])
 }
 
 // NewFieldWalker creates a new instance of FieldWalker.
@@ -132,7 +184,7 @@ func (walker *FieldWalker) WalkValues(value reflect.Value, callback func(FieldIn
 
 	for _, fieldInfo := range walker.fields {
 		index := fieldInfo.Index
-		embedded := fieldInfo.TagValue == "" && len(index) > 1
+		embedded := len(index) > 1
 
 		v := value
 		for i := 0; i < len(index); i++ {
@@ -167,7 +219,9 @@ func (walker *FieldWalker) initFields() {
 	}
 
 	fields := make([]FieldInfo, 0)
-	visited := make(map[string]struct{})
+	// The first field of each name, used to hide the fields with the same name and detect ambiguous fields.
+	visited := make(map[string]visitedField)
+	var ambiguous [][2]string
 
 	type fieldBuf struct {
 		Index []int        // If the current field is an embedded field, stores the field index sequence.
@@ -175,6 +229,18 @@ func (walker *FieldWalker) initFields() {
 		Type  reflect.Type // The type of the current field.
 	}
 
+	// Build the index sequence and path of a field in the struct described by buf.
+	locate := func(buf fieldBuf, f *reflect.StructField) string {
+		index := make([]int, 0, len(buf.Index)+1)
+		index = append(index, buf.Index...)
+		f.Index = append(index, f.Index...)
+
+		if buf.Path == "" {
+			return f.Name
+		}
+		return buf.Path + "." + f.Name
+	}
+
 	// Dequeue and traverse the first element, enqueue the types of embedded structs, then return then new q.
 	traverseOne := func(q []fieldBuf) []fieldBuf {
 		buf, q := q[0], q[1:] // Dequeue.
@@ -193,18 +259,33 @@ func (walker *FieldWalker) initFields() {
 					continue
 				}
 
-				tag := f.Tag.Get(walker.tagName)
-				if tag == "" {
+				name, opts := parseTag(f.Tag.Get(walker.tagName))
+
+				// Like encoding/json, '-' excludes the field, while '-,' names the field '-'.
+				if name == "-" && opts == nil {
+					tagged[i] = true
+					continue
+				}
+
+				if name == "" || (f.Anonymous && opts.Has("squash") && isStructOrStructPtr(f.Type)) {
 					continue
 				}
 
 				tagged[i] = true
-				visited[tag] = struct{}{}
+				path := locate(buf, &f)
+				if v, ok := visited[name]; ok {
+					if v.isAmbiguousWith(buf.Path, len(f.Index)) {
+						ambiguous = append(ambiguous, [2]string{v.path, path})
+					}
+				} else {
+					visited[name] = visitedField{path, buf.Path, len(f.Index)}
+				}
 
 				fields = append(fields, FieldInfo{
 					StructField: f,
-					Path:        f.Name,
-					TagValue:    tag,
+					Path:        path,
+					TagValue:    name,
+					TagOptions:  opts,
 				})
 			}
 		}
@@ -220,18 +301,14 @@ func (walker *FieldWalker) initFields() {
 				continue
 			}
 
-			if _, ok := visited[f.Name]; ok {
+			path := locate(buf, &f)
+			if v, ok := visited[f.Name]; ok {
+				if v.isAmbiguousWith(buf.Path, len(f.Index)) {
+					ambiguous = append(ambiguous, [2]string{v.path, path})
+				}
 				continue
 			}
 
-			// Build the index sequence and path.
-			f.Index = append(buf.Index, f.Index...)
-			path := buf.Path
-			if path != "" {
-				path += "."
-			}
-			path += f.Name
-
 			if f.Anonymous {
 				// Try to extract the underlying type of a pointer.
 				ft := f.Type
@@ -246,10 +323,17 @@ func (walker *FieldWalker) initFields() {
 				}
 			}
 
-			visited[f.Name] = struct{}{}
+			// An untagged field may still have options, e.g. `json:",omitempty"`.
+			var opts TagOptions
+			if walker.tagName != "" {
+				_, opts = parseTag(f.Tag.Get(walker.tagName))
+			}
+
+			visited[f.Name] = visitedField{path, buf.Path, len(f.Index)}
 			fields = append(fields, FieldInfo{
 				StructField: f,
 				Path:        path,
+				TagOptions:  opts,
 			})
 		}
 		return q
@@ -268,5 +352,29 @@ func (walker *FieldWalker) initFields() {
 		}
 	}
 
+	walker.ambiguous = ambiguous
 	walker.fields = fields
 }
+
+// visitedField is used by FieldWalker.initFields() .
+type visitedField struct {
+	path   string // The path of the field.
+	parent string // The path of the struct containing the field, empty for the fields of the root struct.
+	depth  int    // The length of the index sequence of the field.
+}
+
+// isAmbiguousWith returns true if a field with the same name, in the given struct and at the given depth, is
+// ambiguous with this one, i.e. both are at the same depth but in different embedded structs.
+// By Go's promotion rules, neither of them is promoted.
+func (v visitedField) isAmbiguousWith(parent string, depth int) bool {
+	return v.depth == depth && v.parent != parent
+}
+
+// ambiguousFields returns the pairs of paths of ambiguous fields, see visitedField.isAmbiguousWith() .
+// The first field of each pair is the one used by the walker.
+func (walker *FieldWalker) ambiguousFields() [][2]string {
+	if walker.fields == nil {
+		walker.initFields()
+	}
+	return walker.ambiguous
+}
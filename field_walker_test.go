@@ -118,6 +118,54 @@ func TestFieldWalker_WalkFields(t *testing.T) {
 			{"A", "A.A", []int{0, 0}, ""},
 		})
 	})
+
+	t.Run("squash", func(t *testing.T) {
+		type A struct {
+			A1 int `c:"a1"`
+			A2 int
+		}
+		type B struct {
+			B1 int
+		}
+		type T struct {
+			A  `c:"a,squash"` // The name is ignored.
+			*B `c:",squash"`
+			C  int `c:",omitempty"` // Empty name, use the field name.
+			D  int `c:"d,omitempty"`
+		}
+		walker := NewFieldWalker(reflect.TypeOf(T{}), "c")
+		check(t, walker, []want{
+			{"D", "D", []int{3}, "d"},
+			{"C", "C", []int{2}, ""},
+			{"A1", "A.A1", []int{0, 0}, "a1"},
+			{"A2", "A.A2", []int{0, 1}, ""},
+			{"B1", "B.B1", []int{1, 0}, ""},
+		})
+
+		walker.WalkFields(func(fi FieldInfo) bool {
+			if fi.Name == "D" && !reflect.DeepEqual(fi.TagOptions, TagOptions{"omitempty"}) {
+				t.Errorf("want options [omitempty], got %v", fi.TagOptions)
+			}
+			return true
+		})
+	})
+
+	t.Run("dash", func(t *testing.T) {
+		type A struct {
+			A1 int
+		}
+		type T struct {
+			A `c:"-"` // Excluded, the traverse will not go into the field.
+			B int     `c:"-"`
+			C int     `c:"-,"` // Named '-'.
+			D int
+		}
+		walker := NewFieldWalker(reflect.TypeOf(T{}), "c")
+		check(t, walker, []want{
+			{"C", "C", []int{2}, "-"},
+			{"D", "D", []int{3}, ""},
+		})
+	})
 }
 
 func TestFieldWalker_WalkValues(t *testing.T) {
@@ -213,3 +261,76 @@ func TestFieldWalker_WalkValues(t *testing.T) {
 		})
 	})
 }
+
+func TestTagOptions_Value(t *testing.T) {
+	opts := TagOptions{"omitempty", "layout=2006-01-02", "empty=", "layout=ignored"}
+
+	tests := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{"layout", "2006-01-02", true},
+		{"empty", "", true},
+		{"omitempty", "", false},
+		{"absent", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := opts.Value(tt.name); got != tt.want || ok != tt.wantOk {
+			t.Errorf("Value(%v) = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestFieldWalker_ambiguousFields(t *testing.T) {
+	type A struct {
+		ID   int
+		Name string
+		X    int `json:"Tag"`
+	}
+	type B struct {
+		ID  int
+		Tag int
+	}
+	type Inner struct{ ID int }
+	type C struct {
+		Inner
+		Other int
+	}
+
+	tests := []struct {
+		name    string
+		typ     reflect.Type
+		tagName string
+		want    [][2]string
+	}{
+		{"none", reflect.TypeOf(struct {
+			A
+			ID int // Hides A.ID, not ambiguous.
+		}{}), "", nil},
+		{"same-depth", reflect.TypeOf(struct {
+			A
+			*B
+		}{}), "", [][2]string{{"A.ID", "B.ID"}}},
+		{"tagged", reflect.TypeOf(struct {
+			A
+			*B
+		}{}), "json", [][2]string{{"A.ID", "B.ID"}, {"A.X", "B.Tag"}}},
+		{"different-depth", reflect.TypeOf(struct {
+			A
+			C // C.Inner.ID is deeper than A.ID .
+		}{}), "", nil},
+		{"same-tag-in-struct", reflect.TypeOf(struct {
+			X int `json:"Y"`
+			Y int
+		}{}), "json", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewFieldWalker(tt.typ, tt.tagName).ambiguousFields()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package conv
+
+import "reflect"
+
+// To converts the given value to type T. It is equivalent to ToWith[T](new(Conv), src) .
+//
+// e.g.
+//
+//	n, err := conv.To[int]("12")
+func To[T any](src interface{}) (T, error) {
+	return ToWith[T](_defaultConv, src)
+}
+
+// ToWith converts the given value to type T with the given Conv, using Conv.ConvertType() .
+//
+// If the value is already of type T, and T is a primitive type other than string, the value is returned directly
+// without reflection, unless any of Conv.Conf.CustomConverters, Conv.Conf.CustomConvertersEx and
+// Conv.Conf.TypedConverters is given. Strings are returned directly too, if
+// Conv.Conf.TrimStringValues is false and Conv.Conf.NullStrings is empty.
+func ToWith[T any](c *Conv, src interface{}) (T, error) {
+	if v, ok := src.(T); ok && c.canReturnDirectly(src) {
+		return v, nil
+	}
+
+	var res T
+	typ := reflect.TypeOf(&res).Elem()
+
+	v, err := c.ConvertType(src, typ)
+	if err != nil || v == nil {
+		return res, err
+	}
+
+	// A custom converter may return a value of another type.
+	res, ok := v.(T)
+	if !ok {
+		return res, errForFunction("ToWith", "the converted value is %T, not %v", v, typ)
+	}
+	return res, nil
+}
+
+// MustTo is like To() but panics instead of returns an error.
+func MustTo[T any](src interface{}) T {
+	res, err := To[T](src)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// SliceTo converts the given value to []T , the value is usually a slice or an array.
+// It is equivalent to To[[]T](src) .
+func SliceTo[T any](src interface{}) ([]T, error) {
+	return To[[]T](src)
+}
+
+// MapTo converts the given value to map[K]V , the value is usually a map or a struct.
+// It is equivalent to To[map[K]V](src) .
+func MapTo[K comparable, V any](src interface{}) (map[K]V, error) {
+	return To[map[K]V](src)
+}
+
+// RegisterConverterFor is the generic version of Conv.RegisterConverter() , it registers the function for type T.
+//
+// e.g.
+//
+//	conv.RegisterConverterFor(c, func(v interface{}) (net.IP, error) { ... })
+func RegisterConverterFor[T any](c *Conv, f func(value interface{}) (T, error)) {
+	var zero T
+	c.RegisterConverter(reflect.TypeOf(&zero).Elem(), func(value interface{}, _ reflect.Type) (interface{}, error) {
+		res, err := f(value)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	})
+}
+
+// canReturnDirectly returns true if converting the primitive value to its own type returns the value as is,
+// see ToWith() .
+func (c *Conv) canReturnDirectly(src interface{}) bool {
+	if len(c.Conf.CustomConverters) > 0 || len(c.Conf.CustomConvertersEx) > 0 || len(c.Conf.TypedConverters) > 0 {
+		return false
+	}
+
+	switch src.(type) {
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	case string:
+		return !c.Conf.TrimStringValues && len(c.Conf.NullStrings) == 0
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package conv
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTo(t *testing.T) {
+	t.Run("primitives", func(t *testing.T) {
+		if got, err := To[int]("12"); err != nil || got != 12 {
+			t.Errorf("want 12, got %v, %v", got, err)
+		}
+		if got, err := To[string](1.5); err != nil || got != "1.5" {
+			t.Errorf("want 1.5, got %v, %v", got, err)
+		}
+		if got, err := To[bool]("true"); err != nil || !got {
+			t.Errorf("want true, got %v, %v", got, err)
+		}
+		if got, err := To[*int](nil); err != nil || got != nil {
+			t.Errorf("want nil, got %v, %v", got, err)
+		}
+		if _, err := To[int]("x"); err == nil || !strings.HasPrefix(err.Error(), "conv.ConvertType:") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("same-type", func(t *testing.T) {
+		if got, err := To[int](3); err != nil || got != 3 {
+			t.Errorf("want 3, got %v, %v", got, err)
+		}
+
+		// The options still apply to strings.
+		c := &Conv{Conf: Config{TrimStringValues: true}}
+		if got, err := ToWith[string](c, " a "); err != nil || got != "a" {
+			t.Errorf("want a, got %v, %v", got, err)
+		}
+
+		// The custom converters still apply.
+		c = &Conv{Conf: Config{CustomConverters: []ConvertFunc{
+			func(value interface{}, typ reflect.Type) (interface{}, error) {
+				if typ.Kind() == reflect.Int {
+					return 100, nil
+				}
+				return nil, nil
+			},
+		}}}
+		if got, err := ToWith[int](c, 3); err != nil || got != 100 {
+			t.Errorf("want 100, got %v, %v", got, err)
+		}
+
+		// A misbehaving converter returns a value of another type.
+		c = &Conv{Conf: Config{CustomConverters: []ConvertFunc{
+			func(value interface{}, typ reflect.Type) (interface{}, error) { return "x", nil },
+		}}}
+		got, err := ToWith[int](c, 3)
+		if want := "conv.ToWith: the converted value is string, not int"; err == nil || err.Error() != want || got != 0 {
+			t.Errorf("want error %v, got %v, %v", want, got, err)
+		}
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		type User struct {
+			Name string
+			Age  int
+		}
+
+		got, err := To[User](map[string]interface{}{"Name": "Bob", "Age": "12"})
+		if want := (User{"Bob", 12}); err != nil || got != want {
+			t.Errorf("want %v, got %v, %v", want, got, err)
+		}
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		got, err := To[interface{}](nil)
+		if err != nil || got != nil {
+			t.Errorf("want nil, got %v, %v", got, err)
+		}
+	})
+}
+
+func TestMustTo(t *testing.T) {
+	if got := MustTo[uint8]("255"); got != 255 {
+		t.Errorf("want 255, got %v", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("should panic")
+		}
+	}()
+	MustTo[uint8]("256")
+}
+
+func TestSliceTo(t *testing.T) {
+	got, err := SliceTo[int]([]string{"1", "2"})
+	if want := []int{1, 2}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v, %v", want, got, err)
+	}
+
+	got, err = SliceTo[int]([2]interface{}{3, "4"})
+	if want := []int{3, 4}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v, %v", want, got, err)
+	}
+
+	if _, err := SliceTo[int]([]string{"x"}); err == nil {
+		t.Errorf("want error")
+	}
+}
+
+func TestMapTo(t *testing.T) {
+	got, err := MapTo[string, int](map[string]string{"a": "1"})
+	if want := map[string]int{"a": 1}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v, %v", want, got, err)
+	}
+
+	type T struct{ A, B int }
+	anyMap, err := MapTo[string, interface{}](T{1, 2})
+	if want := map[string]interface{}{"A": 1, "B": 2}; err != nil || !reflect.DeepEqual(anyMap, want) {
+		t.Errorf("want %v, got %v, %v", want, anyMap, err)
+	}
+
+	if _, err := MapTo[int, int](map[string]int{"x": 1}); err == nil {
+		t.Errorf("want error")
+	}
+}
+
+func TestRegisterConverterFor(t *testing.T) {
+	type Celsius float64
+
+	c := new(Conv)
+	RegisterConverterFor(c, func(v interface{}) (Celsius, error) {
+		s, ok := v.(string)
+		if !ok || !strings.HasSuffix(s, "C") {
+			return 0, errors.New("bad temperature")
+		}
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s, "C"), 64)
+		return Celsius(f), err
+	})
+
+	got, err := ToWith[Celsius](c, "36.5C")
+	if err != nil || got != 36.5 {
+		t.Errorf("unexpected result %v, %v", got, err)
+	}
+
+	// Values other than strings are rejected by the converter.
+	if _, err := ToWith[Celsius](c, 1); err == nil || !strings.Contains(err.Error(), "bad temperature") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
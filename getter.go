@@ -0,0 +1,143 @@
+package conv
+
+import (
+	"reflect"
+)
+
+var getterCache syncMap
+
+// getter describes a getter method of a struct, which is used when Conv.Conf.IncludeGetters is true.
+//
+// A getter is an exported method of the pointer to the struct, with no parameter and one return value which is
+// not an error. Methods returning an error, such as 'Close() error', usually have side effects, they are excluded.
+// The name of the method is used as the name of the getter, e.g. 'Name()' is read as 'Name'.
+// Methods promoted from embedded structs, interfaces or pointers are included, at any depth.
+type getter struct {
+	name string
+
+	// The index of the method in the method set of the pointer to the struct.
+	index int
+
+	// The index sequence of the embedded fields which the method is promoted from, nil if the method is not
+	// promoted. The method can't be called if any interface or pointer on the path is nil.
+	embedded []int
+}
+
+// getGetters returns the getters of the given type of struct.
+// Methods which have the same name as a field of the struct are excluded, the field takes precedence.
+func getGetters(structTyp reflect.Type) []getter {
+	if v, ok := getterCache.Load(structTyp); ok {
+		return v.([]getter)
+	}
+
+	var res []getter
+	ptrTyp := reflect.PtrTo(structTyp)
+	for i := 0; i < ptrTyp.NumMethod(); i++ {
+		m := ptrTyp.Method(i)
+
+		// The first parameter is the receiver.
+		if m.Type.NumIn() != 1 || m.Type.NumOut() != 1 || m.Type.Out(0) == typError {
+			continue
+		}
+
+		if _, ok := structTyp.FieldByName(m.Name); ok {
+			continue
+		}
+
+		res = append(res, getter{
+			name:     m.Name,
+			index:    i,
+			embedded: findMethodProvider(structTyp, m.Name),
+		})
+	}
+
+	v, _ := getterCache.LoadOrStore(structTyp, res)
+	return v.([]getter)
+}
+
+// findMethodProvider returns the index sequence of the embedded fields which provides the method with the given
+// name, or nil if the method is not promoted from an embedded field.
+// Like the promotion rules of Go, the shallowest embedded field is used.
+func findMethodProvider(structTyp reflect.Type, name string) []int {
+	for i := 0; i < structTyp.NumField(); i++ {
+		f := structTyp.Field(i)
+		if !f.Anonymous {
+			continue
+		}
+
+		switch f.Type.Kind() {
+		case reflect.Interface:
+			if _, ok := f.Type.MethodByName(name); ok {
+				return []int{i}
+			}
+
+		case reflect.Ptr, reflect.Struct:
+			// The struct is addressable as a field of an addressable struct, use the method set of the pointer.
+			ft := f.Type
+			if ft.Kind() == reflect.Struct {
+				ft = reflect.PtrTo(ft)
+			}
+
+			if _, ok := ft.MethodByName(name); !ok {
+				continue
+			}
+
+			// The method may be promoted from a deeper field.
+			if elem := ft.Elem(); elem.Kind() == reflect.Struct {
+				if deeper := findMethodProvider(elem, name); deeper != nil {
+					return append([]int{i}, deeper...)
+				}
+			}
+			return []int{i}
+		}
+	}
+	return nil
+}
+
+// canCallGetter returns false if an interface or a pointer on the path of the embedded fields is nil.
+func canCallGetter(v reflect.Value, path []int) bool {
+	for _, i := range path {
+		v = v.Field(i)
+		switch v.Kind() {
+		case reflect.Interface:
+			return !v.IsNil()
+
+		case reflect.Ptr:
+			if v.IsNil() {
+				return false
+			}
+			v = v.Elem()
+		}
+	}
+	return true
+}
+
+// walkGetters calls the getters of the given struct value, and passes the results to the callback.
+// Getters promoted from nil embedded fields are skipped.
+// The walking stops if the callback returns false.
+func walkGetters(v reflect.Value, callback func(name string, value reflect.Value) bool) {
+	getters := getGetters(v.Type())
+	if len(getters) == 0 {
+		return
+	}
+
+	// Methods on the pointer need an addressable value.
+	var ptr reflect.Value
+	if v.CanAddr() {
+		ptr = v.Addr()
+	} else {
+		ptr = reflect.New(v.Type())
+		ptr.Elem().Set(v)
+	}
+
+	for _, g := range getters {
+		if !canCallGetter(v, g.embedded) {
+			continue
+		}
+
+		res := ptr.Method(g.index).Call(nil)
+		if !callback(g.name, res[0]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package conv
+
+import (
+	"reflect"
+	"testing"
+)
+
+type getterNamer interface {
+	Name() string
+}
+
+type getterName string
+
+func (n getterName) Name() string { return string(n) }
+
+type getterSource struct {
+	getterNamer
+	Title  string
+	age    int
+	closed *bool // Set by Close().
+}
+
+func (s *getterSource) Age() int           { return s.age }
+func (s getterSource) Upper() []string     { return []string{s.Title} }
+func (s *getterSource) SetAge(v int)       { s.age = v }
+func (s *getterSource) Pair() (int, error) { return 0, nil }
+func (s *getterSource) Close() error {
+	*s.closed = true
+	return nil
+}
+
+type getterChan struct{}
+
+func (g *getterChan) Ch() chan int { return nil }
+
+type getterInner struct {
+	getterNamer
+}
+
+type getterOuter struct {
+	*getterInner
+	Title string
+}
+
+func Test_getGetters(t *testing.T) {
+	gs := getGetters(reflect.TypeOf(getterSource{}))
+
+	var names []string
+	for _, g := range gs {
+		names = append(names, g.name)
+	}
+
+	want := []string{"Age", "Name", "Upper"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("want %v, got %v", want, names)
+	}
+
+	if !reflect.DeepEqual(gs[1].embedded, []int{0}) || gs[0].embedded != nil {
+		t.Errorf("unexpected embedded index: %v", gs)
+	}
+
+	gs = getGetters(reflect.TypeOf(getterOuter{}))
+	if len(gs) != 1 || gs[0].name != "Name" || !reflect.DeepEqual(gs[0].embedded, []int{0, 0}) {
+		t.Errorf("unexpected getters: %v", gs)
+	}
+}
+
+func TestConv_includeGetters(t *testing.T) {
+	c := &Conv{Conf: Config{IncludeGetters: true}}
+	src := getterSource{getterNamer: getterName("n"), Title: "t", age: 12}
+
+	t.Run("StructToMap", func(t *testing.T) {
+		got, err := c.StructToMap(src)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want := map[string]interface{}{
+			"Title": "t",
+			"Age":   12,
+			"Name":  "n",
+			"Upper": []string{"t"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("StructToStruct", func(t *testing.T) {
+		type Dst struct {
+			Title string
+			Name  string
+			Age   string
+		}
+
+		got, err := c.StructToStruct(src, reflect.TypeOf(Dst{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want := Dst{Title: "t", Name: "n", Age: "12"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("nil-embedded", func(t *testing.T) {
+		got, err := c.StructToMap(getterSource{Title: "t"})
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		if _, ok := got["Name"]; ok {
+			t.Errorf("the getter of a nil interface should be skipped")
+		}
+		if got["Age"] != 0 {
+			t.Errorf("want Age 0, got %v", got["Age"])
+		}
+	})
+
+	t.Run("nested-nil-embedded", func(t *testing.T) {
+		got, err := c.StructToMap(getterOuter{getterInner: &getterInner{}, Title: "t"})
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		if want := map[string]interface{}{"Title": "t"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+
+		got, err = c.StructToMap(getterOuter{getterInner: &getterInner{getterName("n")}})
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		if got["Name"] != "n" {
+			t.Errorf("want Name n, got %v", got["Name"])
+		}
+	})
+
+	t.Run("error-methods", func(t *testing.T) {
+		closed := false
+		got, err := c.StructToMap(getterSource{Title: "t", closed: &closed})
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		if _, ok := got["Close"]; ok || closed {
+			t.Errorf("methods returning an error should not be called")
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := c.StructToMap(getterChan{})
+		if want := "conv.StructToMap: error on converting getter 'Ch': must be a simple type, got chan"; err == nil || err.Error() != want {
+			t.Errorf("want %v, got %v", want, err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		got, err := _defaultConv.StructToMap(src)
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		if _, ok := got["Age"]; ok {
+			t.Errorf("getters should be ignored")
+		}
+	})
+}
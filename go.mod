@@ -1,3 +1,3 @@
 module github.com/cmstar/go-conv
 
-go 1.16
+go 1.18
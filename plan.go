@@ -0,0 +1,274 @@
+package conv
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	planCache     syncMap
+	locationCache syncMap
+
+	// The FieldMatcherCreator used if Conv.Conf.FieldMatcherCreator is nil, shared so that the matchers are cached.
+	_defaultMatcherCreator = new(SimpleMatcherCreator)
+)
+
+// planKey is the key of planCache.
+type planKey struct {
+	src, dst reflect.Type
+}
+
+// planFunc converts a value to the destination type of a convPlan, the value is not nil.
+type planFunc func(c *Conv, src interface{}) (interface{}, error)
+
+// failPlan returns the planFunc which always fails.
+func failPlan(srcTyp, dstTyp reflect.Type) planFunc {
+	return func(c *Conv, src interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("cannot convert %v to %v", srcTyp, dstTyp)
+	}
+}
+
+// when returns the planFunc which calls f if the conversion is enabled by Conv.Conf , otherwise calls fallback.
+func (f planFunc) when(enabled func(c *Conv) bool, fallback planFunc) planFunc {
+	return func(c *Conv, src interface{}) (interface{}, error) {
+		if enabled(c) {
+			return f(c, src)
+		}
+		return fallback(c, src)
+	}
+}
+
+func mapKVSlicesEnabled(c *Conv) bool { return c.Conf.MapKVSlices }
+
+func drainChannelsEnabled(c *Conv) bool { return c.Conf.DrainChannels }
+
+// convPlan is the compiled conversion from a source type to a destination type, see getPlan() .
+// It is decided by the kinds of the types only once, the steps depending on Conv.Conf are still checked on each
+// call by the compiled function.
+type convPlan struct {
+	// convert converts a value of the source type to the destination type.
+	convert planFunc
+
+	// The structMeta of the destination struct, for each structMetaKey .
+	structs syncMap
+}
+
+// structMetaKey identifies a structMeta, since the metadata depends on the tag name and Conv.Conf.RequireAllFields .
+type structMetaKey struct {
+	tagName    string
+	requireAll bool
+}
+
+// structMeta holds the fields with special tag options of a struct, which are used by MapToStruct() .
+type structMeta struct {
+	rawField      *FieldInfo  // The field with the 'raw' tag option, see Conv.findRawField() .
+	rawErr        error       // The error returned by Conv.findRawField() .
+	checkedFields []FieldInfo // See Conv.findRequiredFields() .
+	defaultFields []FieldInfo // See Conv.findDefaultFields() .
+}
+
+// getPlan returns the convPlan from srcTyp to dstTyp, both are not pointers.
+func getPlan(srcTyp, dstTyp reflect.Type) *convPlan {
+	key := planKey{srcTyp, dstTyp}
+	if v, ok := planCache.Load(key); ok {
+		return v.(*convPlan)
+	}
+
+	v, _ := planCache.LoadOrStore(key, &convPlan{convert: compilePlan(srcTyp, dstTyp)})
+	return v.(*convPlan)
+}
+
+// structMeta returns the structMeta of the destination struct for the given Conv.
+func (p *convPlan) structMeta(c *Conv, structTyp reflect.Type) *structMeta {
+	key := structMetaKey{c.tagName(), c.Conf.RequireAllFields}
+	if v, ok := p.structs.Load(key); ok {
+		return v.(*structMeta)
+	}
+
+	meta := &structMeta{
+		checkedFields: c.findRequiredFields(structTyp),
+		defaultFields: c.findDefaultFields(structTyp),
+	}
+	meta.rawField, meta.rawErr = c.findRawField(structTyp)
+
+	v, _ := p.structs.LoadOrStore(key, meta)
+	return v.(*structMeta)
+}
+
+// compilePlan decides the conversion from srcTyp to dstTyp by the kinds of the types. It is the last step of
+// Conv.convertToNonPtr() , see the document of Conv.ConvertType() for the conversions.
+func compilePlan(srcTyp, dstTyp reflect.Type) planFunc {
+	srcKind, dstKind := srcTyp.Kind(), dstTyp.Kind()
+	if getTypeInfo(dstTyp).simple && getTypeInfo(srcTyp).simple {
+		return func(c *Conv, src interface{}) (interface{}, error) {
+			return c.SimpleToSimple(src, dstTyp)
+		}
+	}
+
+	// string <-> []byte, with Conv.Conf.ByteSliceEncoding .
+	if srcKind == reflect.String && isByteSlice(dstTyp) {
+		next := compileKindPlan(srcTyp, dstTyp)
+		return func(c *Conv, src interface{}) (interface{}, error) {
+			enc := c.Conf.ByteSliceEncoding
+			if enc == "" {
+				return next(c, src)
+			}
+
+			b, err := decodeString(enc, reflect.ValueOf(src).String())
+			if err != nil {
+				return nil, err
+			}
+			return reflect.ValueOf(b).Convert(dstTyp).Interface(), nil
+		}
+	}
+
+	if dstKind == reflect.String && isByteSlice(srcTyp) {
+		next := failPlan(srcTyp, dstTyp)
+		// []byte -> string, the bytes are decoded as UTF-8, like string([]byte) .
+		if srcTyp.ConvertibleTo(dstTyp) {
+			next = func(c *Conv, src interface{}) (interface{}, error) {
+				return reflect.ValueOf(src).Convert(dstTyp).Interface(), nil
+			}
+		}
+
+		return func(c *Conv, src interface{}) (interface{}, error) {
+			enc := c.Conf.ByteSliceEncoding
+			if enc == "" {
+				return next(c, src)
+			}
+
+			s, err := encodeBytes(enc, reflect.ValueOf(src).Bytes())
+			if err != nil {
+				return nil, err
+			}
+			return reflect.ValueOf(s).Convert(dstTyp).Interface(), nil
+		}
+	}
+
+	return compileKindPlan(srcTyp, dstTyp)
+}
+
+// compileKindPlan is the part of compilePlan() for maps, structs, slices and arrays.
+func compileKindPlan(srcTyp, dstTyp reflect.Type) planFunc {
+	srcKind, dstKind := srcTyp.Kind(), dstTyp.Kind()
+	fail := failPlan(srcTyp, dstTyp)
+
+	switch {
+	case srcKind == reflect.Map:
+		var next planFunc
+		switch dstKind {
+		// map -> map
+		case reflect.Map:
+			next = func(c *Conv, src interface{}) (interface{}, error) { return c.MapToMap(src, dstTyp) }
+
+		// map -> []struct{Key; Value}
+		case reflect.Slice:
+			next = planFunc(func(c *Conv, src interface{}) (interface{}, error) {
+				return c.MapToKVSlice(src, dstTyp)
+			}).when(mapKVSlicesEnabled, fail)
+
+		// map[string]ANY -> struct
+		// Other maps, such as map[interface{}]interface{} produced by YAML decoders, are converted to
+		// map[string]interface{} first. Nested maps are handled the same way when converting the fields.
+		case reflect.Struct:
+			next = func(c *Conv, src interface{}) (interface{}, error) {
+				mm, ok := src.(map[string]interface{})
+				if !ok {
+					var err error
+					mm, err = c.stringKeyMap(src)
+					if err != nil {
+						return nil, fmt.Errorf("when converting a map to a struct, %v", err)
+					}
+				}
+				return c.MapToStruct(mm, dstTyp)
+			}
+
+		default:
+			next = fail
+		}
+
+		return func(c *Conv, src interface{}) (interface{}, error) {
+			// map[string]ANY { "": value } -> ConvertType(value)
+			if underlyingValue := c.tryFlattenKeyMap(src, dstTyp); underlyingValue != nil {
+				return c.ConvertType(underlyingValue, dstTyp)
+			}
+			return next(c, src)
+		}
+
+	case srcKind == reflect.Struct:
+		switch dstKind {
+		case reflect.Map:
+			return func(c *Conv, src interface{}) (interface{}, error) {
+				m, err := c.StructToMap(src)
+				if err != nil || dstTyp == typStringMap {
+					return m, err
+				}
+
+				// e.g. map[string]string, the names and values are converted to the types of the destination map.
+				return c.MapToMap(m, dstTyp)
+			}
+
+		case reflect.Struct:
+			return func(c *Conv, src interface{}) (interface{}, error) { return c.StructToStruct(src, dstTyp) }
+		}
+
+	case dstKind == reflect.Slice:
+		switch srcKind {
+		// string -> []simple
+		case reflect.String:
+			return func(c *Conv, src interface{}) (interface{}, error) {
+				return c.StringToSlice(reflect.ValueOf(src).String(), dstTyp)
+			}
+
+		// [N]ANY -> []ANY
+		case reflect.Slice, reflect.Array:
+			return func(c *Conv, src interface{}) (interface{}, error) { return c.SliceToSlice(src, dstTyp) }
+
+		// chan -> []ANY
+		case reflect.Chan:
+			return planFunc(func(c *Conv, src interface{}) (interface{}, error) {
+				elems, err := drainChannel(reflect.ValueOf(src))
+				if err != nil {
+					return nil, err
+				}
+
+				if elems == nil {
+					return reflect.Zero(dstTyp).Interface(), nil
+				}
+				return c.SliceToSlice(elems, dstTyp)
+			}).when(drainChannelsEnabled, fail)
+		}
+
+	// []ANY -> [N]ANY, [N]ANY -> [M]ANY
+	case dstKind == reflect.Array:
+		if srcKind == reflect.Slice || srcKind == reflect.Array {
+			return func(c *Conv, src interface{}) (interface{}, error) { return c.SliceToArray(src, dstTyp) }
+		}
+
+	// []struct{Key; Value} -> map
+	case dstKind == reflect.Map:
+		if srcKind == reflect.Slice || srcKind == reflect.Array {
+			return planFunc(func(c *Conv, src interface{}) (interface{}, error) {
+				return c.KVSliceToMap(src, dstTyp)
+			}).when(mapKVSlicesEnabled, fail)
+		}
+	}
+
+	return fail
+}
+
+// loadLocation is like time.LoadLocation() , but the locations are cached.
+func loadLocation(name string) (*time.Location, error) {
+	if v, ok := locationCache.Load(name); ok {
+		return v.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	v, _ := locationCache.LoadOrStore(name, loc)
+	return v.(*time.Location), nil
+}
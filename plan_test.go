@@ -0,0 +1,91 @@
+package conv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_getPlan(t *testing.T) {
+	type T struct{ A int }
+	typT := reflect.TypeOf(T{})
+
+	p := getPlan(typStringMap, typT)
+	if getPlan(typStringMap, typT) != p {
+		t.Errorf("should be cached")
+	}
+
+	got, err := p.convert(_defaultConv, map[string]interface{}{"A": "1"})
+	if err != nil || got != (T{A: 1}) {
+		t.Errorf("unexpected result %v, %v", got, err)
+	}
+
+	// The steps depending on Conv.Conf are checked on each call.
+	typKV := reflect.TypeOf([]struct{ Key, Value int }{})
+	p = getPlan(reflect.TypeOf(map[int]int{}), typKV)
+	if _, err := p.convert(_defaultConv, map[int]int{1: 2}); err == nil {
+		t.Errorf("want error without MapKVSlices")
+	}
+
+	got, err = p.convert(&Conv{Conf: Config{MapKVSlices: true}}, map[int]int{1: 2})
+	if err != nil || !reflect.DeepEqual(got, []struct{ Key, Value int }{{1, 2}}) {
+		t.Errorf("unexpected result %v, %v", got, err)
+	}
+
+	_, err = getPlan(reflect.TypeOf(0), typT).convert(_defaultConv, 1)
+	if want := "cannot convert int to conv.T"; err == nil || err.Error() != want {
+		t.Errorf("want %v, got %v", want, err)
+	}
+}
+
+func Test_convPlan_structMeta(t *testing.T) {
+	type T struct {
+		A   int                    `conv:"a,required"`
+		B   int                    `conv:"b,default=1"`
+		C   int                    `conv:"c"`
+		Raw map[string]interface{} `conv:",raw"`
+	}
+	typT := reflect.TypeOf(T{})
+	p := getPlan(typStringMap, typT)
+
+	meta := p.structMeta(_tagConv, typT)
+	if p.structMeta(_tagConv, typT) != meta {
+		t.Errorf("should be cached")
+	}
+
+	if meta.rawErr != nil || meta.rawField == nil || meta.rawField.Name != "Raw" {
+		t.Errorf("unexpected raw field %v, %v", meta.rawField, meta.rawErr)
+	}
+	if len(meta.checkedFields) != 1 || meta.checkedFields[0].Name != "A" {
+		t.Errorf("unexpected checked fields %v", meta.checkedFields)
+	}
+	if len(meta.defaultFields) != 1 || meta.defaultFields[0].Name != "B" {
+		t.Errorf("unexpected default fields %v", meta.defaultFields)
+	}
+
+	// Depends on RequireAllFields.
+	c := &Conv{Conf: _tagConv.Conf}
+	c.Conf.RequireAllFields = true
+	if n := len(p.structMeta(c, typT).checkedFields); n != 3 {
+		t.Errorf("want 3 checked fields, got %v", n)
+	}
+
+	// Depends on the tag name.
+	if meta := p.structMeta(_defaultConv, typT); meta.rawField != nil || len(meta.defaultFields) != 0 {
+		t.Errorf("tags should be ignored without a tag name")
+	}
+}
+
+func Test_loadLocation(t *testing.T) {
+	loc, err := loadLocation("UTC")
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+
+	if again, _ := loadLocation("UTC"); again != loc {
+		t.Errorf("should be cached")
+	}
+
+	if _, err := loadLocation("No/Such_Zone"); err == nil {
+		t.Errorf("want error")
+	}
+}
@@ -0,0 +1,101 @@
+package conv
+
+import (
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+var setterCache syncMap
+
+// setters describes the setter methods of a struct, which are used when Conv.Conf.UseSetters is true.
+//
+// A setter is an exported method of the pointer to the struct, with a name like 'SetXxx' and one parameter.
+// 'Xxx' is the name of the setter, it must start with an uppercase letter.
+// The method can return nothing or an error, a non-nil error is returned by the conversion.
+type setters struct {
+	// A struct type whose fields are named with the names of the setters, the type of each field is the
+	// type of the parameter. It is used with FieldMatcher, thus the setters are matched the same way as fields.
+	typ reflect.Type
+
+	// The setter methods, the keys are the names of the setters.
+	methods map[string]reflect.Method
+}
+
+// getSetters returns the setters of the given type of struct.
+func getSetters(structTyp reflect.Type) *setters {
+	if v, ok := setterCache.Load(structTyp); ok {
+		return v.(*setters)
+	}
+
+	s := &setters{
+		methods: make(map[string]reflect.Method),
+	}
+
+	var fields []reflect.StructField
+	ptrTyp := reflect.PtrTo(structTyp)
+	for i := 0; i < ptrTyp.NumMethod(); i++ {
+		m := ptrTyp.Method(i)
+		if len(m.Name) <= 3 || m.Name[:3] != "Set" {
+			continue
+		}
+
+		name := m.Name[3:]
+		if r, _ := utf8.DecodeRuneInString(name); !unicode.IsUpper(r) {
+			continue
+		}
+
+		// The first parameter is the receiver.
+		if m.Type.NumIn() != 2 {
+			continue
+		}
+
+		switch m.Type.NumOut() {
+		case 0:
+		case 1:
+			if m.Type.Out(0) != typError {
+				continue
+			}
+		default:
+			continue
+		}
+
+		s.methods[name] = m
+		fields = append(fields, reflect.StructField{
+			Name: name,
+			Type: m.Type.In(1),
+		})
+	}
+
+	s.typ = reflect.StructOf(fields)
+	v, _ := setterCache.LoadOrStore(structTyp, s)
+	return v.(*setters)
+}
+
+// setBySetter tries to find a setter matches the given name, and calls it with the value converted to the type of
+// its parameter. The dst must be an addressable struct.
+// Returns false if no setter matches the name.
+func (c *Conv) setBySetter(dst reflect.Value, name string, value interface{}) (bool, error) {
+	s := getSetters(dst.Type())
+	if len(s.methods) == 0 {
+		return false, nil
+	}
+
+	matcher := c.fieldMatcherCreator().GetMatcher(s.typ)
+	field, ok := matcher.MatchField(name)
+	if !ok {
+		return false, nil
+	}
+
+	m := s.methods[field.Name]
+	arg, err := c.at(field.Name).ConvertType(value, m.Type.In(1))
+	if err != nil {
+		return true, err
+	}
+
+	res := dst.Addr().Method(m.Index).Call([]reflect.Value{valueOrZero(arg, m.Type.In(1))})
+	if len(res) == 1 && !res[0].IsNil() {
+		return true, res[0].Interface().(error)
+	}
+	return true, nil
+}
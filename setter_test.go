@@ -0,0 +1,120 @@
+package conv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type setterTarget struct {
+	Exported string
+	name     string
+	age      int
+	tags     []string
+	any      interface{}
+	email    string
+}
+
+func (s *setterTarget) SetName(v string)     { s.name = v }
+func (s *setterTarget) SetAge(v int)         { s.age = v }
+func (s *setterTarget) SetTags(v []string)   { s.tags = v }
+func (s *setterTarget) SetAny(v interface{}) { s.any = v }
+func (s *setterTarget) SetExported(v string) { s.Exported = "setter:" + v }
+func (s *setterTarget) Setup()               {}
+func (s *setterTarget) SetEmail(v string) error {
+	if !strings.Contains(v, "@") {
+		return errors.New("bad email")
+	}
+	s.email = v
+	return nil
+}
+func (s *setterTarget) Setlower(v string)       {}
+func (s *setterTarget) SetTwo(a, b string)      {}
+func (s *setterTarget) SetResult(v string) bool { return true }
+
+func Test_getSetters(t *testing.T) {
+	s := getSetters(reflect.TypeOf(setterTarget{}))
+
+	var names []string
+	for i := 0; i < s.typ.NumField(); i++ {
+		names = append(names, s.typ.Field(i).Name)
+	}
+
+	want := []string{"Age", "Any", "Email", "Exported", "Name", "Tags"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("want %v, got %v", want, names)
+	}
+
+	if s2 := getSetters(reflect.TypeOf(setterTarget{})); s2 != s {
+		t.Errorf("should be cached")
+	}
+}
+
+func TestConv_MapToStruct_useSetters(t *testing.T) {
+	m := map[string]interface{}{
+		"Exported": "e",
+		"name":     "Bob",
+		"AGE":      "51",
+		"Tags":     []interface{}{"a", "b"},
+		"Any":      nil,
+		"Setup":    1,
+		"lower":    "x",
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		got, err := _caseInsensitiveConv.MapToStruct(m, reflect.TypeOf(setterTarget{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		want := setterTarget{Exported: "e"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %#v, got %#v", want, got)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		c := &Conv{Conf: _caseInsensitiveConv.Conf}
+		c.Conf.UseSetters = true
+
+		got, err := c.MapToStruct(m, reflect.TypeOf(setterTarget{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+
+		// The field is preferred to the setter.
+		want := setterTarget{Exported: "e", name: "Bob", age: 51, tags: []string{"a", "b"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %#v, got %#v", want, got)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		c := &Conv{Conf: Config{UseSetters: true}}
+
+		_, err := c.MapToStruct(map[string]interface{}{"Age": "x"}, reflect.TypeOf(setterTarget{}))
+		const wantPrefix = "conv.MapToStruct: error on calling the setter of 'Age': "
+		if err == nil || len(err.Error()) < len(wantPrefix) || err.Error()[:len(wantPrefix)] != wantPrefix {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("setter-returns-error", func(t *testing.T) {
+		c := &Conv{Conf: Config{UseSetters: true}}
+
+		got, err := c.MapToStruct(map[string]interface{}{"Email": "a@b.c"}, reflect.TypeOf(setterTarget{}))
+		if err != nil {
+			t.Fatalf("got error %s", err)
+		}
+		if want := (setterTarget{email: "a@b.c"}); !reflect.DeepEqual(got, want) {
+			t.Errorf("want %#v, got %#v", want, got)
+		}
+
+		_, err = c.MapToStruct(map[string]interface{}{"Email": "x"}, reflect.TypeOf(setterTarget{}))
+		const want = "conv.MapToStruct: error on calling the setter of 'Email': bad email"
+		if err == nil || err.Error() != want {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
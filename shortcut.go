@@ -14,6 +14,11 @@ func ConvertType(src interface{}, dstTyp reflect.Type) (interface{}, error) {
 	return _defaultConv.ConvertType(src, dstTyp)
 }
 
+// ConvertValue is equivalent to new(Conv).ConvertValue() .
+func ConvertValue(src reflect.Value, dstTyp reflect.Type) (reflect.Value, error) {
+	return _defaultConv.ConvertValue(src, dstTyp)
+}
+
 // Convert is equivalent to new(Conv).Convert() .
 func Convert(src interface{}, dstPtr interface{}) error {
 	return _defaultConv.Convert(src, dstPtr)
@@ -0,0 +1,49 @@
+package conv
+
+import (
+	"reflect"
+)
+
+var typeInfoCache syncMap
+
+// typeInfo holds the facts about a type which are checked on each conversion, such as whether the type implements
+// some interface. They don't depend on Conv.Conf , so they are computed once and cached, see getTypeInfo() .
+// The steps of the conversion which depend on Conv.Conf are still decided on each call, the steps depending on
+// the kinds of the types are compiled into a convPlan .
+type typeInfo struct {
+	// Whether IsSimpleType() returns true.
+	simple bool
+
+	// Whether the type implements encoding.TextMarshaler, and whether only the pointer to the type implements it.
+	textMarshaler, textMarshalerOnPtr bool
+
+	// Whether the pointer to the type implements encoding.TextUnmarshaler .
+	textUnmarshalerOnPtr bool
+
+	// Whether the type implements driver.Valuer, and whether only the pointer to the type implements it.
+	valuer, valuerOnPtr bool
+
+	// Whether the pointer to the type implements sql.Scanner .
+	scannerOnPtr bool
+}
+
+// getTypeInfo returns the typeInfo of the given type.
+func getTypeInfo(typ reflect.Type) *typeInfo {
+	if v, ok := typeInfoCache.Load(typ); ok {
+		return v.(*typeInfo)
+	}
+
+	ptrTyp := reflect.PtrTo(typ)
+	info := &typeInfo{
+		simple:               IsSimpleType(typ),
+		textMarshaler:        typ.Implements(typTextMarshaler),
+		textUnmarshalerOnPtr: ptrTyp.Implements(typTextUnmarshaler),
+		valuer:               typ.Implements(typDriverValuer),
+		scannerOnPtr:         ptrTyp.Implements(typSQLScanner),
+	}
+	info.textMarshalerOnPtr = !info.textMarshaler && ptrTyp.Implements(typTextMarshaler)
+	info.valuerOnPtr = !info.valuer && ptrTyp.Implements(typDriverValuer)
+
+	v, _ := typeInfoCache.LoadOrStore(typ, info)
+	return v.(*typeInfo)
+}
@@ -0,0 +1,36 @@
+package conv
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_getTypeInfo(t *testing.T) {
+	tests := []struct {
+		typ  reflect.Type
+		want typeInfo
+	}{
+		{reflect.TypeOf(0), typeInfo{simple: true}},
+		{reflect.TypeOf(time.Time{}), typeInfo{simple: true, textMarshaler: true, textUnmarshalerOnPtr: true}},
+		{reflect.TypeOf(textPoint{}), typeInfo{textMarshaler: true, textUnmarshalerOnPtr: true}},
+		{reflect.TypeOf(&textPoint{}), typeInfo{textMarshaler: true}},
+		{reflect.TypeOf(sql.NullString{}), typeInfo{valuer: true, scannerOnPtr: true}},
+		{reflect.TypeOf(&sql.NullString{}), typeInfo{valuer: true}},
+		{reflect.TypeOf(struct{}{}), typeInfo{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ.String(), func(t *testing.T) {
+			got := getTypeInfo(tt.typ)
+			if *got != tt.want {
+				t.Errorf("want %+v, got %+v", tt.want, *got)
+			}
+
+			// Cached.
+			if getTypeInfo(tt.typ) != got {
+				t.Errorf("should be cached")
+			}
+		})
+	}
+}
@@ -1,12 +1,18 @@
 package conv
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
 	"errors"
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+	"unicode"
 )
 
 //lint:ignore U1000 The alias of the empty interface. Go 1.18 defines this but in earlier versions we can't use it.
@@ -19,11 +25,28 @@ var (
 	typTime  = reflect.TypeOf(time.Time{})
 	zeroTime = time.Time{}
 
+	// The type of time.Duration .
+	typDuration = reflect.TypeOf(time.Duration(0))
+
 	// The type of map used when converting between structs and maps.
 	typStringMap = reflect.TypeOf(map[string]interface{}(nil))
 
+	// The type of string.
+	typString = reflect.TypeOf("")
+
 	// The type of the empty interface.
 	typEmptyInterface = reflect.TypeOf((*interface{})(nil)).Elem()
+
+	// The type of the error interface.
+	typError = reflect.TypeOf((*error)(nil)).Elem()
+
+	// The types of driver.Valuer and sql.Scanner .
+	typDriverValuer = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	typSQLScanner   = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+	// The types of encoding.TextMarshaler and encoding.TextUnmarshaler .
+	typTextMarshaler   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	typTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 )
 
 func init() {
@@ -77,6 +100,94 @@ func isKindComplex(k reflect.Kind) bool {
 	return k == reflect.Complex64 || k == reflect.Complex128
 }
 
+func isKindNumber(k reflect.Kind) bool {
+	return isKindInt(k) || isKindUint(k) || isKindFloat(k) || isKindComplex(k)
+}
+
+// valueOrZero returns reflect.ValueOf(v), or the zero value of the given type if v is nil.
+func valueOrZero(v interface{}, typ reflect.Type) reflect.Value {
+	if v == nil {
+		return reflect.Zero(typ)
+	}
+	return reflect.ValueOf(v)
+}
+
+// valueOrZeroValue returns v, or the zero value of the type if v is invalid.
+func valueOrZeroValue(v reflect.Value, typ reflect.Type) reflect.Value {
+	if !v.IsValid() {
+		return reflect.Zero(typ)
+	}
+	return v
+}
+
+// valueInterface returns v.Interface() , or nil if v is invalid.
+func valueInterface(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex() , but returns false instead of panicking if an embedded pointer
+// on the way is nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			for v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// sortMapKeys sorts the keys of a map: numbers are compared by their values, booleans are false first, other
+// values are compared by their string forms given by fmt.Sprint() .
+func sortMapKeys(keys []reflect.Value) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		for a.Kind() == reflect.Interface && !a.IsNil() {
+			a = a.Elem()
+		}
+		for b.Kind() == reflect.Interface && !b.IsNil() {
+			b = b.Elem()
+		}
+
+		if a.Kind() == b.Kind() {
+			switch k := a.Kind(); {
+			case isKindInt(k):
+				return a.Int() < b.Int()
+			case isKindUint(k):
+				return a.Uint() < b.Uint()
+			case isKindFloat(k):
+				return a.Float() < b.Float()
+			case k == reflect.Bool:
+				return !a.Bool() && b.Bool()
+			case k == reflect.String:
+				return a.String() < b.String()
+			}
+		}
+		return fmt.Sprint(valueInterface(a)) < fmt.Sprint(valueInterface(b))
+	})
+}
+
+// isByteSlice returns true if the type is a slice of bytes, e.g. []byte .
+func isByteSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// isStructOrStructPtr returns true if the type is a struct, or a pointer (of any depth) to a struct.
+func isStructOrStructPtr(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
 func errCantConvertTo(v interface{}, dstType string) error {
 	return fmt.Errorf("cannot convert %#v (%[1]T) to %s", v, dstType)
 }
@@ -186,3 +297,429 @@ func getFieldValue(val reflect.Value, index []int) (reflect.Value, error) {
 
 	return current.Field(index[ln-1]), nil
 }
+
+// isIntegerString returns true if the string is an integer in decimal, with an optional sign.
+func isIntegerString(s string) bool {
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// containsString returns true if the slice contains the given string.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// namedTimeToTime converts a value of a named time type, such as 'type Timestamp time.Time', to time.Time .
+// Other values are returned as is.
+func namedTimeToTime(v interface{}) interface{} {
+	t := reflect.TypeOf(v)
+	if t == nil || t == typTime || t.Kind() != reflect.Struct || !t.ConvertibleTo(typTime) {
+		return v
+	}
+	return reflect.ValueOf(v).Convert(typTime).Interface()
+}
+
+// isBoolOrBoolPtr returns true if the type is a bool, or a pointer (of any depth) to a bool.
+func isBoolOrBoolPtr(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// isPresenceOnly returns true if the value is nil or an empty string, i.e. a key with such a value only tells
+// the presence of the key.
+func isPresenceOnly(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.String && rv.Len() == 0
+}
+
+// isEmptyValue returns true if the value is the zero value of its type, or is an empty slice or map,
+// or is a pointer to an empty value.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+
+	case reflect.Ptr:
+		return v.IsNil() || isEmptyValue(v.Elem())
+	}
+	return v.IsZero()
+}
+
+// isJSONEmptyValue returns true if the value is empty by the rule of the 'omitempty' option of encoding/json:
+// false, 0, a nil pointer, a nil interface, or an empty string, slice, map or array. Structs are never empty.
+func isJSONEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Struct:
+		return false
+	}
+	return v.IsZero()
+}
+
+// deepCopyMap returns a deep copy of the map. Nested map[string]interface{} and []interface{} values are copied
+// recursively, other values are copied as is.
+func deepCopyMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		res[k] = deepCopyValue(v)
+	}
+	return res
+}
+
+// deepCopy returns a deep copy of the value. Maps, slices, arrays, pointers, interfaces and exported fields of
+// structs are copied recursively; other values, including unexported fields of structs, are copied as is.
+// Cycles and shared references are reproduced: the same map, slice or pointer in the source is copied once, and the
+// copy is shared in the result.
+func deepCopy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return newDeepCopier().copy(reflect.ValueOf(v)).Interface()
+}
+
+// deepCopier copies values for deepCopy(), it records the copied references to reproduce cycles.
+type deepCopier struct {
+	visited map[deepCopyRef]reflect.Value
+}
+
+// deepCopyRef identifies a map, a slice or a pointer. The length distinguishes slices sharing the same array.
+type deepCopyRef struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+func newDeepCopier() *deepCopier {
+	return &deepCopier{visited: make(map[deepCopyRef]reflect.Value)}
+}
+
+func (d *deepCopier) copy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		ref := deepCopyRef{v.Type(), v.Pointer(), 0}
+		if res, ok := d.visited[ref]; ok {
+			return res
+		}
+
+		res := reflect.MakeMapWithSize(v.Type(), v.Len())
+		d.visited[ref] = res
+		iter := v.MapRange()
+		for iter.Next() {
+			res.SetMapIndex(iter.Key(), d.copy(iter.Value()))
+		}
+		return res
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		ref := deepCopyRef{v.Type(), v.Pointer(), v.Len()}
+		if res, ok := d.visited[ref]; ok {
+			return res
+		}
+
+		res := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		d.visited[ref] = res
+		for i := 0; i < v.Len(); i++ {
+			res.Index(i).Set(d.copy(v.Index(i)))
+		}
+		return res
+
+	case reflect.Array:
+		res := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			res.Index(i).Set(d.copy(v.Index(i)))
+		}
+		return res
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+
+		ref := deepCopyRef{v.Type(), v.Pointer(), 0}
+		if res, ok := d.visited[ref]; ok {
+			return res
+		}
+
+		res := reflect.New(v.Type().Elem())
+		d.visited[ref] = res
+		res.Elem().Set(d.copy(v.Elem()))
+		return res
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		res := reflect.New(v.Type()).Elem()
+		res.Set(d.copy(v.Elem()))
+		return res
+
+	case reflect.Struct:
+		res := reflect.New(v.Type()).Elem()
+		res.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := res.Field(i); f.CanSet() {
+				f.Set(d.copy(v.Field(i)))
+			}
+		}
+		return res
+	}
+	return v
+}
+
+func deepCopyValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		return deepCopyMap(vv)
+
+	case []interface{}:
+		if vv == nil {
+			return vv
+		}
+
+		res := make([]interface{}, len(vv))
+		for i, e := range vv {
+			res[i] = deepCopyValue(e)
+		}
+		return res
+	}
+	return v
+}
+
+// parseScientificInt returns the float64 parsed from the string if it is not an integer but is a float, such as
+// '1e3', see Conv.Conf.AcceptScientificInt . Otherwise the string is returned as is.
+func parseScientificInt(s string, unsigned bool) interface{} {
+	var err error
+	if unsigned {
+		_, err = strconv.ParseUint(s, 0, 64)
+	} else {
+		_, err = strconv.ParseInt(s, 0, 64)
+	}
+
+	if err == nil {
+		return s
+	}
+
+	if f, e := strconv.ParseFloat(s, 64); e == nil {
+		return f
+	}
+	return s
+}
+
+// isISO8601Duration returns true if the string looks like an ISO8601 duration, i.e. it starts with 'P' with
+// an optional sign.
+func isISO8601Duration(s string) bool {
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	return s != "" && s[0] == 'P'
+}
+
+// parseISO8601Duration parses an ISO8601 duration, see Conv.Conf.ISO8601Durations for the supported format.
+func parseISO8601Duration(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid ISO8601 duration %q", s)
+
+	v := s
+	neg := false
+	if v != "" && (v[0] == '-' || v[0] == '+') {
+		neg = v[0] == '-'
+		v = v[1:]
+	}
+
+	if len(v) < 2 || v[0] != 'P' {
+		return 0, invalid
+	}
+	v = v[1:]
+
+	var res time.Duration
+	inTime := false
+	lastOrder := -1
+	fractional := false
+	for v != "" {
+		if v[0] == 'T' {
+			if inTime || len(v) == 1 {
+				return 0, invalid
+			}
+			inTime = true
+			v = v[1:]
+			continue
+		}
+
+		n := 0
+		for n < len(v) && (v[n] >= '0' && v[n] <= '9' || v[n] == '.' || v[n] == ',') {
+			n++
+		}
+		if n == 0 || n == len(v) {
+			return 0, invalid
+		}
+
+		// Only the last number can have a fractional part.
+		if fractional {
+			return 0, invalid
+		}
+
+		num, designator := v[:n], v[n]
+		v = v[n+1:]
+
+		var unit time.Duration
+		var order int
+		switch {
+		case !inTime && designator == 'W':
+			unit, order = 7*24*time.Hour, 0
+		case !inTime && designator == 'D':
+			unit, order = 24*time.Hour, 1
+		case inTime && designator == 'H':
+			unit, order = time.Hour, 2
+		case inTime && designator == 'M':
+			unit, order = time.Minute, 3
+		case inTime && designator == 'S':
+			unit, order = time.Second, 4
+		case !inTime && (designator == 'Y' || designator == 'M'):
+			return 0, fmt.Errorf("years and months are not supported in the ISO8601 duration %q", s)
+		default:
+			return 0, invalid
+		}
+
+		if order <= lastOrder {
+			return 0, invalid
+		}
+		lastOrder = order
+
+		d, frac, ok := parseISO8601DurationPart(num, unit)
+		if !ok {
+			return 0, invalid
+		}
+		fractional = frac
+
+		if res > math.MaxInt64-d {
+			return 0, fmt.Errorf("the ISO8601 duration %q overflows time.Duration", s)
+		}
+		res += d
+	}
+
+	if neg {
+		res = -res
+	}
+	return res, nil
+}
+
+// parseISO8601DurationPart parses the number of a part of an ISO8601 duration, returns the duration of the part
+// and whether the number has a fractional part.
+func parseISO8601DurationPart(num string, unit time.Duration) (time.Duration, bool, bool) {
+	num = strings.Replace(num, ",", ".", 1)
+	intPart, fracPart := num, ""
+	if dot := strings.IndexByte(num, '.'); dot >= 0 {
+		intPart, fracPart = num[:dot], num[dot+1:]
+		if fracPart == "" {
+			return 0, false, false
+		}
+	}
+
+	n, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || n > math.MaxInt64/int64(unit) {
+		return 0, false, false
+	}
+	res := time.Duration(n) * unit
+
+	if fracPart != "" {
+		f, err := strconv.ParseFloat("0."+fracPart, 64)
+		if err != nil {
+			return 0, false, false
+		}
+		res += time.Duration(f * float64(unit))
+	}
+	return res, fracPart != "", true
+}
+
+// compareNumbers returns -1 if less is true, 1 if greater is true, otherwise 0.
+func compareNumbers(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parseLenientComplex parses a complex number in the formats described by Conv.Conf.LenientComplex .
+func parseLenientComplex(s string) (complex128, bool) {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+
+	// The pair form: (re,im).
+	if len(s) >= 2 && s[0] == '(' && s[len(s)-1] == ')' && strings.Contains(s, ",") {
+		parts := strings.Split(s[1:len(s)-1], ",")
+		if len(parts) != 2 {
+			return 0, false
+		}
+
+		re, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return 0, false
+		}
+
+		im, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return 0, false
+		}
+		return complex(re, im), true
+	}
+
+	if strings.HasSuffix(s, "j") || strings.HasSuffix(s, "J") {
+		s = s[:len(s)-1] + "i"
+	}
+
+	// Add the omitted coefficient, e.g. '3-i' -> '3-1i'.
+	if strings.HasSuffix(s, "i") {
+		n := len(s)
+		if n == 1 || s[n-2] == '+' || s[n-2] == '-' {
+			s = s[:n-1] + "1i"
+		}
+	}
+
+	res, err := strconv.ParseComplex(s, 128)
+	return res, err == nil
+}
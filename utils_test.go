@@ -209,3 +209,120 @@ func Test_getFieldValue(t *testing.T) {
 		}
 	})
 }
+
+func Test_parseLenientComplex(t *testing.T) {
+	tests := []struct {
+		s    string
+		want complex128
+		ok   bool
+	}{
+		{"3+4i", complex(3, 4), true},
+		{"3", complex(3, 0), true},
+		{"3i", complex(0, 3), true},
+		{"(3+4i)", complex(3, 4), true},
+		{" 3 + 4i ", complex(3, 4), true},
+		{"3+4j", complex(3, 4), true},
+		{"3-J", complex(3, -1), true},
+		{"i", complex(0, 1), true},
+		{"-i", complex(0, -1), true},
+		{"(3,4)", complex(3, 4), true},
+		{"( -1.5 , 2e1 )", complex(-1.5, 20), true},
+		{"", 0, false},
+		{"(3,4,5)", 0, false},
+		{"(a,4)", 0, false},
+		{"(3,b)", 0, false},
+		{"3+4k", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, ok := parseLenientComplex(tt.s)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("parseLenientComplex() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func Test_deepCopy(t *testing.T) {
+	type inner struct {
+		N    []int
+		note *string
+	}
+	type T struct {
+		M   map[string][]int
+		P   *inner
+		A   [2][]int
+		I   interface{}
+		Nil []int
+	}
+
+	note := "n"
+	src := T{
+		M: map[string][]int{"a": {1}},
+		P: &inner{N: []int{2}, note: &note},
+		A: [2][]int{{3}, {4}},
+		I: []string{"x"},
+	}
+	got := deepCopy(src).(T)
+	if !reflect.DeepEqual(got, src) {
+		t.Fatalf("want %v, got %v", src, got)
+	}
+
+	src.M["a"][0] = 0
+	src.P.N[0] = 0
+	src.A[0][0] = 0
+	src.I.([]string)[0] = ""
+	if got.M["a"][0] != 1 || got.P.N[0] != 2 || got.A[0][0] != 3 || got.I.([]string)[0] != "x" {
+		t.Errorf("should not share the storage: %v", got)
+	}
+
+	// Unexported fields are copied shallowly.
+	if got.P.note != &note {
+		t.Errorf("unexported fields should be copied as is")
+	}
+
+	if deepCopy(nil) != nil {
+		t.Errorf("want nil")
+	}
+}
+
+func Test_deepCopy_cycles(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+		Refs map[string]interface{}
+	}
+
+	a := &node{Name: "a"}
+	b := &node{Name: "b", Next: a}
+	a.Next = b
+	a.Refs = map[string]interface{}{"self": a, "b": b}
+	a.Refs["refs"] = a.Refs
+
+	got := deepCopy(a).(*node)
+	if got == a || got.Next == b || got.Name != "a" || got.Next.Name != "b" {
+		t.Fatalf("unexpected copy %v", got)
+	}
+
+	// The cycles are reproduced in the copy.
+	if got.Next.Next != got {
+		t.Errorf("the cycle a->b->a is not reproduced")
+	}
+	if got.Refs["self"] != got || got.Refs["b"] != got.Next {
+		t.Errorf("the references in the map are not reproduced")
+	}
+	if reflect.ValueOf(got.Refs["refs"]).Pointer() != reflect.ValueOf(got.Refs).Pointer() {
+		t.Errorf("the map referring to itself is not reproduced")
+	}
+	if reflect.ValueOf(got.Refs).Pointer() == reflect.ValueOf(a.Refs).Pointer() {
+		t.Errorf("the map should be copied")
+	}
+
+	// Shared slices are copied once, slices of different lengths are copied separately.
+	s := []int{1, 2, 3}
+	pair := deepCopy([][]int{s, s, s[:2]}).([][]int)
+	pair[0][0] = 0
+	if pair[1][0] != 0 || pair[2][0] != 1 || s[0] != 1 {
+		t.Errorf("unexpected copy %v", pair)
+	}
+}